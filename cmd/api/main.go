@@ -2,25 +2,55 @@ package main
 
 import (
 	"log"
+	"net"
 	"net/http"
 
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
 	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
+	grpctransport "github.com/EyalShahaf/temporal-seats/internal/transport/grpc"
 	httptransport "github.com/EyalShahaf/temporal-seats/internal/transport/http"
 	"go.temporal.io/sdk/client"
 )
 
 func main() {
 	// 1. Create Temporal client
+	cfg := config.Load()
 	temporalClient, err := client.Dial(client.Options{
-		HostPort: "localhost:7233",
+		HostPort:  cfg.Temporal.HostPort,
+		Namespace: cfg.Temporal.Namespace,
 	})
 	if err != nil {
 		log.Fatalf("unable to create Temporal client: %v", err)
 	}
 	defer temporalClient.Close()
 
-	cfg := config.Load()
-	router := httptransport.NewRouter(cfg, temporalClient)
+	authn, err := auth.New(cfg.Auth)
+	if err != nil {
+		log.Fatalf("invalid authentication config: %v", err)
+	}
+	if authn == nil {
+		log.Println("WARNING: authentication is disabled; any caller can act on any order")
+	}
+	// Both transports share one service, so that every watcher sees every
+	// change, and one set of rate limits, so that every caller has one budget.
+	svc := orders.NewService(temporalClient, cfg)
+	limits := ratelimit.New(cfg.RateLimit)
+	router := httptransport.NewRouter(cfg, svc, limits, authn)
+
+	// 2. Serve the gRPC ReservationService next to the HTTP API
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		log.Fatalf("could not listen for gRPC: %v", err)
+	}
+	grpcServer := grpctransport.NewServer(svc, limits, authn)
+	go func() {
+		log.Println("gRPC server starting on", cfg.GRPCAddr)
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("could not start gRPC server: %v", err)
+		}
+	}()
 
 	log.Println("API server starting on :8080")
 	if err := http.ListenAndServe(":8080", router); err != nil {
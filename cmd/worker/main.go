@@ -10,8 +10,11 @@ import (
 	"time"
 
 	"github.com/EyalShahaf/temporal-seats/internal/activities"
+	"github.com/EyalShahaf/temporal-seats/internal/config"
 	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/waitingroom"
 	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
 	"google.golang.org/grpc"
@@ -29,15 +32,17 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	// Create client with connection validation
+	// Create client with connection validation; activities share it.
+	cfg := config.Load()
 	c, err := client.Dial(client.Options{
-		HostPort: "localhost:7233",
+		HostPort:  cfg.Temporal.HostPort,
+		Namespace: cfg.Temporal.Namespace,
 		ConnectionOptions: client.ConnectionOptions{
 			DialOptions: []grpc.DialOption{grpc.WithBlock()},
 		},
 	})
 	if err != nil {
-		log.Fatalf("Temporal server not reachable at localhost:7233: %v", err)
+		log.Fatalf("Temporal server not reachable at %s: %v", cfg.Temporal.HostPort, err)
 	}
 	defer c.Close()
 
@@ -51,10 +56,15 @@ func main() {
 		defer wg.Done()
 		w := worker.New(c, "order-tq", worker.Options{})
 		w.RegisterWorkflow(workflows.OrderOrchestrationWorkflow)
-		w.RegisterActivity(activities.ValidatePaymentActivity)
+		if cfg.PaymentTestCodes {
+			log.Println("WARNING: accepting the fixed E2E payment codes (PAYMENT_TEST_CODES)")
+			w.RegisterActivityWithOptions(activities.ValidatePaymentWithTestCodes, activity.RegisterOptions{Name: "ValidatePaymentActivity"})
+		} else {
+			w.RegisterActivity(activities.ValidatePaymentActivity)
+		}
 		w.RegisterActivity(activities.ConfirmOrderActivity)
 		w.RegisterActivity(activities.FailOrderActivity)
-		w.RegisterActivity(activities.SeatSignalActivity)
+		w.RegisterActivity(activities.NewSeatActivities(c))
 		log.Println("Starting Order Worker")
 
 		// Graceful shutdown
@@ -74,6 +84,7 @@ func main() {
 		defer wg.Done()
 		w := worker.New(c, "seat-tq", worker.Options{})
 		w.RegisterWorkflow(seat.SeatEntityWorkflow)
+		w.RegisterWorkflow(waitingroom.WaitingRoomWorkflow)
 		log.Println("Starting Seat Worker")
 
 		// Graceful shutdown
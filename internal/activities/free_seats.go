@@ -0,0 +1,18 @@
+package activities
+
+import (
+	"context"
+
+	seat "github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+)
+
+type FreeSeatsInput struct {
+	FlightID string
+}
+
+// FreeSeatsActivity lists the seats of a flight that no order holds and that
+// are neither confirmed nor blocked, so the order workflow can assign a block
+// of adjacent seats.
+func (a *SeatActivities) FreeSeatsActivity(ctx context.Context, in FreeSeatsInput) ([]string, error) {
+	return seat.FreeSeats(ctx, a.temporal, in.FlightID)
+}
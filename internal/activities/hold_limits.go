@@ -0,0 +1,21 @@
+package activities
+
+import (
+	"context"
+
+	seat "github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+)
+
+type CountHeldSeatsInput struct {
+	FlightID string
+	Customer string
+	// ExcludeOrderID is the order asking; its own holds are not counted.
+	ExcludeOrderID string
+}
+
+// CountHeldSeatsActivity counts the seats of a flight that other orders of the
+// same customer currently hold, so the order workflow can enforce the
+// per-customer hold limit.
+func (a *SeatActivities) CountHeldSeatsActivity(ctx context.Context, in CountHeldSeatsInput) (int, error) {
+	return seat.CountHeldSeats(ctx, a.temporal, in.FlightID, in.Customer, in.ExcludeOrderID)
+}
@@ -7,11 +7,32 @@ import (
 	"time"
 
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Fixed payment codes accepted by ValidatePaymentWithTestCodes, so end-to-end
+// tests get a deterministic outcome instead of the simulated gateway failures.
+const (
+	TestPaymentCodeApproved = "00000"
+	TestPaymentCodeDeclined = "99999"
 )
 
 // ValidatePaymentActivity simulates a payment validation process.
 // It validates payment codes and has a 15% chance of failing to simulate a flaky payment gateway.
 func ValidatePaymentActivity(ctx context.Context, orderID string, paymentCode string) (string, error) {
+	return validatePayment(ctx, orderID, paymentCode, false)
+}
+
+// ValidatePaymentWithTestCodes is ValidatePaymentActivity that also honours
+// TestPaymentCodeApproved and TestPaymentCodeDeclined. Workers register it
+// under the name of ValidatePaymentActivity only when test codes are enabled
+// (PAYMENT_TEST_CODES); never in production, where they would let anyone
+// skip the payment checks.
+func ValidatePaymentWithTestCodes(ctx context.Context, orderID string, paymentCode string) (string, error) {
+	return validatePayment(ctx, orderID, paymentCode, true)
+}
+
+func validatePayment(ctx context.Context, orderID string, paymentCode string, testCodes bool) (string, error) {
 	logger := activity.GetLogger(ctx)
 	logger.Info("Validating payment", "OrderID", orderID, "PaymentCode", paymentCode)
 
@@ -21,13 +42,17 @@ func ValidatePaymentActivity(ctx context.Context, orderID string, paymentCode st
 		return "", errors.New("invalid payment code")
 	}
 
-	// Deterministic success for E2E tests
-	if paymentCode == "E2E-OK" {
+	// Deterministic outcomes for E2E tests
+	if testCodes && paymentCode == TestPaymentCodeApproved {
 		logger.Info("E2E deterministic payment - always succeed", "OrderID", orderID, "PaymentCode", paymentCode)
 		time.Sleep(1 * time.Second)
 		logger.Info("Payment validated successfully", "OrderID", orderID)
 		return "PAYMENT_SUCCESSFUL", nil
 	}
+	if testCodes && paymentCode == TestPaymentCodeDeclined {
+		logger.Info("E2E deterministic payment - always declined", "OrderID", orderID, "PaymentCode", paymentCode)
+		return "", temporal.NewNonRetryableApplicationError("payment declined", "PaymentDeclined", nil)
+	}
 
 	logger.Info("Payment code is valid, proceeding with validation", "OrderID", orderID, "PaymentCode", paymentCode)
 
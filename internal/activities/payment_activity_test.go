@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
 )
 
@@ -242,3 +244,30 @@ func TestAllActivitiesRegistered(t *testing.T) {
 		env.RegisterActivity(FailOrderActivity)
 	})
 }
+
+// TestValidatePaymentActivity_TestCodesNeedOptIn tests that the fixed E2E codes
+// only decide the outcome of ValidatePaymentWithTestCodes
+func TestValidatePaymentActivity_TestCodesNeedOptIn(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(ValidatePaymentActivity)
+	env.RegisterActivity(ValidatePaymentWithTestCodes)
+
+	result, err := env.ExecuteActivity(ValidatePaymentWithTestCodes, "order-e2e", TestPaymentCodeApproved)
+	require.NoError(t, err)
+	var paymentResult string
+	require.NoError(t, result.Get(&paymentResult))
+	assert.Equal(t, "PAYMENT_SUCCESSFUL", paymentResult)
+
+	_, err = env.ExecuteActivity(ValidatePaymentWithTestCodes, "order-e2e", TestPaymentCodeDeclined)
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, err, &appErr)
+	assert.True(t, appErr.NonRetryable())
+	assert.Equal(t, "PaymentDeclined", appErr.Type())
+
+	// Without the opt-in the declined code is an ordinary payment code.
+	_, err = env.ExecuteActivity(ValidatePaymentActivity, "order-prod", TestPaymentCodeDeclined)
+	if err != nil {
+		assert.NotContains(t, err.Error(), "payment declined")
+	}
+}
@@ -0,0 +1,103 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"go.temporal.io/sdk/activity"
+
+	seat "github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+)
+
+// maxBatchInFlight bounds the seat commands a SeatBatchActivity sends at once.
+const maxBatchInFlight = 10
+
+type SeatBatchInput struct {
+	FlightID string
+	SeatIDs  []string
+	// Cmd is sent to every seat. HoldTokens, if set, gives each seat the token
+	// of its hold instead of Cmd.HoldToken.
+	Cmd           seat.Command
+	HoldTokens    map[string]int64
+	SeatTaskQueue string // Optional: override task queue
+}
+
+// SeatBatchResult is what came of the commands of a SeatBatchActivity, by
+// seat ID. It is also the heartbeat of the activity, listing the seats that
+// acknowledged their command so far.
+type SeatBatchResult struct {
+	Results map[string]seat.CommandResult
+}
+
+// SeatBatchActivity sends the same command to many seats of a flight
+// concurrently and returns what came of each. Every acknowledged command is
+// recorded in the heartbeat, so that a retry only sends the commands that
+// were not. If a command fails, the activity fails once the others are done
+// and its retry resends the failed ones. Once ctx is done no more commands are
+// sent, and the seats not sent yet count as failed.
+func (a *SeatActivities) SeatBatchActivity(ctx context.Context, in SeatBatchInput) (SeatBatchResult, error) {
+	progress := SeatBatchResult{Results: map[string]seat.CommandResult{}}
+	if activity.HasHeartbeatDetails(ctx) {
+		var previous SeatBatchResult
+		if err := activity.GetHeartbeatDetails(ctx, &previous); err == nil {
+			maps.Copy(progress.Results, previous.Results)
+		}
+	}
+
+	var pending []string
+	for _, seatID := range in.SeatIDs {
+		if _, done := progress.Results[seatID]; !done {
+			pending = append(pending, seatID)
+		}
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, maxBatchInFlight)
+	)
+send:
+	for i, seatID := range pending {
+		cmd := in.Cmd
+		if token, ok := in.HoldTokens[seatID]; ok {
+			cmd.HoldToken = token
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// The seats not sent yet fail too, so that a retry sends them.
+			mu.Lock()
+			for _, unsent := range pending[i:] {
+				errs = append(errs, fmt.Errorf("seat %s: %w", unsent, ctx.Err()))
+			}
+			mu.Unlock()
+			break send
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, err := a.sendCommand(ctx, in.FlightID, seatID, in.SeatTaskQueue, cmd)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("seat %s: %w", seatID, err))
+				return
+			}
+			progress.Results[seatID] = result
+			activity.RecordHeartbeat(ctx, SeatBatchResult{Results: maps.Clone(progress.Results)})
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return progress, fmt.Errorf("%d of %d seat commands failed: %w", len(errs), len(in.SeatIDs), errors.Join(errs...))
+	}
+	return progress, nil
+}
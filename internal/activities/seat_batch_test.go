@@ -0,0 +1,133 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/worker"
+
+	seat "github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+)
+
+// expectSeatCommand expects one command sent to the entity of seatID, which
+// returns result or fails with err.
+func (s *SeatActivitiesTestSuite) expectSeatCommand(seatID string, token int64, result seat.CommandResult, err error) {
+	start := &startOperation{seatID: seatID}
+	s.client.On("NewWithStartWorkflowOperation", mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+		return opts.ID == seat.WorkflowID("FL1", seatID)
+	}), mock.Anything, "FL1", seatID, (*seat.SeatPersistedState)(nil)).Return(start).Once()
+
+	handle := &mocks.WorkflowUpdateHandle{}
+	handle.On("Get", mock.Anything, mock.Anything).Return(func(_ context.Context, valuePtr interface{}) error {
+		*valuePtr.(*seat.CommandResult) = result
+		return err
+	}).Once()
+	s.client.On("UpdateWithStartWorkflow", mock.Anything, mock.MatchedBy(func(opts client.UpdateWithStartWorkflowOptions) bool {
+		cmd := opts.UpdateOptions.Args[0].(seat.Command)
+		return opts.StartWorkflowOperation == start && cmd.HoldToken == token
+	})).Return(handle, nil).Once()
+}
+
+// startOperation tells the start operations of the mocked seats apart.
+type startOperation struct {
+	client.WithStartWorkflowOperation
+	seatID string
+}
+
+func (s *SeatActivitiesTestSuite) TestSeatBatchActivity_CommandsEverySeat() {
+	applied := seat.CommandResult{Outcome: seat.OutcomeApplied}
+	s.expectSeatCommand("1A", 3, applied, nil)
+	s.expectSeatCommand("1B", 5, applied, nil)
+	s.expectSeatCommand("1C", 0, seat.CommandResult{Outcome: seat.OutcomeIgnored, Reason: "seat is not held by this order"}, nil)
+
+	val, err := s.env.ExecuteActivity(s.activities.SeatBatchActivity, SeatBatchInput{
+		FlightID:   "FL1",
+		SeatIDs:    []string{"1A", "1B", "1C"},
+		Cmd:        seat.Command{Type: seat.CmdConfirm, OrderID: "o-1"},
+		HoldTokens: map[string]int64{"1A": 3, "1B": 5},
+	})
+	s.Require().NoError(err)
+	var result SeatBatchResult
+	s.Require().NoError(val.Get(&result))
+	s.Len(result.Results, 3)
+	s.Equal(seat.OutcomeIgnored, result.Results["1C"].Outcome)
+}
+
+func (s *SeatActivitiesTestSuite) TestSeatBatchActivity_ResumesFromHeartbeat() {
+	// A previous attempt got through to 1A; only 1B and 1C are sent again.
+	s.env.SetHeartbeatDetails(SeatBatchResult{Results: map[string]seat.CommandResult{
+		"1A": {Outcome: seat.OutcomeApplied, HoldToken: 1},
+	}})
+	s.expectSeatCommand("1B", 0, seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 2}, nil)
+	s.expectSeatCommand("1C", 0, seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 3}, nil)
+
+	val, err := s.env.ExecuteActivity(s.activities.SeatBatchActivity, SeatBatchInput{
+		FlightID: "FL1",
+		SeatIDs:  []string{"1A", "1B", "1C"},
+		Cmd:      seat.Command{Type: seat.CmdHold, OrderID: "o-1"},
+	})
+	s.Require().NoError(err)
+	var result SeatBatchResult
+	s.Require().NoError(val.Get(&result))
+	s.Equal(map[string]int64{"1A": 1, "1B": 2, "1C": 3}, map[string]int64{
+		"1A": result.Results["1A"].HoldToken, "1B": result.Results["1B"].HoldToken, "1C": result.Results["1C"].HoldToken,
+	})
+}
+
+func (s *SeatActivitiesTestSuite) TestSeatBatchActivity_HeartbeatsAcknowledgedSeats() {
+	var heartbeat SeatBatchResult
+	s.env.SetOnActivityHeartbeatListener(func(_ *activity.Info, details converter.EncodedValues) {
+		s.Require().NoError(details.Get(&heartbeat))
+	})
+	applied := seat.CommandResult{Outcome: seat.OutcomeApplied}
+	s.expectSeatCommand("1A", 0, applied, nil)
+	s.expectSeatCommand("1B", 0, seat.CommandResult{}, errors.New("update timed out"))
+	s.expectSeatCommand("1C", 0, applied, nil)
+
+	_, err := s.env.ExecuteActivity(s.activities.SeatBatchActivity, SeatBatchInput{
+		FlightID: "FL1",
+		SeatIDs:  []string{"1A", "1B", "1C"},
+		Cmd:      seat.Command{Type: seat.CmdRelease, OrderID: "o-1"},
+	})
+	s.ErrorContains(err, "1 of 3 seat commands failed")
+	s.ErrorContains(err, "seat 1B: update timed out")
+
+	// The retry picks up from the last heartbeat, without 1B.
+	s.Equal(map[string]seat.CommandResult{"1A": applied, "1C": applied}, heartbeat.Results)
+}
+
+func (s *SeatActivitiesTestSuite) TestSeatBatchActivity_StopsSendingWhenCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.env.SetWorkerOptions(worker.Options{BackgroundActivityContext: ctx})
+
+	// The activity is cancelled while a full batch of commands is in flight;
+	// the seat after them is never sent.
+	var seatIDs []string
+	for i := range maxBatchInFlight + 1 {
+		seatIDs = append(seatIDs, "1"+string(rune('A'+i)))
+	}
+	var inFlight atomic.Int32
+	s.client.On("NewWithStartWorkflowOperation", mock.Anything, mock.Anything, "FL1", mock.Anything, mock.Anything).
+		Return(nil).Times(maxBatchInFlight)
+	s.client.On("UpdateWithStartWorkflow", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		if inFlight.Add(1) == maxBatchInFlight {
+			cancel()
+		}
+		<-args.Get(0).(context.Context).Done()
+	}).Return(nil, context.Canceled).Times(maxBatchInFlight)
+
+	_, err := s.env.ExecuteActivity(s.activities.SeatBatchActivity, SeatBatchInput{
+		FlightID: "FL1",
+		SeatIDs:  seatIDs,
+		Cmd:      seat.Command{Type: seat.CmdRelease, OrderID: "o-1"},
+	})
+	s.ErrorContains(err, "11 of 11 seat commands failed")
+	s.ErrorContains(err, "seat 1K: context canceled")
+}
@@ -2,14 +2,25 @@ package activities
 
 import (
 	"context"
-	"os"
-	"time"
 
+	enumspb "go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/client"
 
 	seat "github.com/EyalShahaf/temporal-seats/internal/entities/seat"
 )
 
+// SeatActivities are the activities that talk to seat entities. They share the
+// Temporal client of the worker that runs them; register them with
+// RegisterActivity(NewSeatActivities(c)), which names each after its method.
+type SeatActivities struct {
+	temporal client.Client
+}
+
+// NewSeatActivities returns the seat activities, which use c to reach the seat entities.
+func NewSeatActivities(c client.Client) *SeatActivities {
+	return &SeatActivities{temporal: c}
+}
+
 type SeatSignalInput struct {
 	FlightID      string
 	SeatID        string
@@ -17,43 +28,35 @@ type SeatSignalInput struct {
 	SeatTaskQueue string // Optional: override task queue
 }
 
-func SeatSignalActivity(ctx context.Context, in SeatSignalInput) error {
-	host := os.Getenv("TEMPORAL_HOSTPORT")
-	if host == "" {
-		host = "localhost:7233"
-	}
-	ns := os.Getenv("TEMPORAL_NAMESPACE")
-	if ns == "" {
-		ns = "default"
-	}
-	tq := in.SeatTaskQueue
-	if tq == "" {
-		tq = "seat-tq"
-	}
+// SeatSignalActivity sends a command to a seat entity, starting it if needed,
+// and returns what came of it, including the token of the order's hold.
+func (a *SeatActivities) SeatSignalActivity(ctx context.Context, in SeatSignalInput) (seat.CommandResult, error) {
+	return a.sendCommand(ctx, in.FlightID, in.SeatID, in.SeatTaskQueue, in.Cmd)
+}
 
-	c, err := client.Dial(client.Options{HostPort: host, Namespace: ns})
-	if err != nil {
-		return err
+// sendCommand sends cmd to the entity of a seat with the Command update,
+// starting the entity on taskQueue (seat-tq when empty) if needed.
+func (a *SeatActivities) sendCommand(ctx context.Context, flightID, seatID, taskQueue string, cmd seat.Command) (seat.CommandResult, error) {
+	opts := seat.StartOptions(flightID, seatID)
+	if taskQueue != "" {
+		opts.TaskQueue = taskQueue
 	}
-	defer c.Close()
-
-	wfID := "seat::" + in.FlightID + "::" + in.SeatID
-
-	// Start if not running, else just signal.
-	_, err = c.SignalWithStartWorkflow(
-		ctx,
-		wfID,   // WorkflowID
-		"cmd",  // signal name
-		in.Cmd, // signal payload
-		client.StartWorkflowOptions{
-			ID:        wfID,
-			TaskQueue: tq,
-			// very long-lived entity
-			WorkflowExecutionTimeout: 3650 * 24 * time.Hour, // ~10y
-			WorkflowRunTimeout:       365 * 24 * time.Hour,  // rotate via ContinueAsNew
+	// Start if not running, else just update.
+	opts.WorkflowIDConflictPolicy = enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING
+
+	start := a.temporal.NewWithStartWorkflowOperation(opts, seat.SeatEntityWorkflow,
+		flightID, seatID, (*seat.SeatPersistedState)(nil)) // entity workflow args
+	handle, err := a.temporal.UpdateWithStartWorkflow(ctx, client.UpdateWithStartWorkflowOptions{
+		StartWorkflowOperation: start,
+		UpdateOptions: client.UpdateWorkflowOptions{
+			UpdateName:   seat.CommandUpdate,
+			Args:         []interface{}{cmd},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
 		},
-		seat.SeatEntityWorkflow,
-		in.FlightID, in.SeatID, (*seat.SeatPersistedState)(nil), // entity workflow args
-	)
-	return err
+	})
+	var result seat.CommandResult
+	if err == nil {
+		err = handle.Get(ctx, &result)
+	}
+	return result, err
 }
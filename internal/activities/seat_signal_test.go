@@ -0,0 +1,145 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/mocks"
+	"go.temporal.io/sdk/testsuite"
+
+	seat "github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+)
+
+type SeatActivitiesTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+	env        *testsuite.TestActivityEnvironment
+	client     *mocks.Client
+	activities *SeatActivities
+}
+
+func (s *SeatActivitiesTestSuite) SetupTest() {
+	s.client = &mocks.Client{}
+	s.env = s.NewTestActivityEnvironment()
+	s.activities = NewSeatActivities(s.client)
+	s.env.RegisterActivity(s.activities)
+}
+
+func (s *SeatActivitiesTestSuite) TearDownTest() {
+	s.client.AssertExpectations(s.T())
+}
+
+func TestSeatActivitiesTestSuite(t *testing.T) {
+	suite.Run(t, new(SeatActivitiesTestSuite))
+}
+
+func (s *SeatActivitiesTestSuite) TestSeatSignalActivity_UpdatesWithStart() {
+	cmd := seat.Command{Type: seat.CmdHold, OrderID: "o-1", TTL: time.Minute}
+	expiresAt := time.Date(2025, 3, 1, 12, 1, 0, 0, time.UTC)
+
+	s.client.On("NewWithStartWorkflowOperation", mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+		return opts.ID == "seat::FL1::1A" && opts.TaskQueue == "seat-tq" &&
+			opts.WorkflowIDConflictPolicy == enumspb.WORKFLOW_ID_CONFLICT_POLICY_USE_EXISTING
+	}), mock.Anything, "FL1", "1A", (*seat.SeatPersistedState)(nil)).Return(nil).Once()
+
+	handle := &mocks.WorkflowUpdateHandle{}
+	handle.On("Get", mock.Anything, mock.Anything).Return(func(_ context.Context, valuePtr interface{}) error {
+		*valuePtr.(*seat.CommandResult) = seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 4, ExpiresAt: expiresAt}
+		return nil
+	}).Once()
+	s.client.On("UpdateWithStartWorkflow", mock.Anything, mock.MatchedBy(func(opts client.UpdateWithStartWorkflowOptions) bool {
+		u := opts.UpdateOptions
+		return u.UpdateName == seat.CommandUpdate && u.WaitForStage == client.WorkflowUpdateStageCompleted &&
+			len(u.Args) == 1 && u.Args[0] == cmd
+	})).Return(handle, nil).Once()
+
+	val, err := s.env.ExecuteActivity(s.activities.SeatSignalActivity, SeatSignalInput{FlightID: "FL1", SeatID: "1A", Cmd: cmd})
+	s.Require().NoError(err)
+	var result seat.CommandResult
+	s.Require().NoError(val.Get(&result))
+	s.Equal(seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 4, ExpiresAt: expiresAt}, result)
+	handle.AssertExpectations(s.T())
+}
+
+func (s *SeatActivitiesTestSuite) TestSeatSignalActivity_ReturnsUpdateErrors() {
+	s.client.On("NewWithStartWorkflowOperation", mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+		return opts.TaskQueue == "seat-tq-canary"
+	}), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	s.client.On("UpdateWithStartWorkflow", mock.Anything, mock.Anything).Return(nil, errors.New("frontend unavailable")).Once()
+
+	_, err := s.env.ExecuteActivity(s.activities.SeatSignalActivity, SeatSignalInput{
+		FlightID: "FL1", SeatID: "1A", Cmd: seat.Command{Type: seat.CmdRelease, OrderID: "o-1"}, SeatTaskQueue: "seat-tq-canary",
+	})
+	s.ErrorContains(err, "frontend unavailable")
+}
+
+// expectSeatStates answers GetState queries of seat entity workflows with
+// states; other seats fail with errs, or do not exist.
+func (s *SeatActivitiesTestSuite) expectSeatStates(states map[string]seat.SeatState, errs map[string]error) {
+	s.client.On("QueryWorkflow", mock.Anything, mock.Anything, "", "GetState").Return(
+		func(_ context.Context, workflowID, _, _ string, _ ...interface{}) (converter.EncodedValue, error) {
+			if err, ok := errs[workflowID]; ok {
+				return nil, err
+			}
+			state, ok := states[workflowID]
+			if !ok {
+				return nil, serviceerror.NewNotFound("workflow not found")
+			}
+			value := &mocks.Value{}
+			value.On("Get", mock.Anything).Return(func(valuePtr interface{}) error {
+				*valuePtr.(*seat.SeatState) = state
+				return nil
+			})
+			return value, nil
+		})
+}
+
+func (s *SeatActivitiesTestSuite) TestCountHeldSeatsActivity_CountsOtherOrdersOfCustomer() {
+	states := map[string]seat.SeatState{
+		"seat::FL1::1A": {IsHeld: true, HeldBy: "o-2", HeldFor: "apikey:a"},
+		"seat::FL1::1B": {IsHeld: true, HeldBy: "o-3", HeldFor: "apikey:a"},
+		// The asking order's own hold and other customers' holds do not count.
+		"seat::FL1::1C": {IsHeld: true, HeldBy: "o-1", HeldFor: "apikey:a"},
+		"seat::FL1::2A": {IsHeld: true, HeldBy: "o-4", HeldFor: "apikey:b"},
+		"seat::FL1::2B": {IsConfirmed: true, ConfirmedBy: "o-5"},
+	}
+	s.expectSeatStates(states, nil)
+
+	val, err := s.env.ExecuteActivity(s.activities.CountHeldSeatsActivity, CountHeldSeatsInput{
+		FlightID: "FL1", Customer: "apikey:a", ExcludeOrderID: "o-1",
+	})
+	s.Require().NoError(err)
+	var held int
+	s.Require().NoError(val.Get(&held))
+	s.Equal(2, held)
+}
+
+func (s *SeatActivitiesTestSuite) TestFreeSeatsActivity_ListsSeatsOrdersCanHold() {
+	s.expectSeatStates(map[string]seat.SeatState{
+		"seat::FL1::1A": {IsHeld: true, HeldBy: "o-1"},
+		"seat::FL1::1B": {IsConfirmed: true, ConfirmedBy: "o-2"},
+		"seat::FL1::1C": {Block: &seat.Block{Reason: "broken recline"}},
+		// Released seats are free again.
+		"seat::FL1::1D": {},
+	}, map[string]error{
+		"seat::FL1::1E": serviceerror.NewUnavailable("try again"),
+	})
+
+	val, err := s.env.ExecuteActivity(s.activities.FreeSeatsActivity, FreeSeatsInput{FlightID: "FL1"})
+	s.Require().NoError(err)
+	var free []string
+	s.Require().NoError(val.Get(&free))
+	s.Len(free, len(seat.AllSeatIDs())-4)
+	s.Contains(free, "1D")
+	s.Contains(free, "1F")
+	s.NotContains(free, "1A")
+	s.NotContains(free, "1E")
+}
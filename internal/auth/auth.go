@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries no
+// credentials it understands, so the next authenticator in a Chain may try.
+var ErrNoCredentials = errors.New("no credentials")
+
+// ErrInvalidCredentials is returned when credentials are present but rejected.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is an authenticated caller. Orders are owned by the principal that created them.
+type Principal struct {
+	// ID identifies the caller, e.g. "apikey:partner-a" or "jwt:user-123".
+	ID string
+}
+
+// Credentials are the transport-independent credentials of a request.
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+}
+
+// Authenticator verifies credentials and returns the caller's principal.
+type Authenticator interface {
+	Authenticate(ctx context.Context, creds Credentials) (Principal, error)
+}
+
+// Chain tries each authenticator in turn until one recognizes the credentials.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, creds Credentials) (Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(ctx, creds)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+	return Principal{}, ErrNoCredentials
+}
+
+// APIKeys authenticates static API keys, mapping each key to a principal name.
+type APIKeys map[string]string
+
+func (k APIKeys) Authenticate(_ context.Context, creds Credentials) (Principal, error) {
+	if creds.APIKey == "" {
+		return Principal{}, ErrNoCredentials
+	}
+	for key, name := range k {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(creds.APIKey)) == 1 {
+			return Principal{ID: "apikey:" + name}, nil
+		}
+	}
+	return Principal{}, ErrInvalidCredentials
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of an authenticated request. ok is false
+// when authentication is disabled.
+func FromContext(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the network address of the caller.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// Caller identifies who is calling for per-customer limits: the principal ID
+// when authenticated, otherwise "ip:<address>". It is empty when neither is known.
+func Caller(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.ID
+	}
+	if ip, _ := ctx.Value(clientIPKey{}).(string); ip != "" {
+		return "ip:" + ip
+	}
+	return ""
+}
+
+// New builds the authenticator described by cfg, or returns nil when
+// authentication is disabled.
+func New(cfg config.AuthConfig) (Authenticator, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	var chain Chain
+	if len(cfg.APIKeys) > 0 {
+		chain = append(chain, APIKeys(cfg.APIKeys))
+	}
+	if cfg.JWTSecret != "" || cfg.JWTPublicKeyFile != "" {
+		j := &JWT{Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience}
+		if cfg.JWTSecret != "" {
+			j.Secret = []byte(cfg.JWTSecret)
+		}
+		if cfg.JWTPublicKeyFile != "" {
+			pem, err := os.ReadFile(cfg.JWTPublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("read JWT public key: %w", err)
+			}
+			if j.PublicKey, err = ParseRSAPublicKey(pem); err != nil {
+				return nil, fmt.Errorf("parse JWT public key: %w", err)
+			}
+		}
+		chain = append(chain, j)
+	}
+	return chain, nil
+}
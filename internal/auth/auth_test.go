@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func signed(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims(subject string) jwt.MapClaims {
+	return jwt.MapClaims{"sub": subject, "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys := APIKeys{"secret-a": "partner-a"}
+	ctx := context.Background()
+
+	p, err := keys.Authenticate(ctx, Credentials{APIKey: "secret-a"})
+	require.NoError(t, err)
+	require.Equal(t, "apikey:partner-a", p.ID)
+
+	_, err = keys.Authenticate(ctx, Credentials{APIKey: "wrong"})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = keys.Authenticate(ctx, Credentials{})
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestJWT_HS256(t *testing.T) {
+	secret := []byte("hs256-secret")
+	j := &JWT{Secret: secret, Issuer: "issuer"}
+	ctx := context.Background()
+
+	claims := validClaims("user-1")
+	claims["iss"] = "issuer"
+	p, err := j.Authenticate(ctx, Credentials{BearerToken: signed(t, jwt.SigningMethodHS256, secret, claims)})
+	require.NoError(t, err)
+	require.Equal(t, "jwt:user-1", p.ID)
+
+	// Wrong secret, wrong issuer, expired and missing expiry are all rejected.
+	for name, token := range map[string]string{
+		"wrong secret": signed(t, jwt.SigningMethodHS256, []byte("other"), claims),
+		"wrong issuer": signed(t, jwt.SigningMethodHS256, secret, validClaims("user-1")),
+		"expired":      signed(t, jwt.SigningMethodHS256, secret, jwt.MapClaims{"sub": "user-1", "iss": "issuer", "exp": time.Now().Add(-time.Minute).Unix()}),
+		"no expiry":    signed(t, jwt.SigningMethodHS256, secret, jwt.MapClaims{"sub": "user-1", "iss": "issuer"}),
+	} {
+		_, err := j.Authenticate(ctx, Credentials{BearerToken: token})
+		require.ErrorIs(t, err, ErrInvalidCredentials, name)
+	}
+}
+
+func TestJWT_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	j := &JWT{PublicKey: &key.PublicKey}
+	ctx := context.Background()
+
+	p, err := j.Authenticate(ctx, Credentials{BearerToken: signed(t, jwt.SigningMethodRS256, key, validClaims("user-2"))})
+	require.NoError(t, err)
+	require.Equal(t, "jwt:user-2", p.ID)
+
+	// Only RS256 is configured, so an HS256 token must not be accepted.
+	_, err = j.Authenticate(ctx, Credentials{BearerToken: signed(t, jwt.SigningMethodHS256, []byte("secret"), validClaims("user-2"))})
+	require.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestChain(t *testing.T) {
+	secret := []byte("hs256-secret")
+	authn, err := New(config.AuthConfig{APIKeys: map[string]string{"key": "partner"}, JWTSecret: string(secret)})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	p, err := authn.Authenticate(ctx, Credentials{APIKey: "key"})
+	require.NoError(t, err)
+	require.Equal(t, "apikey:partner", p.ID)
+
+	p, err = authn.Authenticate(ctx, Credentials{BearerToken: signed(t, jwt.SigningMethodHS256, secret, validClaims("user-3"))})
+	require.NoError(t, err)
+	require.Equal(t, "jwt:user-3", p.ID)
+
+	_, err = authn.Authenticate(ctx, Credentials{})
+	require.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestNew_Disabled(t *testing.T) {
+	authn, err := New(config.AuthConfig{})
+	require.NoError(t, err)
+	require.Nil(t, authn)
+}
+
+func TestCaller(t *testing.T) {
+	ctx := context.Background()
+	require.Empty(t, Caller(ctx))
+
+	ctx = WithClientIP(ctx, "10.0.0.1")
+	require.Equal(t, "ip:10.0.0.1", Caller(ctx))
+
+	// An authenticated principal identifies the caller regardless of its address.
+	ctx = WithPrincipal(ctx, Principal{ID: "apikey:partner-a"})
+	require.Equal(t, "apikey:partner-a", Caller(ctx))
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT authenticates bearer tokens signed with HS256 (shared secret) and/or
+// RS256 (RSA public key). The principal is the token's subject.
+type JWT struct {
+	// Secret verifies HS256 tokens; nil disables HS256.
+	Secret []byte
+	// PublicKey verifies RS256 tokens; nil disables RS256.
+	PublicKey *rsa.PublicKey
+	// Issuer and Audience, when set, must match the token's iss and aud claims.
+	Issuer   string
+	Audience string
+}
+
+// ParseRSAPublicKey parses a PEM-encoded RSA public key for RS256 verification.
+func ParseRSAPublicKey(pem []byte) (*rsa.PublicKey, error) {
+	return jwt.ParseRSAPublicKeyFromPEM(pem)
+}
+
+func (j *JWT) Authenticate(_ context.Context, creds Credentials) (Principal, error) {
+	if creds.BearerToken == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(j.methods()), jwt.WithExpirationRequired()}
+	if j.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.Issuer))
+	}
+	if j.Audience != "" {
+		opts = append(opts, jwt.WithAudience(j.Audience))
+	}
+
+	token, err := jwt.Parse(creds.BearerToken, j.key, opts...)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	subject, err := token.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+	return Principal{ID: "jwt:" + subject}, nil
+}
+
+func (j *JWT) methods() []string {
+	var methods []string
+	if j.Secret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if j.PublicKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	return methods
+}
+
+// key selects the verification key by the token's algorithm, which
+// jwt.WithValidMethods has already restricted to the configured ones.
+func (j *JWT) key(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return j.Secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		return j.PublicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
@@ -1,9 +1,220 @@
 package config
 
-// Config placeholder for application configuration.
-type Config struct{}
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
-// Load placeholder for loading configuration.
+// Config holds the application configuration loaded from the environment.
+type Config struct {
+	// Temporal locates the Temporal server the API and the workers connect to.
+	Temporal TemporalConfig
+
+	// GRPCAddr is the address the gRPC ReservationService listens on (GRPC_ADDR).
+	GRPCAddr string
+	// SSETick is how often watched orders, flights and tickets are re-queried for changes (SSE_TICK_MS).
+	SSETick time.Duration
+	// SSEKeepAlive is the interval between keep-alive comments on idle streams (SSE_KEEPALIVE_MS).
+	SSEKeepAlive time.Duration
+	// HoldExpiryWarning is how long before hold expiry an expiry-warning event is sent (HOLD_EXPIRY_WARNING_MS).
+	HoldExpiryWarning time.Duration
+	// WSPingInterval is how often WebSocket connections are pinged (WS_PING_MS).
+	WSPingInterval time.Duration
+
+	// Auth configures caller authentication. It is disabled when no method is configured.
+	Auth AuthConfig
+	// Limits caps how many seats callers may hold at once.
+	Limits LimitsConfig
+	// RateLimit caps how fast each caller may use the API.
+	RateLimit RateLimitConfig
+	// WaitingRoom queues the customers of high-demand flights before they may create orders.
+	WaitingRoom WaitingRoomConfig
+
+	// PaymentTestCodes makes the worker accept the fixed payment codes of the
+	// end-to-end tests (PAYMENT_TEST_CODES=true). Never enable it in production.
+	PaymentTestCodes bool
+}
+
+// TemporalConfig locates the Temporal server.
+type TemporalConfig struct {
+	// HostPort is the address of the Temporal frontend (TEMPORAL_HOSTPORT).
+	HostPort string
+	// Namespace is the Temporal namespace of the workflows (TEMPORAL_NAMESPACE).
+	Namespace string
+}
+
+// WaitingRoomConfig configures the virtual waiting room.
+type WaitingRoomConfig struct {
+	// Flights lists the flights that require an admitted queue ticket to create
+	// an order; "*" matches every flight (WAITING_ROOM_FLIGHTS="F-100,F-200").
+	Flights []string
+	// AdmitPerMinute is how many tickets of a flight are admitted per minute (WAITING_ROOM_ADMIT_PER_MIN).
+	AdmitPerMinute int
+	// AdmissionTTL is how long an admitted ticket may be used to create an order (WAITING_ROOM_ADMISSION_TTL_MS).
+	AdmissionTTL time.Duration
+}
+
+// Enabled reports whether orders for flightID need an admitted ticket.
+func (w WaitingRoomConfig) Enabled(flightID string) bool {
+	for _, f := range w.Flights {
+		if f == "*" || f == flightID {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitConfig holds the per-caller request budgets. Zero disables a budget.
+type RateLimitConfig struct {
+	// MutationsPerMinute and MutationBurst limit order creation, seat and payment
+	// requests (RATE_LIMIT_MUTATIONS_PER_MIN, RATE_LIMIT_MUTATIONS_BURST).
+	MutationsPerMinute int
+	MutationBurst      int
+	// AvailabilityPerMinute and AvailabilityBurst limit flight availability, order
+	// status and queue ticket reads (RATE_LIMIT_AVAILABILITY_PER_MIN, RATE_LIMIT_AVAILABILITY_BURST).
+	AvailabilityPerMinute int
+	AvailabilityBurst     int
+	// MaxStreams caps the open SSE/WebSocket/gRPC watch connections and status
+	// long-polls per caller (RATE_LIMIT_STREAMS).
+	MaxStreams int
+}
+
+// LimitsConfig holds the anti-hoarding limits on seat holds. Zero disables a limit.
+type LimitsConfig struct {
+	// MaxSeatsPerOrder caps the seats a single order may select (MAX_SEATS_PER_ORDER).
+	MaxSeatsPerOrder int
+	// MaxHeldSeatsPerCustomer caps the seats one customer (API key, JWT subject, or
+	// IP address when authentication is disabled) may hold on a flight across all
+	// of its orders (MAX_HELD_SEATS_PER_CUSTOMER). It is off by default: without
+	// authentication, every client behind one address is the same customer.
+	MaxHeldSeatsPerCustomer int
+}
+
+// AuthConfig configures API key and JWT authentication.
+type AuthConfig struct {
+	// APIKeys maps API keys to principal names (AUTH_API_KEYS="key1=partner-a,key2=partner-b").
+	APIKeys map[string]string
+	// JWTSecret enables HS256 bearer tokens signed with this secret (AUTH_JWT_SECRET).
+	JWTSecret string
+	// JWTPublicKeyFile enables RS256 bearer tokens verified with this PEM public key (AUTH_JWT_PUBLIC_KEY_FILE).
+	JWTPublicKeyFile string
+	// JWTIssuer and JWTAudience, when set, must match the tokens' iss and aud (AUTH_JWT_ISSUER, AUTH_JWT_AUDIENCE).
+	JWTIssuer   string
+	JWTAudience string
+	// Admins lists the principals allowed to use the admin API (AUTH_ADMINS="apikey:ops,jwt:alice").
+	Admins []string
+}
+
+// Enabled reports whether any authentication method is configured.
+func (a AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.JWTSecret != "" || a.JWTPublicKeyFile != ""
+}
+
+// Load reads the configuration from the environment, falling back to defaults.
 func Load() Config {
-	return Config{}
+	return Config{
+		Temporal: TemporalConfig{
+			HostPort:  text("TEMPORAL_HOSTPORT", "localhost:7233"),
+			Namespace: text("TEMPORAL_NAMESPACE", "default"),
+		},
+		GRPCAddr:          text("GRPC_ADDR", ":9090"),
+		SSETick:           durationMS("SSE_TICK_MS", time.Second),
+		SSEKeepAlive:      durationMS("SSE_KEEPALIVE_MS", 15*time.Second),
+		HoldExpiryWarning: durationMS("HOLD_EXPIRY_WARNING_MS", 2*time.Minute),
+		WSPingInterval:    durationMS("WS_PING_MS", 30*time.Second),
+		Auth: AuthConfig{
+			APIKeys:          keyValues("AUTH_API_KEYS"),
+			JWTSecret:        os.Getenv("AUTH_JWT_SECRET"),
+			JWTPublicKeyFile: os.Getenv("AUTH_JWT_PUBLIC_KEY_FILE"),
+			JWTIssuer:        os.Getenv("AUTH_JWT_ISSUER"),
+			JWTAudience:      os.Getenv("AUTH_JWT_AUDIENCE"),
+			Admins:           list("AUTH_ADMINS"),
+		},
+		Limits: LimitsConfig{
+			MaxSeatsPerOrder:        count("MAX_SEATS_PER_ORDER", 10),
+			MaxHeldSeatsPerCustomer: count("MAX_HELD_SEATS_PER_CUSTOMER", 0),
+		},
+		RateLimit: RateLimitConfig{
+			MutationsPerMinute:    count("RATE_LIMIT_MUTATIONS_PER_MIN", 60),
+			MutationBurst:         count("RATE_LIMIT_MUTATIONS_BURST", 20),
+			AvailabilityPerMinute: count("RATE_LIMIT_AVAILABILITY_PER_MIN", 120),
+			AvailabilityBurst:     count("RATE_LIMIT_AVAILABILITY_BURST", 20),
+			MaxStreams:            count("RATE_LIMIT_STREAMS", 10),
+		},
+		WaitingRoom: WaitingRoomConfig{
+			Flights:        list("WAITING_ROOM_FLIGHTS"),
+			AdmitPerMinute: count("WAITING_ROOM_ADMIT_PER_MIN", 60),
+			AdmissionTTL:   durationMS("WAITING_ROOM_ADMISSION_TTL_MS", 10*time.Minute),
+		},
+		PaymentTestCodes: flag("PAYMENT_TEST_CODES"),
+	}
+}
+
+// text reads an environment variable, falling back to def when unset.
+func text(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// durationMS reads an environment variable holding a positive number of milliseconds.
+func durationMS(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	ms, err := strconv.Atoi(v)
+	if err != nil || ms <= 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// count reads an environment variable holding a non-negative integer.
+func count(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return def
+	}
+	return n
+}
+
+// flag reads an environment variable holding a boolean; unset or malformed is false.
+func flag(key string) bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(key))
+	return enabled
+}
+
+// list reads an environment variable holding comma-separated values.
+func list(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// keyValues reads an environment variable holding comma-separated key=value pairs.
+func keyValues(key string) map[string]string {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(v, ",") {
+		k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && k != "" && val != "" {
+			pairs[k] = val
+		}
+	}
+	return pairs
 }
@@ -3,9 +3,18 @@ package domain
 import "time"
 
 // CreateOrderRequest is the client's request to create a new order.
+// OrderID is optional; the server generates a sortable ID when it is empty.
+// TicketID is the admitted waiting room ticket, required for flights behind a waiting room.
+// Waitlist queues the order for seats held by other orders instead of skipping them.
+// JSON keys match case-insensitively, so legacy "flightID"/"orderID" bodies still decode.
 type CreateOrderRequest struct {
-	FlightID string `json:"flightID"`
-	OrderID  string `json:"orderID"`
+	FlightID string `json:"flightId"`
+	OrderID  string `json:"orderId,omitempty"`
+	TicketID string `json:"ticketId,omitempty"`
+	Waitlist bool   `json:"waitlist,omitempty"`
+	// AssignSeats, when positive, has the server pick and hold that many
+	// adjacent seats for the order instead of waiting for a selection.
+	AssignSeats int `json:"assignSeats,omitempty"`
 }
 
 // CreateOrderResponse is the server's response after creating an order.
@@ -31,4 +40,167 @@ type GetStatusResponse struct {
 	AttemptsLeft  int        `json:"attemptsLeft"`
 	LastError     string     `json:"lastError,omitempty"`
 	PaymentStatus string     `json:"paymentStatus,omitempty"` // NEW: trying, retrying, failed, success
+	Version       int64      `json:"version"`                 // increments on every change; used for ETags and SSE event IDs
+	SeatsError    string     `json:"seatsError,omitempty"`    // why the order workflow rejected the last seat selection
+	Waitlisted    []string   `json:"waitlisted,omitempty"`    // selected seats still held by other orders; payment waits for them
+}
+
+// PaymentEvent is the payload of the "payment" SSE event, sent when the payment status changes.
+type PaymentEvent struct {
+	PaymentStatus string `json:"paymentStatus"`
+	AttemptsLeft  int    `json:"attemptsLeft"`
+	LastError     string `json:"lastError,omitempty"`
+}
+
+// ExpiryWarningEvent is the payload of the "expiry-warning" SSE event, sent shortly before the seat hold expires.
+type ExpiryWarningEvent struct {
+	HoldExpiresAt time.Time `json:"holdExpiresAt"`
+	SecondsLeft   int       `json:"secondsLeft"`
+}
+
+// FlightAvailability groups the seats of a flight by their current state.
+type FlightAvailability struct {
+	FlightID  string   `json:"flightId"`
+	Available []string `json:"available"`
+	Held      []string `json:"held"`
+	Confirmed []string `json:"confirmed"`
+	Blocked   []string `json:"blocked"` // taken out of sale by operations
+	Total     int      `json:"total"`
+}
+
+// BlockSeatRequest is the body of the admin request that blocks a seat.
+type BlockSeatRequest struct {
+	Reason    string     `json:"reason"`              // e.g. "broken recline", "crew rest"
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // when the block lifts by itself; omitted blocks until unblocked
+}
+
+// SeatBlock is a seat blocked by operations, as listed by the admin API.
+type SeatBlock struct {
+	SeatID    string     `json:"seatId"`
+	Reason    string     `json:"reason"`
+	BlockedBy string     `json:"blockedBy"` // the principal, or client IP without authentication, that blocked the seat
+	BlockedAt time.Time  `json:"blockedAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// SeatOverrideRequest is the body of the admin requests that force-release,
+// force-unconfirm or reassign a seat.
+type SeatOverrideRequest struct {
+	Reason  string `json:"reason"`
+	OrderID string `json:"orderId,omitempty"` // the order a reassigned seat goes to
+}
+
+// SeatOverride is the audit record of an admin override of a seat.
+type SeatOverride struct {
+	Command         string    `json:"command"` // FORCE_RELEASE, FORCE_UNCONFIRM, REASSIGN
+	Reason          string    `json:"reason"`
+	Actor           string    `json:"actor"`
+	At              time.Time `json:"at"`
+	PreviousOrderID string    `json:"previousOrderId,omitempty"` // held or confirmed the seat before
+	OrderID         string    `json:"orderId,omitempty"`         // the order a REASSIGN gave the seat to
+}
+
+// SeatDetails is the full state of a seat, as inspected by the admin API.
+type SeatDetails struct {
+	FlightID      string         `json:"flightId"`
+	SeatID        string         `json:"seatId"`
+	Status        string         `json:"status"` // available, held, confirmed, blocked
+	HeldBy        string         `json:"heldBy,omitempty"`
+	HeldFor       string         `json:"heldFor,omitempty"`
+	HoldExpiresAt *time.Time     `json:"holdExpiresAt,omitempty"`
+	ConfirmedBy   string         `json:"confirmedBy,omitempty"`
+	Waitlist      []string       `json:"waitlist"`
+	Block         *SeatBlock     `json:"block,omitempty"`
+	Overrides     []SeatOverride `json:"overrides"`
+}
+
+// SeatHistoryEntry is a command processed by a seat and what came of it.
+type SeatHistoryEntry struct {
+	At      time.Time `json:"at"`
+	Command string    `json:"command"` // HOLD, EXTEND, RELEASE, CONFIRM, ...
+	OrderID string    `json:"orderId,omitempty"`
+	Actor   string    `json:"actor,omitempty"`
+	Outcome string    `json:"outcome"`          // applied, ignored
+	Reason  string    `json:"reason,omitempty"` // why it was ignored, or the admin reason
+	// HeldBy and ConfirmedBy are the orders of the seat after the command.
+	HeldBy      string `json:"heldBy,omitempty"`
+	ConfirmedBy string `json:"confirmedBy,omitempty"`
+}
+
+// SeatHistory is the latest commands processed by a seat, oldest first.
+type SeatHistory struct {
+	FlightID string             `json:"flightId"`
+	SeatID   string             `json:"seatId"`
+	Entries  []SeatHistoryEntry `json:"entries"`
+}
+
+// OrderTimeline is what happened to an order, read from the history of its
+// workflow, for support agents.
+type OrderTimeline struct {
+	OrderID string          `json:"orderId"`
+	State   string          `json:"state"` // PENDING, SEATS_SELECTED, CONFIRMED, FAILED, EXPIRED
+	Events  []TimelineEvent `json:"events"`
+}
+
+// TimelineEvent is one step of an order timeline. Payment codes in Input are
+// redacted.
+type TimelineEvent struct {
+	At      time.Time `json:"at"`
+	Kind    string    `json:"kind"` // workflow, signal, activity, timer
+	Name    string    `json:"name"` // the signal or activity name, or what happened to the workflow or timer
+	Summary string    `json:"summary"`
+	Input   any       `json:"input,omitempty"`
+	Result  any       `json:"result,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// QueueTicket is a waiting room ticket: returned when joining the queue of a
+// flight, by the ticket status endpoint and as the "ticket" SSE event.
+type QueueTicket struct {
+	TicketID string `json:"ticketId"`
+	FlightID string `json:"flightId"`
+	State    string `json:"state"`              // waiting, admitted, redeemed, expired
+	Position int    `json:"position,omitempty"` // 1-based place in the queue while waiting
+	// EstimatedWaitSeconds is how long until a waiting ticket is admitted at the current rate.
+	EstimatedWaitSeconds int        `json:"estimatedWaitSeconds,omitempty"`
+	AdmittedAt           *time.Time `json:"admittedAt,omitempty"`
+	ExpiresAt            *time.Time `json:"expiresAt,omitempty"` // until when an admitted ticket may create an order
+	OrderID              string     `json:"orderId,omitempty"`   // the order a redeemed ticket created
+}
+
+// WebSocket message types.
+const (
+	WSSubscribe     = "subscribe"
+	WSUnsubscribe   = "unsubscribe"
+	WSUpdateSeats   = "updateSeats"
+	WSSubmitPayment = "submitPayment"
+
+	WSState        = "state"
+	WSAvailability = "availability"
+	WSAck          = "ack"
+	WSError        = "error"
+)
+
+// WSClientMessage is a message sent by a WebSocket client: a subscription
+// change for an order or flight, or a seat/payment command for an order.
+type WSClientMessage struct {
+	Type     string   `json:"type"`
+	ID       string   `json:"id,omitempty"` // optional; echoed back in the ack or error
+	OrderID  string   `json:"orderId,omitempty"`
+	FlightID string   `json:"flightId,omitempty"`
+	Seats    []string `json:"seats,omitempty"`
+	Code     string   `json:"code,omitempty"`
+}
+
+// WSServerMessage is a message sent to a WebSocket client. State and
+// availability messages carry the same payloads as the SSE and REST endpoints.
+type WSServerMessage struct {
+	Type     string `json:"type"`
+	ID       string `json:"id,omitempty"`
+	OrderID  string `json:"orderId,omitempty"`
+	FlightID string `json:"flightId,omitempty"`
+	Version  int64  `json:"version,omitempty"`
+	Data     any    `json:"data,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"` // stable error code, see Problem.Code
 }
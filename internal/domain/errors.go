@@ -0,0 +1,40 @@
+package domain
+
+// Stable error codes returned in Problem.Code. Clients should branch on these
+// rather than on the human-readable detail.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidOrderID       = "invalid_order_id"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeOrderNotFound        = "order_not_found"
+	CodeOrderAlreadyExists   = "order_already_exists"
+	CodeConflict             = "conflict"
+	CodeTooManySeats         = "too_many_seats"
+	CodeHoldLimitExceeded    = "hold_limit_exceeded"
+	CodeTicketRequired       = "ticket_required"
+	CodeTicketNotFound       = "ticket_not_found"
+	CodeTicketNotAdmitted    = "ticket_not_admitted"
+	CodeSeatNotFound         = "seat_not_found"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeRateLimited          = "rate_limited"
+	CodeServiceUnavailable   = "service_unavailable"
+	CodeStreamingUnsupported = "streaming_unsupported"
+	CodeInternal             = "internal_error"
+)
+
+// Problem is an RFC 7807 "application/problem+json" error body, extended with
+// a stable error code and the order/flight the error relates to.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code     string `json:"code"`
+	OrderID  string `json:"orderId,omitempty"`
+	FlightID string `json:"flightId,omitempty"`
+	// OrderStatus is the current status of the conflicting order on order_already_exists.
+	OrderStatus *GetStatusResponse `json:"orderStatus,omitempty"`
+}
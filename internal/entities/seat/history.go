@@ -0,0 +1,59 @@
+package seat
+
+import "time"
+
+// GetHistoryQuery returns the latest commands processed by a seat, oldest first.
+const GetHistoryQuery = "GetHistory"
+
+// maxHistory bounds the commands kept in a seat's history.
+const maxHistory = 200
+
+// Outcome is what a seat did with a command.
+type Outcome string
+
+const (
+	OutcomeApplied Outcome = "applied"
+	OutcomeIgnored Outcome = "ignored"
+)
+
+// HistoryEntry is a command processed by a seat and what came of it.
+type HistoryEntry struct {
+	At      time.Time   `json:"at"`
+	Command CommandType `json:"command"`
+	OrderID string      `json:"orderId,omitempty"`
+	Actor   string      `json:"actor,omitempty"`
+	Outcome Outcome     `json:"outcome"`
+	// Reason says why the command was ignored, or what else it did.
+	Reason string `json:"reason,omitempty"`
+	// HeldBy and ConfirmedBy are the orders of the seat after the command.
+	HeldBy      string `json:"heldBy,omitempty"`
+	ConfirmedBy string `json:"confirmedBy,omitempty"`
+}
+
+// history is a ring buffer of the latest maxHistory entries.
+type history struct {
+	buf   []HistoryEntry
+	start int // index of the oldest entry once buf is full
+}
+
+// newHistory restores a history from its entries, oldest first.
+func newHistory(entries []HistoryEntry) *history {
+	if len(entries) > maxHistory {
+		entries = entries[len(entries)-maxHistory:]
+	}
+	return &history{buf: append(make([]HistoryEntry, 0, maxHistory), entries...)}
+}
+
+func (h *history) add(e HistoryEntry) {
+	if len(h.buf) < maxHistory {
+		h.buf = append(h.buf, e)
+		return
+	}
+	h.buf[h.start] = e
+	h.start = (h.start + 1) % maxHistory
+}
+
+// entries returns the entries oldest first.
+func (h *history) entries() []HistoryEntry {
+	return append(append([]HistoryEntry{}, h.buf[h.start:]...), h.buf[:h.start]...)
+}
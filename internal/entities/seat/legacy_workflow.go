@@ -0,0 +1,504 @@
+package seat
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// reducerVersion is the change ID of the seat rules moving into Apply, when
+// seats also started taking commands as updates and issuing hold tokens.
+// Seats started before it run legacySeatEntityWorkflow until they continue as
+// new, which they do at the first Command update.
+const reducerVersion = "seat-reducer"
+
+// legacySeatState is the state of a seat run started before reducerVersion.
+type legacySeatState struct {
+	isHeld      bool
+	isConfirmed bool   // NEW - permanently reserved after payment
+	heldBy      string // which orderID holds it
+	heldFor     string // which customer the holding order belongs to
+	confirmedBy string // NEW - which orderID confirmed it
+	expiresAt   time.Time
+	waitlist    []Waiter // orders waiting for the seat, in FIFO order
+	block       *Block   // set while the seat is blocked by operations
+	overrides   []Override
+}
+
+// legacySeatEntityWorkflow is SeatEntityWorkflow as it was before
+// reducerVersion, kept so that the seats it started replay. Do not change it:
+// it continues as new into the current rules at the first Command update,
+// which it refuses with a retryable error, or when its history grows too long.
+func legacySeatEntityWorkflow(ctx workflow.Context, flightID, seatID string, initial *SeatPersistedState) error {
+	logger := workflow.GetLogger(ctx)
+
+	state := legacySeatState{}
+	if initial != nil {
+		state = legacySeatState{
+			isHeld:      initial.IsHeld,
+			isConfirmed: initial.IsConfirmed,
+			heldBy:      initial.HeldBy,
+			heldFor:     initial.HeldFor,
+			confirmedBy: initial.ConfirmedBy,
+			expiresAt:   initial.ExpiresAt,
+			waitlist:    initial.Waitlist,
+			block:       initial.Block,
+			overrides:   initial.Overrides,
+		}
+		logger.Info("Restored state from ContinueAsNew", "IsHeld", state.isHeld, "IsConfirmed", state.isConfirmed, "HeldBy", state.heldBy, "ConfirmedBy", state.confirmedBy)
+	}
+
+	hist := newHistory(nil)
+	if initial != nil {
+		hist = newHistory(initial.History)
+	}
+
+	cmdChan := workflow.GetSignalChannel(ctx, "cmd")
+	var holdTimer workflow.Future
+	var holdCancel workflow.CancelFunc
+	var blockTimer workflow.Future
+	var blockCancel workflow.CancelFunc
+
+	// Register query handler to expose seat state
+	workflow.SetQueryHandler(ctx, "GetState", func() (SeatState, error) {
+		var exp string
+		if !state.expiresAt.IsZero() {
+			exp = state.expiresAt.Format(time.RFC3339)
+		}
+		var waitlist []string
+		for _, w := range state.waitlist {
+			waitlist = append(waitlist, w.OrderID)
+		}
+		return SeatState{
+			IsHeld:      state.isHeld,
+			IsConfirmed: state.isConfirmed,
+			HeldBy:      state.heldBy,
+			HeldFor:     state.heldFor,
+			ConfirmedBy: state.confirmedBy,
+			ExpiresAt:   exp,
+			Waitlist:    waitlist,
+			Block:       state.block,
+			Overrides:   state.overrides,
+		}, nil
+	})
+
+	workflow.SetQueryHandler(ctx, GetHistoryQuery, func() ([]HistoryEntry, error) {
+		return hist.entries(), nil
+	})
+
+	// Commands sent as updates come from orders that expect hold tokens. The
+	// seat moves to the current rules first; the caller retries there.
+	migrate := workflow.NewBufferedChannel(ctx, 1)
+	if err := workflow.SetUpdateHandler(ctx, CommandUpdate, func(ctx workflow.Context, cmd Command) (CommandResult, error) {
+		logger.Info("Command update on a legacy seat, continuing as new", "Type", cmd.Type, "OrderID", cmd.OrderID)
+		migrate.SendAsync(true)
+		return CommandResult{}, temporal.NewApplicationError("seat is moving to the current rules, retry", "SeatMigrating")
+	}); err != nil {
+		return err
+	}
+	migrating := false
+
+	makeHoldTimer := func(ttl time.Duration) {
+		// Clamp TTL defensively to prevent zero/negative values
+		if ttl < time.Second {
+			ttl = time.Second
+		}
+
+		// cancel previous timer (if any)
+		if holdCancel != nil {
+			holdCancel()
+			holdCancel = nil
+			holdTimer = nil
+		}
+		holdCtx, cancel := workflow.WithCancel(ctx)
+		holdCancel = cancel
+		holdTimer = workflow.NewTimer(holdCtx, ttl)
+		state.expiresAt = workflow.Now(ctx).Add(ttl)
+	}
+
+	clearHold := func() {
+		state.isHeld = false
+		state.heldBy = ""
+		state.heldFor = ""
+		state.expiresAt = time.Time{}
+		if holdCancel != nil {
+			holdCancel()
+			holdCancel = nil
+			holdTimer = nil
+		}
+	}
+
+	// setBlock replaces the block of the seat, re-arming its expiry timer.
+	setBlock := func(block *Block) {
+		if blockCancel != nil {
+			blockCancel()
+			blockCancel = nil
+			blockTimer = nil
+		}
+		state.block = block
+		if block == nil || block.ExpiresAt.IsZero() {
+			return
+		}
+		blockCtx, cancel := workflow.WithCancel(ctx)
+		blockCancel = cancel
+		blockTimer = workflow.NewTimer(blockCtx, max(block.ExpiresAt.Sub(workflow.Now(ctx)), time.Millisecond))
+	}
+
+	// notify signals the order workflow of a waitlisted order.
+	notify := func(orderID string, notice WaitlistNotice) error {
+		notice.FlightID = flightID
+		notice.SeatID = seatID
+		return workflow.SignalExternalWorkflow(ctx, orderWorkflowID(orderID), "", WaitlistSignal, notice).Get(ctx, nil)
+	}
+
+	waitlistIndex := func(orderID string) int {
+		for i, w := range state.waitlist {
+			if w.OrderID == orderID {
+				return i
+			}
+		}
+		return -1
+	}
+
+	leaveWaitlist := func(orderID string) bool {
+		i := waitlistIndex(orderID)
+		if i < 0 {
+			return false
+		}
+		state.waitlist = append(state.waitlist[:i:i], state.waitlist[i+1:]...)
+		return true
+	}
+
+	// audit records an override before it changes the seat.
+	audit := func(cmd Command) {
+		previous := state.heldBy
+		if state.isConfirmed {
+			previous = state.confirmedBy
+		}
+		o := Override{Command: cmd.Type, Actor: cmd.Actor, Reason: cmd.Reason, At: workflow.Now(ctx), PreviousOrderID: previous}
+		if cmd.Type == CmdReassign {
+			o.OrderID = cmd.OrderID
+		}
+		state.overrides = append(state.overrides, o)
+		if len(state.overrides) > maxOverrides {
+			state.overrides = state.overrides[len(state.overrides)-maxOverrides:]
+		}
+		logger.Info("Admin OVERRIDE", "Command", cmd.Type, "Actor", cmd.Actor, "Reason", cmd.Reason, "PreviousOrderID", previous, "OrderID", o.OrderID)
+	}
+
+	// revoke tells an order that it lost its hold to an admin override.
+	revoke := func(orderID string) {
+		if err := notify(orderID, WaitlistNotice{Event: WaitlistRevoked}); err != nil {
+			logger.Warn("Failed to notify order of revoked hold", "OrderID", orderID, "Error", err)
+		}
+	}
+
+	// grantNext hands a free seat to the first waiting order. Orders that can no
+	// longer be notified lose their turn.
+	grantNext := func() {
+		for !state.isHeld && !state.isConfirmed && state.block == nil && len(state.waitlist) > 0 {
+			next := state.waitlist[0]
+			state.waitlist = state.waitlist[1:]
+			state.isHeld = true
+			state.heldBy = next.OrderID
+			state.heldFor = next.Customer
+			makeHoldTimer(next.TTL)
+			if err := notify(next.OrderID, WaitlistNotice{Event: WaitlistGranted, ExpiresAt: state.expiresAt}); err != nil {
+				logger.Warn("Failed to notify waitlisted order, skipping it", "OrderID", next.OrderID, "Error", err)
+				clearHold()
+				continue
+			}
+			logger.Info("Seat HELD from waitlist", "HeldBy", state.heldBy, "ExpiresAt", state.expiresAt, "StillWaiting", len(state.waitlist))
+		}
+	}
+
+	// apply carries out a command and reports whether it was applied or, if
+	// not, why it was ignored.
+	apply := func(cmd Command) (Outcome, string) {
+		// Early guard: ignore all commands on confirmed seats except idempotent confirm and overrides
+		if state.isConfirmed && !cmd.Type.IsOverride() {
+			if cmd.Type == CmdHold && cmd.Waitlist && cmd.OrderID != state.confirmedBy {
+				if err := notify(cmd.OrderID, WaitlistNotice{Event: WaitlistClosed}); err != nil {
+					logger.Warn("Failed to notify waitlisted order", "OrderID", cmd.OrderID, "Error", err)
+				}
+			}
+			if cmd.Type == CmdConfirm && cmd.OrderID == state.confirmedBy {
+				logger.Info("Confirm idempotent - already confirmed", "OrderID", cmd.OrderID)
+				return OutcomeIgnored, "already confirmed by this order"
+			}
+			logger.Warn("Ignoring command on confirmed seat", "Type", cmd.Type, "ConfirmedBy", state.confirmedBy)
+			return OutcomeIgnored, "seat is confirmed by " + state.confirmedBy
+		}
+
+		// Blocked seats take no holds until operations unblock them.
+		if state.block != nil && (cmd.Type == CmdHold || cmd.Type == CmdExtend) {
+			logger.Warn("Ignoring command on blocked seat", "Type", cmd.Type, "OrderID", cmd.OrderID, "Reason", state.block.Reason)
+			return OutcomeIgnored, "seat is blocked: " + state.block.Reason
+		}
+
+		switch cmd.Type {
+
+		case CmdHold:
+			// If already held by someone else and not expired, reject or queue
+			if state.isHeld && state.heldBy != cmd.OrderID && workflow.Now(ctx).Before(state.expiresAt) {
+				if !cmd.Waitlist {
+					logger.Warn("Seat already held and not expired", "HeldBy", state.heldBy)
+					return OutcomeIgnored, "seat is held by " + state.heldBy
+				}
+				if i := waitlistIndex(cmd.OrderID); i >= 0 {
+					logger.Info("Order already waitlisted", "OrderID", cmd.OrderID)
+					return OutcomeIgnored, fmt.Sprintf("already waitlisted at position %d", i+1)
+				}
+				state.waitlist = append(state.waitlist, Waiter{OrderID: cmd.OrderID, Customer: cmd.Customer, TTL: cmd.TTL})
+				logger.Info("Seat held, order WAITLISTED", "HeldBy", state.heldBy, "OrderID", cmd.OrderID, "Position", len(state.waitlist))
+				if err := notify(cmd.OrderID, WaitlistNotice{Event: WaitlistQueued, Position: len(state.waitlist)}); err != nil {
+					logger.Warn("Failed to notify waitlisted order", "OrderID", cmd.OrderID, "Error", err)
+				}
+				return OutcomeApplied, fmt.Sprintf("waitlisted at position %d", len(state.waitlist))
+			}
+			// Grant/refresh hold for this order
+			leaveWaitlist(cmd.OrderID)
+			state.isHeld = true
+			state.heldBy = cmd.OrderID
+			state.heldFor = cmd.Customer
+			makeHoldTimer(cmd.TTL)
+			logger.Info("Seat HELD", "HeldBy", state.heldBy, "ExpiresAt", state.expiresAt)
+
+		case CmdExtend:
+			// Only the current holder may extend
+			if !state.isHeld || state.heldBy != cmd.OrderID {
+				logger.Warn("Extend ignored - not held by this order", "HeldBy", state.heldBy, "OrderID", cmd.OrderID)
+				return OutcomeIgnored, "seat is not held by this order"
+			}
+			makeHoldTimer(cmd.TTL)
+			logger.Info("Seat HOLD EXTENDED", "HeldBy", state.heldBy, "ExpiresAt", state.expiresAt)
+
+		case CmdRelease:
+			switch {
+			case state.isHeld && state.heldBy == cmd.OrderID:
+				clearHold()
+				logger.Info("Seat RELEASED by order", "OrderID", cmd.OrderID)
+				grantNext()
+			case leaveWaitlist(cmd.OrderID):
+				logger.Info("Order left the waitlist", "OrderID", cmd.OrderID)
+				return OutcomeApplied, "left the waitlist"
+			default:
+				logger.Warn("Release ignored - not held by this order", "HeldBy", state.heldBy, "OrderID", cmd.OrderID)
+				return OutcomeIgnored, "seat is not held by this order"
+			}
+
+		case CmdConfirm:
+			// Only holder can confirm; once confirmed, make it permanent
+			if !state.isHeld || state.heldBy != cmd.OrderID {
+				logger.Warn("Confirm ignored - seat not held by this order", "HeldBy", state.heldBy, "OrderID", cmd.OrderID)
+				return OutcomeIgnored, "seat is not held by this order"
+			}
+			state.isConfirmed = true
+			state.confirmedBy = cmd.OrderID
+			clearHold()
+			logger.Info("Seat PERMANENTLY CONFIRMED", "ConfirmedBy", state.confirmedBy)
+
+			// Nobody else can get the seat any more.
+			waiters := state.waitlist
+			state.waitlist = nil
+			for _, w := range waiters {
+				if err := notify(w.OrderID, WaitlistNotice{Event: WaitlistClosed}); err != nil {
+					logger.Warn("Failed to notify waitlisted order", "OrderID", w.OrderID, "Error", err)
+				}
+			}
+
+		case CmdBlock:
+			// Operations cannot take a seat away from an order holding it.
+			if state.isHeld && workflow.Now(ctx).Before(state.expiresAt) {
+				logger.Warn("Block ignored - seat is held", "HeldBy", state.heldBy, "Actor", cmd.Actor)
+				return OutcomeIgnored, "seat is held by " + state.heldBy
+			}
+			clearHold()
+			block := &Block{Reason: cmd.Reason, Actor: cmd.Actor, BlockedAt: workflow.Now(ctx)}
+			if cmd.TTL > 0 {
+				block.ExpiresAt = block.BlockedAt.Add(cmd.TTL)
+			}
+			setBlock(block)
+			logger.Info("Seat BLOCKED", "Reason", block.Reason, "Actor", block.Actor, "ExpiresAt", block.ExpiresAt)
+			return OutcomeApplied, cmd.Reason
+
+		case CmdUnblock:
+			if state.block == nil {
+				logger.Info("Unblock idempotent - seat not blocked", "Actor", cmd.Actor)
+				return OutcomeIgnored, "seat is not blocked"
+			}
+			logger.Info("Seat UNBLOCKED", "Reason", state.block.Reason, "Actor", cmd.Actor)
+			setBlock(nil)
+			grantNext()
+
+		case CmdForceRelease:
+			if !state.isHeld {
+				logger.Warn("Force release ignored - seat not held", "Actor", cmd.Actor)
+				return OutcomeIgnored, "seat is not held"
+			}
+			holder := state.heldBy
+			audit(cmd)
+			clearHold()
+			revoke(holder)
+			grantNext()
+			return OutcomeApplied, cmd.Reason
+
+		case CmdForceUnconfirm:
+			if !state.isConfirmed {
+				logger.Warn("Force unconfirm ignored - seat not confirmed", "Actor", cmd.Actor)
+				return OutcomeIgnored, "seat is not confirmed"
+			}
+			audit(cmd)
+			state.isConfirmed = false
+			state.confirmedBy = ""
+			grantNext()
+			return OutcomeApplied, cmd.Reason
+
+		case CmdReassign:
+			switch {
+			case state.isConfirmed:
+				audit(cmd)
+				state.confirmedBy = cmd.OrderID
+			case state.isHeld:
+				holder := state.heldBy
+				audit(cmd)
+				leaveWaitlist(cmd.OrderID)
+				state.heldBy = cmd.OrderID
+				state.heldFor = cmd.Customer
+				if holder != cmd.OrderID {
+					revoke(holder)
+				}
+				if err := notify(cmd.OrderID, WaitlistNotice{Event: WaitlistGranted, ExpiresAt: state.expiresAt}); err != nil {
+					logger.Warn("Failed to notify order of reassigned hold", "OrderID", cmd.OrderID, "Error", err)
+				}
+			default:
+				logger.Warn("Reassign ignored - seat neither held nor confirmed", "Actor", cmd.Actor)
+				return OutcomeIgnored, "seat is neither held nor confirmed"
+			}
+			return OutcomeApplied, cmd.Reason
+
+		default:
+			logger.Warn("Ignoring unknown command", "Type", cmd.Type)
+			return OutcomeIgnored, "unknown command"
+		}
+		return OutcomeApplied, ""
+	}
+
+	// Restore timer if seat was held and not expired
+	if state.isHeld && !state.expiresAt.IsZero() && workflow.Now(ctx).Before(state.expiresAt) {
+		// Re-arm timer for remaining duration
+		remaining := state.expiresAt.Sub(workflow.Now(ctx))
+		holdCtx, cancel := workflow.WithCancel(ctx)
+		holdCancel = cancel
+		holdTimer = workflow.NewTimer(holdCtx, remaining)
+		logger.Info("Restored hold timer", "Remaining", remaining, "HeldBy", state.heldBy)
+	} else if state.isHeld {
+		// Hold already expired between runs
+		logger.Info("Hold expired during ContinueAsNew, clearing", "HeldBy", state.heldBy)
+		state.isHeld = false
+		state.heldBy = ""
+		state.heldFor = ""
+		state.expiresAt = time.Time{}
+	}
+	if state.block != nil && !state.block.ExpiresAt.IsZero() && !workflow.Now(ctx).Before(state.block.ExpiresAt) {
+		logger.Info("Block expired during ContinueAsNew, clearing", "Reason", state.block.Reason)
+		state.block = nil
+	}
+	setBlock(state.block)
+	grantNext()
+
+	// handle applies a command and records it in the history.
+	handle := func(cmd Command) {
+		outcome, reason := apply(cmd)
+		hist.add(HistoryEntry{
+			At:          workflow.Now(ctx),
+			Command:     cmd.Type,
+			OrderID:     cmd.OrderID,
+			Actor:       cmd.Actor,
+			Outcome:     outcome,
+			Reason:      reason,
+			HeldBy:      state.heldBy,
+			ConfirmedBy: state.confirmedBy,
+		})
+	}
+
+	// Commands the previous run received but did not get to go first.
+	if initial != nil && len(initial.Pending) > 0 {
+		logger.Info("Applying commands carried over by ContinueAsNew", "Count", len(initial.Pending))
+		for _, cmd := range initial.Pending {
+			handle(cmd)
+		}
+	}
+
+	for {
+		sel := workflow.NewSelector(ctx)
+
+		// Incoming commands
+		sel.AddReceive(cmdChan, func(c workflow.ReceiveChannel, more bool) {
+			var cmd Command
+			c.Receive(ctx, &cmd)
+			logger.Info("Received command", "Type", cmd.Type, "OrderID", cmd.OrderID)
+			handle(cmd)
+		})
+
+		// Hold expiry
+		if holdTimer != nil {
+			sel.AddFuture(holdTimer, func(f workflow.Future) {
+				// Timer fired (not canceled) → release hold
+				logger.Info("Hold EXPIRED, releasing seat", "HeldBy", state.heldBy)
+				clearHold()
+				grantNext()
+			})
+		}
+
+		// Block expiry
+		if blockTimer != nil {
+			sel.AddFuture(blockTimer, func(f workflow.Future) {
+				logger.Info("Block EXPIRED, seat back on sale", "Reason", state.block.Reason)
+				blockTimer, blockCancel = nil, nil
+				state.block = nil
+				grantNext()
+			})
+		}
+
+		sel.AddReceive(migrate, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &migrating)
+		})
+
+		sel.Select(ctx)
+
+		info := workflow.GetInfo(ctx)
+		if migrating || info.GetContinueAsNewSuggested() || info.GetCurrentHistoryLength() >= maxHistoryLength {
+			// Carry over the commands already buffered, so that none is lost
+			// with this run. Commands arriving while the run completes make the
+			// server retry the task, which drains them as well.
+			var pending []Command
+			for {
+				var cmd Command
+				if !cmdChan.ReceiveAsync(&cmd) {
+					break
+				}
+				pending = append(pending, cmd)
+			}
+			logger.Info("Continuing as new to trim history", "HistoryLength", info.GetCurrentHistoryLength(), "Pending", len(pending))
+			return workflow.NewContinueAsNewError(ctx, SeatEntityWorkflow, flightID, seatID,
+				&SeatPersistedState{
+					State: State{
+						IsHeld:      state.isHeld,
+						IsConfirmed: state.isConfirmed,
+						HeldBy:      state.heldBy,
+						HeldFor:     state.heldFor,
+						ConfirmedBy: state.confirmedBy,
+						ExpiresAt:   state.expiresAt,
+						Waitlist:    state.waitlist,
+						Block:       state.block,
+						Overrides:   state.overrides,
+					},
+					History: hist.entries(),
+					Pending: pending,
+				})
+		}
+	}
+}
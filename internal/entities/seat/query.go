@@ -0,0 +1,106 @@
+package seat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// WorkflowID returns the ID of the entity workflow of a seat.
+func WorkflowID(flightID, seatID string) string {
+	return "seat::" + flightID + "::" + seatID
+}
+
+// StartOptions returns the options that start the entity workflow of a seat
+// with its first command, see SignalWithStartWorkflow.
+func StartOptions(flightID, seatID string) client.StartWorkflowOptions {
+	return client.StartWorkflowOptions{
+		ID:        WorkflowID(flightID, seatID),
+		TaskQueue: "seat-tq",
+		// very long-lived entity
+		WorkflowExecutionTimeout: 3650 * 24 * time.Hour, // ~10y
+		WorkflowRunTimeout:       365 * 24 * time.Hour,  // rotate via ContinueAsNew
+	}
+}
+
+// orderWorkflowID returns the ID of the order workflow of orderID; see orders.WorkflowID.
+func orderWorkflowID(orderID string) string {
+	return "order::" + orderID
+}
+
+// AllSeatIDs lists every seat of a flight: rows 1-5, columns A-F.
+func AllSeatIDs() []string {
+	seats := []string{}
+	for _, row := range seatRows {
+		for _, col := range seatColumns {
+			seats = append(seats, row+col)
+		}
+	}
+	return seats
+}
+
+// maxHeldSeatQueries bounds the seat queries CountHeldSeats runs at once.
+const maxHeldSeatQueries = 8
+
+// CountHeldSeats queries every seat of a flight, maxHeldSeatQueries at a
+// time, and counts those currently held for customer by orders other than
+// excludeOrderID. Seats whose entity workflow does not exist or cannot be
+// queried are not held.
+func CountHeldSeats(ctx context.Context, c client.Client, flightID, customer, excludeOrderID string) (int, error) {
+	var held atomic.Int64
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxHeldSeatQueries)
+	for _, seatID := range AllSeatIDs() {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			resp, err := c.QueryWorkflow(ctx, WorkflowID(flightID, seatID), "", "GetState")
+			if err != nil {
+				return
+			}
+			var state SeatState
+			if err := resp.Get(&state); err != nil {
+				return
+			}
+			if state.IsHeld && state.HeldFor == customer && state.HeldBy != excludeOrderID {
+				held.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	return int(held.Load()), ctx.Err()
+}
+
+// FreeSeats queries every seat of a flight and lists those an order could
+// hold right now: not held, confirmed or blocked. Seats whose entity workflow
+// does not exist yet are free; seats that cannot be queried are left out.
+func FreeSeats(ctx context.Context, c client.Client, flightID string) ([]string, error) {
+	free := []string{}
+	for _, seatID := range AllSeatIDs() {
+		resp, err := c.QueryWorkflow(ctx, WorkflowID(flightID, seatID), "", "GetState")
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			var notFound *serviceerror.NotFound
+			if errors.As(err, &notFound) {
+				free = append(free, seatID)
+			}
+			continue
+		}
+		var state SeatState
+		if err := resp.Get(&state); err != nil {
+			continue
+		}
+		if !state.IsHeld && !state.IsConfirmed && state.Block == nil {
+			free = append(free, seatID)
+		}
+	}
+	return free, nil
+}
@@ -0,0 +1,324 @@
+package seat
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// minHoldTTL is the shortest hold a HOLD or EXTEND grants.
+const minHoldTTL = time.Second
+
+// State is the state of a seat that commands act on. It is also what a seat
+// carries over Continue-As-New (see SeatPersistedState).
+type State struct {
+	IsHeld      bool   `json:"isHeld"`
+	IsConfirmed bool   `json:"isConfirmed"` // permanently reserved after payment
+	HeldBy      string `json:"heldBy"`      // which orderID holds it
+	HeldFor     string `json:"heldFor,omitempty"`
+	ConfirmedBy string `json:"confirmedBy"`
+	// ExpiresAt is when the hold lapses.
+	ExpiresAt time.Time `json:"expiresAt"`
+	// HoldToken fences the current hold: EXTEND, RELEASE and CONFIRM must
+	// carry it. LastToken is the latest token issued; every granted hold gets
+	// the next one, so tokens never repeat on a seat.
+	HoldToken int64 `json:"holdToken,omitempty"`
+	LastToken int64 `json:"lastToken,omitempty"`
+	// Waitlist holds the orders waiting for the seat, in FIFO order.
+	Waitlist []Waiter `json:"waitlist,omitempty"`
+	// Block is set while the seat is blocked by operations.
+	Block *Block `json:"block,omitempty"`
+	// Overrides are the latest admin overrides, oldest first.
+	Overrides []Override `json:"overrides,omitempty"`
+}
+
+// Notice is a WaitlistNotice owed to the order workflow of OrderID.
+type Notice struct {
+	OrderID string
+	WaitlistNotice
+}
+
+// Result is what came of a command.
+type Result struct {
+	Outcome Outcome
+	// Reason says why the command was ignored, or what else it did.
+	Reason string
+	// Notices are owed to orders, in order. FlightID and SeatID are left for
+	// the caller to fill in.
+	Notices []Notice
+}
+
+// Apply returns the state of seat s after cmd is processed at now, and what
+// came of it. Holds and blocks that lapsed by now are let go first (see
+// Expire). Apply is pure: it leaves s untouched and has no side effects, so
+// the caller arms timers and sends the notices.
+//
+// The rules:
+//   - a confirmed seat ignores everything but admin overrides;
+//   - a blocked seat ignores HOLD and EXTEND;
+//   - a HOLD on a seat held by another order is ignored, or queued if it
+//     asks for the waitlist;
+//   - only the holder may EXTEND, RELEASE or CONFIRM a hold, and only with
+//     the token of the hold;
+//   - a free seat goes to the first waiting order.
+func Apply(s State, cmd Command, now time.Time) (State, Result) {
+	s, res := Expire(s, now)
+	res.Outcome, res.Reason = s.apply(cmd, now, &res)
+	return s, res
+}
+
+// Expire returns the state of seat s at now: its hold and block are let go
+// if they lapsed, and a free seat goes to the first waiting order.
+func Expire(s State, now time.Time) (State, Result) {
+	s = s.clone()
+	var res Result
+	if s.IsHeld && !now.Before(s.ExpiresAt) {
+		s.clearHold()
+	}
+	if s.Block != nil && !s.Block.ExpiresAt.IsZero() && !now.Before(s.Block.ExpiresAt) {
+		s.Block = nil
+	}
+	s.grantNext(now, &res)
+	return s, res
+}
+
+func (s *State) apply(cmd Command, now time.Time, res *Result) (Outcome, string) {
+	// Ignore all commands on confirmed seats except idempotent confirm and overrides.
+	if s.IsConfirmed && !cmd.Type.IsOverride() {
+		if cmd.Type == CmdHold && cmd.Waitlist && cmd.OrderID != s.ConfirmedBy {
+			res.notify(cmd.OrderID, WaitlistNotice{Event: WaitlistClosed})
+		}
+		if cmd.Type == CmdConfirm && cmd.OrderID == s.ConfirmedBy {
+			return OutcomeIgnored, "already confirmed by this order"
+		}
+		return OutcomeIgnored, "seat is confirmed by " + s.ConfirmedBy
+	}
+
+	// Blocked seats take no holds until operations unblock them.
+	if s.Block != nil && (cmd.Type == CmdHold || cmd.Type == CmdExtend) {
+		return OutcomeIgnored, "seat is blocked: " + s.Block.Reason
+	}
+
+	switch cmd.Type {
+	case CmdHold:
+		if s.IsHeld && s.HeldBy != cmd.OrderID {
+			if !cmd.Waitlist {
+				return OutcomeIgnored, "seat is held by " + s.HeldBy
+			}
+			if i := s.waitlistIndex(cmd.OrderID); i >= 0 {
+				return OutcomeIgnored, fmt.Sprintf("already waitlisted at position %d", i+1)
+			}
+			s.Waitlist = append(s.Waitlist, Waiter{OrderID: cmd.OrderID, Customer: cmd.Customer, TTL: cmd.TTL})
+			res.notify(cmd.OrderID, WaitlistNotice{Event: WaitlistQueued, Position: len(s.Waitlist)})
+			return OutcomeApplied, fmt.Sprintf("waitlisted at position %d", len(s.Waitlist))
+		}
+		// Grant or refresh the hold of this order; a refresh keeps its token.
+		s.leaveWaitlist(cmd.OrderID)
+		if !s.IsHeld {
+			s.issueToken()
+		}
+		s.hold(cmd.OrderID, cmd.Customer, cmd.TTL, now)
+
+	case CmdExtend:
+		if reason := s.checkHolder(cmd); reason != "" {
+			return OutcomeIgnored, reason
+		}
+		s.hold(s.HeldBy, s.HeldFor, cmd.TTL, now)
+
+	case CmdRelease:
+		switch {
+		case s.IsHeld && s.HeldBy == cmd.OrderID:
+			if reason := s.checkHolder(cmd); reason != "" {
+				return OutcomeIgnored, reason
+			}
+			s.clearHold()
+			s.grantNext(now, res)
+		case s.leaveWaitlist(cmd.OrderID):
+			return OutcomeApplied, "left the waitlist"
+		default:
+			return OutcomeIgnored, "seat is not held by this order"
+		}
+
+	case CmdConfirm:
+		// Only the holder can confirm; once confirmed, the seat is permanent.
+		if reason := s.checkHolder(cmd); reason != "" {
+			return OutcomeIgnored, reason
+		}
+		s.clearHold()
+		s.IsConfirmed = true
+		s.ConfirmedBy = cmd.OrderID
+		// Nobody else can get the seat any more.
+		for _, w := range s.Waitlist {
+			res.notify(w.OrderID, WaitlistNotice{Event: WaitlistClosed})
+		}
+		s.Waitlist = nil
+
+	case CmdBlock:
+		// Operations cannot take a seat away from an order holding it.
+		if s.IsHeld {
+			return OutcomeIgnored, "seat is held by " + s.HeldBy
+		}
+		block := &Block{Reason: cmd.Reason, Actor: cmd.Actor, BlockedAt: now}
+		if cmd.TTL > 0 {
+			block.ExpiresAt = now.Add(cmd.TTL)
+		}
+		s.Block = block
+		return OutcomeApplied, cmd.Reason
+
+	case CmdUnblock:
+		if s.Block == nil {
+			return OutcomeIgnored, "seat is not blocked"
+		}
+		s.Block = nil
+		s.grantNext(now, res)
+
+	case CmdForceRelease:
+		if !s.IsHeld {
+			return OutcomeIgnored, "seat is not held"
+		}
+		holder := s.HeldBy
+		s.audit(cmd, now)
+		s.clearHold()
+		res.notify(holder, WaitlistNotice{Event: WaitlistRevoked})
+		s.grantNext(now, res)
+		return OutcomeApplied, cmd.Reason
+
+	case CmdForceUnconfirm:
+		if !s.IsConfirmed {
+			return OutcomeIgnored, "seat is not confirmed"
+		}
+		confirmer := s.ConfirmedBy
+		s.audit(cmd, now)
+		s.IsConfirmed = false
+		s.ConfirmedBy = ""
+		res.notify(confirmer, WaitlistNotice{Event: WaitlistRevoked})
+		s.grantNext(now, res)
+		return OutcomeApplied, cmd.Reason
+
+	case CmdReassign:
+		switch {
+		case s.IsConfirmed:
+			confirmer := s.ConfirmedBy
+			s.audit(cmd, now)
+			s.ConfirmedBy = cmd.OrderID
+			if confirmer != cmd.OrderID {
+				res.notify(confirmer, WaitlistNotice{Event: WaitlistRevoked})
+				res.notify(cmd.OrderID, WaitlistNotice{Event: WaitlistReassigned})
+			}
+		case s.IsHeld:
+			// The hold moves with its expiry, under a new token.
+			holder := s.HeldBy
+			s.audit(cmd, now)
+			s.leaveWaitlist(cmd.OrderID)
+			if holder != cmd.OrderID {
+				s.issueToken()
+				res.notify(holder, WaitlistNotice{Event: WaitlistRevoked})
+			}
+			s.HeldBy = cmd.OrderID
+			s.HeldFor = cmd.Customer
+			res.notify(cmd.OrderID, WaitlistNotice{Event: WaitlistGranted, ExpiresAt: s.ExpiresAt, HoldToken: s.HoldToken, Reassigned: true})
+		default:
+			return OutcomeIgnored, "seat is neither held nor confirmed"
+		}
+		return OutcomeApplied, cmd.Reason
+
+	default:
+		return OutcomeIgnored, "unknown command"
+	}
+	return OutcomeApplied, ""
+}
+
+// clone copies the slices of s, so that changing them leaves the original alone.
+func (s State) clone() State {
+	s.Waitlist = slices.Clone(s.Waitlist)
+	s.Overrides = slices.Clone(s.Overrides)
+	return s
+}
+
+func (s *State) hold(orderID, customer string, ttl time.Duration, now time.Time) {
+	s.IsHeld = true
+	s.HeldBy = orderID
+	s.HeldFor = customer
+	s.ExpiresAt = now.Add(max(ttl, minHoldTTL))
+}
+
+func (s *State) clearHold() {
+	s.IsHeld = false
+	s.HeldBy = ""
+	s.HeldFor = ""
+	s.ExpiresAt = time.Time{}
+	s.HoldToken = 0
+}
+
+// issueToken gives the hold about to be granted the next token.
+func (s *State) issueToken() {
+	s.LastToken++
+	s.HoldToken = s.LastToken
+}
+
+// checkHolder returns why cmd may not act on the hold of the seat, or "" if
+// it comes from the holder with the token of the current hold.
+func (s *State) checkHolder(cmd Command) string {
+	if !s.IsHeld || s.HeldBy != cmd.OrderID {
+		return "seat is not held by this order"
+	}
+	if cmd.HoldToken != s.HoldToken {
+		return "stale hold token"
+	}
+	return ""
+}
+
+// resultFor returns what came of a command from orderID that left the seat
+// in state s.
+func (s State) resultFor(orderID string, res Result) CommandResult {
+	r := CommandResult{Outcome: res.Outcome, Reason: res.Reason}
+	if s.IsHeld && s.HeldBy == orderID {
+		r.HoldToken, r.ExpiresAt = s.HoldToken, s.ExpiresAt
+	}
+	return r
+}
+
+// grantNext hands a free seat to the first waiting order.
+func (s *State) grantNext(now time.Time, res *Result) {
+	if s.IsHeld || s.IsConfirmed || s.Block != nil || len(s.Waitlist) == 0 {
+		return
+	}
+	next := s.Waitlist[0]
+	s.Waitlist = s.Waitlist[1:]
+	s.issueToken()
+	s.hold(next.OrderID, next.Customer, next.TTL, now)
+	res.notify(next.OrderID, WaitlistNotice{Event: WaitlistGranted, ExpiresAt: s.ExpiresAt, HoldToken: s.HoldToken})
+}
+
+func (s *State) waitlistIndex(orderID string) int {
+	return slices.IndexFunc(s.Waitlist, func(w Waiter) bool { return w.OrderID == orderID })
+}
+
+func (s *State) leaveWaitlist(orderID string) bool {
+	i := s.waitlistIndex(orderID)
+	if i < 0 {
+		return false
+	}
+	s.Waitlist = slices.Delete(s.Waitlist, i, i+1)
+	return true
+}
+
+// audit records an override before it changes the seat.
+func (s *State) audit(cmd Command, now time.Time) {
+	previous := s.HeldBy
+	if s.IsConfirmed {
+		previous = s.ConfirmedBy
+	}
+	o := Override{Command: cmd.Type, Actor: cmd.Actor, Reason: cmd.Reason, At: now, PreviousOrderID: previous}
+	if cmd.Type == CmdReassign {
+		o.OrderID = cmd.OrderID
+	}
+	s.Overrides = append(s.Overrides, o)
+	if len(s.Overrides) > maxOverrides {
+		s.Overrides = s.Overrides[len(s.Overrides)-maxOverrides:]
+	}
+}
+
+func (r *Result) notify(orderID string, notice WaitlistNotice) {
+	r.Notices = append(r.Notices, Notice{OrderID: orderID, WaitlistNotice: notice})
+}
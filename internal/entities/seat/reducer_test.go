@@ -0,0 +1,208 @@
+package seat
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// maxTestTTL bounds the TTLs of the random commands.
+const maxTestTTL = 20 * time.Minute
+
+var testCommandTypes = []CommandType{
+	CmdHold, CmdHold, CmdHold, CmdExtend, CmdExtend, CmdRelease, CmdRelease, CmdConfirm,
+	CmdBlock, CmdUnblock, CmdForceRelease, CmdForceUnconfirm, CmdReassign,
+}
+
+// randomCommand returns a command from one of a few orders, so that they
+// compete for the seat.
+func randomCommand(r *rand.Rand) Command {
+	return Command{
+		Type:     testCommandTypes[r.Intn(len(testCommandTypes))],
+		OrderID:  "o-" + strconv.Itoa(r.Intn(4)+1),
+		Customer: "c-" + strconv.Itoa(r.Intn(2)+1),
+		TTL:      time.Duration(r.Int63n(int64(maxTestTTL))),
+		Waitlist: r.Intn(2) == 0,
+		Reason:   "test",
+		Actor:    "ops",
+	}
+}
+
+// randomToken returns the token of the current hold of s most of the time,
+// and otherwise one of an earlier hold.
+func randomToken(r *rand.Rand, s State) int64 {
+	if r.Intn(4) > 0 || s.LastToken == 0 {
+		return s.HoldToken
+	}
+	return r.Int63n(s.LastToken) + 1
+}
+
+// runRandomCommands applies random commands at random times to a free seat,
+// calling check with every step.
+func runRandomCommands(t *testing.T, check func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result)) {
+	for seed := int64(1); seed <= 200; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+		var s State
+		for step := 0; step < 200; step++ {
+			now = now.Add(time.Duration(r.Int63n(int64(5 * time.Minute))))
+			cmd := randomCommand(r)
+			cmd.HoldToken = randomToken(r, s)
+			next, res := Apply(s, cmd, now)
+			check(t, s, cmd, now, next, res)
+			if t.Failed() {
+				t.Fatalf("seed %d, step %d: %+v at %s on %+v gave %+v (%s %s)", seed, step, cmd, now, s, next, res.Outcome, res.Reason)
+			}
+			s = next
+		}
+	}
+}
+
+func TestApply_ConfirmedSeatNeverChangesOwner(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		if !before.IsConfirmed || cmd.Type.IsOverride() {
+			return
+		}
+		require.True(t, after.IsConfirmed)
+		require.Equal(t, before.ConfirmedBy, after.ConfirmedBy)
+		require.False(t, after.IsHeld)
+		require.Equal(t, OutcomeIgnored, res.Outcome)
+	})
+}
+
+func TestApply_OnlyTheHolderExtendsOrReleases(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		if cmd.Type != CmdExtend && cmd.Type != CmdRelease {
+			return
+		}
+		current, _ := Expire(before, now)
+		if current.IsHeld && current.HeldBy == cmd.OrderID {
+			return
+		}
+		// Another order's hold is left exactly as it was.
+		require.Equal(t, current.IsHeld, after.IsHeld)
+		require.Equal(t, current.HeldBy, after.HeldBy)
+		require.Equal(t, current.ExpiresAt, after.ExpiresAt)
+		if cmd.Type == CmdExtend {
+			require.Equal(t, OutcomeIgnored, res.Outcome)
+		}
+	})
+}
+
+func TestApply_StaleTokensAreIgnored(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		if cmd.Type != CmdExtend && cmd.Type != CmdRelease && cmd.Type != CmdConfirm {
+			return
+		}
+		current, _ := Expire(before, now)
+		if !current.IsHeld || current.HeldBy != cmd.OrderID || current.HoldToken == cmd.HoldToken {
+			return
+		}
+		require.Equal(t, OutcomeIgnored, res.Outcome)
+		require.Equal(t, "stale hold token", res.Reason)
+		require.Equal(t, current, after)
+	})
+}
+
+func TestApply_HoldTokensNeverRepeat(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		require.GreaterOrEqual(t, after.LastToken, before.LastToken)
+		require.Equal(t, after.IsHeld, after.HoldToken != 0)
+		require.LessOrEqual(t, after.HoldToken, after.LastToken)
+		// A hold that changed hands carries a token never issued before.
+		if after.IsHeld && (!before.IsHeld || before.HeldBy != after.HeldBy) {
+			require.Greater(t, after.HoldToken, before.LastToken)
+		}
+		// The holder keeps its token while its hold lasts.
+		current, _ := Expire(before, now)
+		if current.IsHeld && after.IsHeld && current.HeldBy == after.HeldBy {
+			require.Equal(t, current.HoldToken, after.HoldToken)
+		}
+	})
+}
+
+func TestApply_HoldsNeverOutliveTheirTTL(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		if !after.IsHeld {
+			require.True(t, after.ExpiresAt.IsZero())
+			return
+		}
+		require.True(t, after.ExpiresAt.After(now), "hold has lapsed")
+		require.LessOrEqual(t, after.ExpiresAt.Sub(now), maxTestTTL, "hold lasts longer than any TTL")
+		if after.HeldBy != before.HeldBy || !after.ExpiresAt.Equal(before.ExpiresAt) {
+			return
+		}
+		// An unchanged hold is one that had not lapsed yet.
+		require.True(t, before.ExpiresAt.After(now))
+	})
+}
+
+func TestApply_KeepsSeatConsistent(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		require.False(t, after.IsHeld && after.IsConfirmed, "held and confirmed")
+		require.False(t, after.IsHeld && after.Block != nil, "held and blocked")
+		require.Equal(t, after.IsHeld, after.HeldBy != "")
+		require.Equal(t, after.IsConfirmed, after.ConfirmedBy != "")
+		require.LessOrEqual(t, len(after.Overrides), maxOverrides)
+		seen := map[string]bool{}
+		for _, w := range after.Waitlist {
+			require.False(t, seen[w.OrderID], "order waitlisted twice")
+			require.NotEqual(t, after.HeldBy, w.OrderID, "holder is waitlisted")
+			seen[w.OrderID] = true
+		}
+		// A free seat with a waitlist goes to the first waiter.
+		if !after.IsHeld && !after.IsConfirmed && after.Block == nil {
+			require.Empty(t, after.Waitlist)
+		}
+	})
+}
+
+func TestApply_IsPure(t *testing.T) {
+	runRandomCommands(t, func(t *testing.T, before State, cmd Command, now time.Time, after State, res Result) {
+		snapshot := before.clone()
+		again, res2 := Apply(before, cmd, now)
+		require.Equal(t, after, again)
+		require.Equal(t, res, res2)
+		require.True(t, reflect.DeepEqual(snapshot, before), "Apply changed its input")
+	})
+}
+
+func TestApply_WaitlistGrantsInOrder(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	s, _ := Apply(State{}, Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute}, now)
+	s, res := Apply(s, Command{Type: CmdHold, OrderID: "o-2", TTL: time.Minute, Waitlist: true}, now)
+	require.Equal(t, []Notice{{OrderID: "o-2", WaitlistNotice: WaitlistNotice{Event: WaitlistQueued, Position: 1}}}, res.Notices)
+
+	// The hold lapses; the next command finds the seat granted to o-2.
+	later := now.Add(time.Minute)
+	s, res = Apply(s, Command{Type: CmdExtend, OrderID: "o-1", TTL: time.Minute}, later)
+	require.Equal(t, OutcomeIgnored, res.Outcome)
+	require.Equal(t, "o-2", s.HeldBy)
+	require.Equal(t, later.Add(time.Minute), s.ExpiresAt)
+	require.Equal(t, []Notice{{OrderID: "o-2", WaitlistNotice: WaitlistNotice{Event: WaitlistGranted, ExpiresAt: later.Add(time.Minute), HoldToken: 2}}}, res.Notices)
+}
+
+func TestApply_OverridesRevokeConfirmedSeats(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	confirmed := State{IsConfirmed: true, ConfirmedBy: "o-1", LastToken: 1}
+	revoked := []Notice{{OrderID: "o-1", WaitlistNotice: WaitlistNotice{Event: WaitlistRevoked}}}
+
+	s, res := Apply(confirmed, Command{Type: CmdForceUnconfirm, Actor: "ops", Reason: "refund"}, now)
+	require.Equal(t, OutcomeApplied, res.Outcome)
+	require.False(t, s.IsConfirmed)
+	require.Equal(t, revoked, res.Notices)
+
+	s, res = Apply(confirmed, Command{Type: CmdReassign, OrderID: "o-2", Actor: "ops", Reason: "rebooking"}, now)
+	require.Equal(t, OutcomeApplied, res.Outcome)
+	require.Equal(t, "o-2", s.ConfirmedBy)
+	require.Equal(t, append(revoked, Notice{OrderID: "o-2", WaitlistNotice: WaitlistNotice{Event: WaitlistReassigned}}), res.Notices)
+
+	// Reassigning a seat to the order that has it takes nothing away.
+	_, res = Apply(confirmed, Command{Type: CmdReassign, OrderID: "o-1", Actor: "ops", Reason: "no-op"}, now)
+	require.Equal(t, OutcomeApplied, res.Outcome)
+	require.Empty(t, res.Notices)
+}
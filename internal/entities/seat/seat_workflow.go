@@ -13,29 +13,134 @@ const (
 	CmdExtend  CommandType = "EXTEND"
 	CmdRelease CommandType = "RELEASE"
 	CmdConfirm CommandType = "CONFIRM" // NEW - permanent lock after payment
+	CmdBlock   CommandType = "BLOCK"   // operational block, no order involved
+	CmdUnblock CommandType = "UNBLOCK"
+
+	// Admin overrides bypass the holder checks and are recorded in the seat's
+	// Overrides. REASSIGN moves the hold or confirmation to Command.OrderID.
+	CmdForceRelease   CommandType = "FORCE_RELEASE"
+	CmdForceUnconfirm CommandType = "FORCE_UNCONFIRM"
+	CmdReassign       CommandType = "REASSIGN"
 )
 
+// IsOverride reports whether t is an admin override.
+func (t CommandType) IsOverride() bool {
+	return t == CmdForceRelease || t == CmdForceUnconfirm || t == CmdReassign
+}
+
+// maxOverrides bounds the audit trail of admin overrides kept per seat.
+const maxOverrides = 50
+
 type Command struct {
 	Type    CommandType
 	OrderID string
-	TTL     time.Duration
+	// TTL is how long a HOLD or EXTEND lasts; for BLOCK, how long the block
+	// lasts, zero meaning until UNBLOCK.
+	TTL time.Duration
+	// Customer identifies who the holding order belongs to, for per-customer hold limits.
+	Customer string
+	// Waitlist queues a HOLD on a seat held by another order instead of ignoring
+	// it; the hold is granted when the seat is released or its hold expires.
+	Waitlist bool
+	// Reason and Actor record why and by whom a seat was blocked, unblocked or
+	// overridden.
+	Reason string
+	Actor  string
+	// HoldToken is the token of the hold that an EXTEND, RELEASE or CONFIRM
+	// acts on, as returned when the hold was granted. Commands carrying the
+	// token of an earlier hold are ignored.
+	HoldToken int64
 }
 
-type seatState struct {
-	isHeld      bool
-	isConfirmed bool   // NEW - permanently reserved after payment
-	heldBy      string // which orderID holds it
-	confirmedBy string // NEW - which orderID confirmed it
-	expiresAt   time.Time
+// CommandUpdate applies a Command and returns its CommandResult. It is how
+// order workflows learn the token of the holds they are granted; the "cmd"
+// signal applies commands without telling the sender what came of them.
+const CommandUpdate = "Command"
+
+// CommandResult is what came of a command sent with CommandUpdate.
+type CommandResult struct {
+	Outcome Outcome
+	// Reason says why the command was ignored, or what else it did.
+	Reason string
+	// HoldToken and ExpiresAt describe the hold of the sending order after the
+	// command; HoldToken is zero if the order does not hold the seat.
+	HoldToken int64
+	ExpiresAt time.Time
+}
+
+// WaitlistSignal is sent to the order workflow of a waitlisted order with a
+// WaitlistNotice, and to an order whose hold an admin took away.
+const WaitlistSignal = "SeatWaitlist"
+
+// WaitlistEvent is what happened to an order's place on a seat's waitlist.
+type WaitlistEvent string
+
+const (
+	// WaitlistQueued: the order is waiting for the seat.
+	WaitlistQueued WaitlistEvent = "queued"
+	// WaitlistGranted: the seat is now held by the order.
+	WaitlistGranted WaitlistEvent = "granted"
+	// WaitlistClosed: the seat was confirmed by another order.
+	WaitlistClosed WaitlistEvent = "closed"
+	// WaitlistRevoked: an admin released the order's hold or gave it to another order.
+	WaitlistRevoked WaitlistEvent = "revoked"
+	// WaitlistReassigned: an admin gave the order a seat another order had
+	// confirmed; the seat is now confirmed by the order.
+	WaitlistReassigned WaitlistEvent = "reassigned"
+)
+
+// WaitlistNotice tells an order about its place on the waitlist of a seat.
+type WaitlistNotice struct {
+	FlightID string
+	SeatID   string
+	Event    WaitlistEvent
+	// Position is the 1-based place in the waitlist when queued.
+	Position int
+	// ExpiresAt is when the granted hold expires.
+	ExpiresAt time.Time
+	// HoldToken is the token of the granted hold; see Command.HoldToken.
+	HoldToken int64
+	// Reassigned marks the grant of a hold an administrator handed over
+	// (REASSIGN), which the order may never have selected.
+	Reassigned bool
+}
+
+// Waiter is an order waiting for a seat.
+type Waiter struct {
+	OrderID  string        `json:"orderId"`
+	Customer string        `json:"customer,omitempty"`
+	TTL      time.Duration `json:"ttl"`
+}
+
+// Override is the audit record of an admin override.
+type Override struct {
+	Command CommandType `json:"command"`
+	Actor   string      `json:"actor"`
+	Reason  string      `json:"reason"`
+	At      time.Time   `json:"at"`
+	// PreviousOrderID held or confirmed the seat before the override.
+	PreviousOrderID string `json:"previousOrderId,omitempty"`
+	// OrderID is the order a REASSIGN gave the seat to.
+	OrderID string `json:"orderId,omitempty"`
+}
+
+// Block records why a seat was taken out of sale by operations.
+type Block struct {
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	BlockedAt time.Time `json:"blockedAt"`
+	// ExpiresAt is when the block lifts by itself; zero means until UNBLOCK.
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
 }
 
 // SeatPersistedState is the exported DTO for Continue-As-New serialization
 type SeatPersistedState struct {
-	IsHeld      bool      `json:"isHeld"`
-	IsConfirmed bool      `json:"isConfirmed"`
-	HeldBy      string    `json:"heldBy"`
-	ConfirmedBy string    `json:"confirmedBy"`
-	ExpiresAt   time.Time `json:"expiresAt"`
+	State
+	// History holds the latest processed commands, oldest first.
+	History []HistoryEntry `json:"history,omitempty"`
+	// Pending holds the commands received but not yet processed by the previous
+	// run, in arrival order. The new run applies them before anything else.
+	Pending []Command `json:"pending,omitempty"`
 }
 
 // SeatState represents the public state of a seat
@@ -43,92 +148,196 @@ type SeatState struct {
 	IsHeld      bool   `json:"isHeld"`
 	IsConfirmed bool   `json:"isConfirmed"`
 	HeldBy      string `json:"heldBy"`
+	HeldFor     string `json:"heldFor,omitempty"`
 	ConfirmedBy string `json:"confirmedBy"`
 	ExpiresAt   string `json:"expiresAt"` // ISO string for JSON serialization
+	// Waitlist lists the orders waiting for the seat, in FIFO order.
+	Waitlist []string `json:"waitlist,omitempty"`
+	// Block is set while the seat is blocked; blocked seats reject holds.
+	Block *Block `json:"block,omitempty"`
+	// Overrides are the latest admin overrides, oldest first.
+	Overrides []Override `json:"overrides,omitempty"`
 }
 
+// maxHistoryLength is the history length at which a seat continues as new even
+// if the server has not suggested it yet.
+const maxHistoryLength = 10000
+
 // SeatEntityWorkflow manages the state of a single seat.
 // Its workflow ID should be "seat::<flightID>::<seatID>".
+// The rules of the seat live in Apply; the workflow feeds it the commands and
+// expiry timers, and sends the notices it returns.
 func SeatEntityWorkflow(ctx workflow.Context, flightID, seatID string, initial *SeatPersistedState) error {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Starting SeatEntityWorkflow", "FlightID", flightID, "SeatID", seatID)
 
-	state := seatState{}
+	if workflow.GetVersion(ctx, reducerVersion, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return legacySeatEntityWorkflow(ctx, flightID, seatID, initial)
+	}
+
+	var state State
+	hist := newHistory(nil)
 	if initial != nil {
-		state = seatState{
-			isHeld:      initial.IsHeld,
-			isConfirmed: initial.IsConfirmed,
-			heldBy:      initial.HeldBy,
-			confirmedBy: initial.ConfirmedBy,
-			expiresAt:   initial.ExpiresAt,
-		}
-		logger.Info("Restored state from ContinueAsNew", "IsHeld", state.isHeld, "IsConfirmed", state.isConfirmed, "HeldBy", state.heldBy, "ConfirmedBy", state.confirmedBy)
+		state = initial.State
+		hist = newHistory(initial.History)
+		logger.Info("Restored state from ContinueAsNew", "IsHeld", state.IsHeld, "IsConfirmed", state.IsConfirmed, "HeldBy", state.HeldBy, "ConfirmedBy", state.ConfirmedBy)
 	}
 
 	cmdChan := workflow.GetSignalChannel(ctx, "cmd")
 	var holdTimer workflow.Future
 	var holdCancel workflow.CancelFunc
-	processed := 0
+	var blockTimer workflow.Future
+	var blockCancel workflow.CancelFunc
 
 	// Register query handler to expose seat state
 	workflow.SetQueryHandler(ctx, "GetState", func() (SeatState, error) {
 		var exp string
-		if !state.expiresAt.IsZero() {
-			exp = state.expiresAt.Format(time.RFC3339)
+		if !state.ExpiresAt.IsZero() {
+			exp = state.ExpiresAt.Format(time.RFC3339)
+		}
+		var waitlist []string
+		for _, w := range state.Waitlist {
+			waitlist = append(waitlist, w.OrderID)
 		}
 		return SeatState{
-			IsHeld:      state.isHeld,
-			IsConfirmed: state.isConfirmed,
-			HeldBy:      state.heldBy,
-			ConfirmedBy: state.confirmedBy,
+			IsHeld:      state.IsHeld,
+			IsConfirmed: state.IsConfirmed,
+			HeldBy:      state.HeldBy,
+			HeldFor:     state.HeldFor,
+			ConfirmedBy: state.ConfirmedBy,
 			ExpiresAt:   exp,
+			Waitlist:    waitlist,
+			Block:       state.Block,
+			Overrides:   state.Overrides,
 		}, nil
 	})
 
-	makeHoldTimer := func(ttl time.Duration) {
-		// Clamp TTL defensively to prevent zero/negative values
-		if ttl < time.Second {
-			ttl = time.Second
+	workflow.SetQueryHandler(ctx, GetHistoryQuery, func() ([]HistoryEntry, error) {
+		return hist.entries(), nil
+	})
+
+	// Commands arrive both as signals and as updates, whose handlers run
+	// concurrently; the mutex keeps a command and the notices it owes from
+	// interleaving with another. Whoever calls handle or expire holds it.
+	mu := workflow.NewMutex(ctx)
+
+	// syncTimers re-arms the hold and block expiry timers after the seat went
+	// from prev to state, and arms those that are missing.
+	syncTimers := func(prev State) {
+		if holdTimer == nil || prev.IsHeld != state.IsHeld || !prev.ExpiresAt.Equal(state.ExpiresAt) {
+			if holdCancel != nil {
+				holdCancel()
+				holdCancel, holdTimer = nil, nil
+			}
+			if state.IsHeld {
+				holdCtx, cancel := workflow.WithCancel(ctx)
+				holdCancel = cancel
+				holdTimer = workflow.NewTimer(holdCtx, max(state.ExpiresAt.Sub(workflow.Now(ctx)), time.Millisecond))
+			}
+		}
+		if blockTimer == nil || prev.Block != state.Block {
+			if blockCancel != nil {
+				blockCancel()
+				blockCancel, blockTimer = nil, nil
+			}
+			if state.Block != nil && !state.Block.ExpiresAt.IsZero() {
+				blockCtx, cancel := workflow.WithCancel(ctx)
+				blockCancel = cancel
+				blockTimer = workflow.NewTimer(blockCtx, max(state.Block.ExpiresAt.Sub(workflow.Now(ctx)), time.Millisecond))
+			}
 		}
+	}
+
+	// commit makes next the state of the seat and sends the notices owed to
+	// orders. An order that can no longer be told it was granted the seat
+	// loses its turn, as if it had released it.
+	commit := func(next State, notices []Notice) {
+		for {
+			prev := state
+			state = next
+			syncTimers(prev)
 
-		// cancel previous timer (if any)
-		if holdCancel != nil {
-			holdCancel()
-			holdCancel = nil
-			holdTimer = nil
+			lost := ""
+			for _, n := range notices {
+				n.FlightID, n.SeatID = flightID, seatID
+				err := workflow.SignalExternalWorkflow(ctx, orderWorkflowID(n.OrderID), "", WaitlistSignal, n.WaitlistNotice).Get(ctx, nil)
+				if err == nil {
+					continue
+				}
+				logger.Warn("Failed to notify order", "OrderID", n.OrderID, "Event", n.Event, "Error", err)
+				if n.Event == WaitlistGranted && state.HeldBy == n.OrderID {
+					lost = n.OrderID
+				}
+			}
+			if lost == "" {
+				return
+			}
+			var res Result
+			next, res = Apply(state, Command{Type: CmdRelease, OrderID: lost, HoldToken: state.HoldToken}, workflow.Now(ctx))
+			notices = res.Notices
+		}
+	}
+
+	// handle applies a command and records it in the history.
+	handle := func(cmd Command) CommandResult {
+		next, res := Apply(state, cmd, workflow.Now(ctx))
+		if res.Outcome == OutcomeIgnored {
+			logger.Warn("Command ignored", "Type", cmd.Type, "OrderID", cmd.OrderID, "Reason", res.Reason)
+		} else {
+			logger.Info("Command applied", "Type", cmd.Type, "OrderID", cmd.OrderID, "Actor", cmd.Actor, "Reason", res.Reason,
+				"HeldBy", next.HeldBy, "ExpiresAt", next.ExpiresAt, "ConfirmedBy", next.ConfirmedBy, "Waitlist", len(next.Waitlist))
 		}
-		holdCtx, cancel := workflow.WithCancel(ctx)
-		holdCancel = cancel
-		holdTimer = workflow.NewTimer(holdCtx, ttl)
-		state.expiresAt = workflow.Now(ctx).Add(ttl)
+		commit(next, res.Notices)
+		hist.add(HistoryEntry{
+			At:          workflow.Now(ctx),
+			Command:     cmd.Type,
+			OrderID:     cmd.OrderID,
+			Actor:       cmd.Actor,
+			Outcome:     res.Outcome,
+			Reason:      res.Reason,
+			HeldBy:      state.HeldBy,
+			ConfirmedBy: state.ConfirmedBy,
+		})
+		return state.resultFor(cmd.OrderID, res)
 	}
 
-	clearHold := func() {
-		state.isHeld = false
-		state.heldBy = ""
-		state.expiresAt = time.Time{}
-		if holdCancel != nil {
-			holdCancel()
-			holdCancel = nil
-			holdTimer = nil
+	// expire lets go of the hold or block whose timer fired, or that lapsed
+	// between runs, and arms the timers of the rest.
+	expire := func() {
+		prev := state
+		next, res := Expire(state, workflow.Now(ctx))
+		if prev.IsHeld && prev.HeldBy != next.HeldBy {
+			logger.Info("Hold EXPIRED, releasing seat", "HeldBy", prev.HeldBy)
+		}
+		if prev.Block != nil && next.Block == nil {
+			logger.Info("Block EXPIRED, seat back on sale", "Reason", prev.Block.Reason)
 		}
+		commit(next, res.Notices)
+	}
+
+	// Updates wait for the seat to catch up and for the commands carried
+	// over. The handler is registered before anything blocks, so that the
+	// update starting the seat with UpdateWithStart finds it.
+	_ = mu.Lock(ctx)
+	if err := workflow.SetUpdateHandler(ctx, CommandUpdate, func(ctx workflow.Context, cmd Command) (CommandResult, error) {
+		logger.Info("Received command update", "Type", cmd.Type, "OrderID", cmd.OrderID)
+		_ = mu.Lock(ctx)
+		defer mu.Unlock()
+		return handle(cmd), nil
+	}); err != nil {
+		return err
 	}
 
-	// Restore timer if seat was held and not expired
-	if state.isHeld && !state.expiresAt.IsZero() && workflow.Now(ctx).Before(state.expiresAt) {
-		// Re-arm timer for remaining duration
-		remaining := state.expiresAt.Sub(workflow.Now(ctx))
-		holdCtx, cancel := workflow.WithCancel(ctx)
-		holdCancel = cancel
-		holdTimer = workflow.NewTimer(holdCtx, remaining)
-		logger.Info("Restored hold timer", "Remaining", remaining, "HeldBy", state.heldBy)
-	} else if state.isHeld {
-		// Hold already expired between runs
-		logger.Info("Hold expired during ContinueAsNew, clearing", "HeldBy", state.heldBy)
-		state.isHeld = false
-		state.heldBy = ""
-		state.expiresAt = time.Time{}
+	expire()
+
+	// Commands the previous run received but did not get to go first.
+	if initial != nil && len(initial.Pending) > 0 {
+		logger.Info("Applying commands carried over by ContinueAsNew", "Count", len(initial.Pending))
+		for _, cmd := range initial.Pending {
+			handle(cmd)
+		}
 	}
+	mu.Unlock()
 
 	for {
 		sel := workflow.NewSelector(ctx)
@@ -138,83 +347,51 @@ func SeatEntityWorkflow(ctx workflow.Context, flightID, seatID string, initial *
 			var cmd Command
 			c.Receive(ctx, &cmd)
 			logger.Info("Received command", "Type", cmd.Type, "OrderID", cmd.OrderID)
-
-			// Early guard: ignore all commands on confirmed seats except idempotent confirm
-			if state.isConfirmed {
-				if cmd.Type == CmdConfirm && cmd.OrderID == state.confirmedBy {
-					logger.Info("Confirm idempotent - already confirmed", "OrderID", cmd.OrderID)
-				} else {
-					logger.Warn("Ignoring command on confirmed seat", "Type", cmd.Type, "ConfirmedBy", state.confirmedBy)
-				}
-				return
-			}
-
-			switch cmd.Type {
-
-			case CmdHold:
-				// If already held by someone else and not expired, reject
-				if state.isHeld && state.heldBy != cmd.OrderID && workflow.Now(ctx).Before(state.expiresAt) {
-					logger.Warn("Seat already held and not expired", "HeldBy", state.heldBy)
-					return
-				}
-				// Grant/refresh hold for this order
-				state.isHeld = true
-				state.heldBy = cmd.OrderID
-				makeHoldTimer(cmd.TTL)
-				logger.Info("Seat HELD", "HeldBy", state.heldBy, "ExpiresAt", state.expiresAt)
-
-			case CmdExtend:
-				// Only the current holder may extend
-				if state.isHeld && state.heldBy == cmd.OrderID {
-					makeHoldTimer(cmd.TTL)
-					logger.Info("Seat HOLD EXTENDED", "HeldBy", state.heldBy, "ExpiresAt", state.expiresAt)
-				} else {
-					logger.Warn("Extend ignored - not held by this order", "HeldBy", state.heldBy, "OrderID", cmd.OrderID)
-				}
-
-			case CmdRelease:
-				if state.isHeld && state.heldBy == cmd.OrderID {
-					clearHold()
-					logger.Info("Seat RELEASED by order", "OrderID", cmd.OrderID)
-				} else {
-					logger.Warn("Release ignored - not held by this order", "HeldBy", state.heldBy, "OrderID", cmd.OrderID)
-				}
-
-			case CmdConfirm:
-				// Only holder can confirm; once confirmed, make it permanent
-				if !state.isHeld || state.heldBy != cmd.OrderID {
-					logger.Warn("Confirm ignored - seat not held by this order", "HeldBy", state.heldBy, "OrderID", cmd.OrderID)
-					return
-				}
-				state.isConfirmed = true
-				state.confirmedBy = cmd.OrderID
-				clearHold()
-				logger.Info("Seat PERMANENTLY CONFIRMED", "ConfirmedBy", state.confirmedBy)
-			}
+			_ = mu.Lock(ctx)
+			defer mu.Unlock()
+			handle(cmd)
 		})
 
-		// Hold expiry
+		// Hold and block expiry
 		if holdTimer != nil {
 			sel.AddFuture(holdTimer, func(f workflow.Future) {
-				// Timer fired (not canceled) → release hold
-				logger.Info("Hold EXPIRED, releasing seat", "HeldBy", state.heldBy)
-				clearHold()
+				holdCancel, holdTimer = nil, nil
+				_ = mu.Lock(ctx)
+				defer mu.Unlock()
+				expire()
+			})
+		}
+		if blockTimer != nil {
+			sel.AddFuture(blockTimer, func(f workflow.Future) {
+				blockCancel, blockTimer = nil, nil
+				_ = mu.Lock(ctx)
+				defer mu.Unlock()
+				expire()
 			})
 		}
 
 		sel.Select(ctx)
 
-		processed++
-		if processed%1000 == 0 {
-			logger.Info("Continuing as new to trim history", "Processed", processed)
+		info := workflow.GetInfo(ctx)
+		if info.GetContinueAsNewSuggested() || info.GetCurrentHistoryLength() >= maxHistoryLength {
+			// Carry over the commands already buffered, so that none is lost
+			// with this run. Commands arriving while the run completes make the
+			// server retry the task, which drains them as well. Updates being
+			// handled finish first, so that their callers get a result.
+			if err := workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) }); err != nil {
+				return err
+			}
+			var pending []Command
+			for {
+				var cmd Command
+				if !cmdChan.ReceiveAsync(&cmd) {
+					break
+				}
+				pending = append(pending, cmd)
+			}
+			logger.Info("Continuing as new to trim history", "HistoryLength", info.GetCurrentHistoryLength(), "Pending", len(pending))
 			return workflow.NewContinueAsNewError(ctx, SeatEntityWorkflow, flightID, seatID,
-				&SeatPersistedState{
-					IsHeld:      state.isHeld,
-					IsConfirmed: state.isConfirmed,
-					HeldBy:      state.heldBy,
-					ConfirmedBy: state.confirmedBy,
-					ExpiresAt:   state.expiresAt,
-				})
+				&SeatPersistedState{State: state, History: hist.entries(), Pending: pending})
 		}
 	}
 }
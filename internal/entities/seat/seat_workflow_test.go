@@ -1,11 +1,16 @@
 package seat
 
 import (
+	"fmt"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
 )
 
 type SeatWorkflowTestSuite struct {
@@ -50,3 +55,415 @@ func (s *SeatWorkflowTestSuite) TestSeatWorkflow_CommandStructure() {
 	s.Equal("ORDER-123", cmd.OrderID)
 	s.Equal(15*time.Minute, cmd.TTL)
 }
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_WaitlistGrantsInOrder() {
+	env := s.NewTestWorkflowEnvironment()
+
+	var notices []WaitlistNotice
+	var notified []string
+	env.OnSignalExternalWorkflow(mock.Anything, mock.Anything, "", WaitlistSignal, mock.Anything).
+		Return(func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			notified = append(notified, workflowID)
+			notices = append(notices, arg.(WaitlistNotice))
+			return nil
+		})
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-2", TTL: time.Minute, Waitlist: true})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-3", TTL: time.Minute, Waitlist: true})
+		// Without opting in, a HOLD on a held seat is still ignored.
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-4", TTL: time.Minute})
+	}, time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.Equal("o-1", st.HeldBy)
+		s.Equal([]string{"o-2", "o-3"}, st.Waitlist)
+		s.Equal([]WaitlistEvent{WaitlistQueued, WaitlistQueued}, []WaitlistEvent{notices[0].Event, notices[1].Event})
+		s.Equal(2, notices[1].Position)
+
+		env.SignalWorkflow("cmd", Command{Type: CmdRelease, OrderID: "o-1", HoldToken: 1})
+	}, 2*time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.Equal("o-2", st.HeldBy)
+		s.Equal([]string{"o-3"}, st.Waitlist)
+		s.Equal(WaitlistGranted, notices[2].Event)
+		s.Equal("order::o-2", notified[2])
+		s.Equal("FL1", notices[2].FlightID)
+		s.Equal("1A", notices[2].SeatID)
+		s.Equal(int64(2), notices[2].HoldToken)
+	}, 3*time.Second)
+
+	// o-2 lets its hold expire, so o-3 gets the seat; then o-3 confirms it.
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.Equal("o-3", st.HeldBy)
+		s.Empty(st.Waitlist)
+		s.Equal("order::o-3", notified[3])
+		s.Equal(WaitlistGranted, notices[3].Event)
+		s.Equal(int64(3), notices[3].HoldToken)
+	}, 2*time.Minute)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+	s.True(env.IsWorkflowCompleted())
+	s.Len(notices, 4)
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_ConfirmClosesWaitlist() {
+	env := s.NewTestWorkflowEnvironment()
+
+	var closed []string
+	env.OnSignalExternalWorkflow(mock.Anything, mock.Anything, "", WaitlistSignal, mock.Anything).
+		Return(func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			if arg.(WaitlistNotice).Event == WaitlistClosed {
+				closed = append(closed, workflowID)
+			}
+			return nil
+		})
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-2", TTL: time.Minute, Waitlist: true})
+		env.SignalWorkflow("cmd", Command{Type: CmdConfirm, OrderID: "o-1", HoldToken: 1})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-3", TTL: time.Minute, Waitlist: true})
+	}, time.Second)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", &SeatPersistedState{})
+	s.True(env.IsWorkflowCompleted())
+	s.Equal([]string{"order::o-2", "order::o-3"}, closed)
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_BlockRejectsHolds() {
+	env := s.NewTestWorkflowEnvironment()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdBlock, Reason: "broken recline", Actor: "apikey:ops"})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+	}, time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.False(st.IsHeld)
+		s.Require().NotNil(st.Block)
+		s.Equal("broken recline", st.Block.Reason)
+		s.Equal("apikey:ops", st.Block.Actor)
+		s.True(st.Block.ExpiresAt.IsZero())
+
+		env.SignalWorkflow("cmd", Command{Type: CmdUnblock, Actor: "apikey:ops"})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+		// A seat held by an order cannot be blocked.
+		env.SignalWorkflow("cmd", Command{Type: CmdBlock, Reason: "crew rest", Actor: "apikey:ops"})
+	}, 2*time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.Nil(st.Block)
+		s.Equal("o-1", st.HeldBy)
+	}, 3*time.Second)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_BlockExpires() {
+	env := s.NewTestWorkflowEnvironment()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdBlock, Reason: "weight and balance", Actor: "apikey:ops", TTL: 5 * time.Minute})
+	}, time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.Require().NotNil(st.Block)
+		s.Equal(st.Block.BlockedAt.Add(5*time.Minute), st.Block.ExpiresAt)
+	}, time.Minute)
+
+	env.RegisterDelayedCallback(func() {
+		s.Nil(s.queryState(env).Block)
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+	}, 6*time.Minute)
+
+	env.RegisterDelayedCallback(func() {
+		s.Equal("o-1", s.queryState(env).HeldBy)
+	}, 6*time.Minute+time.Second)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_AdminOverrides() {
+	env := s.NewTestWorkflowEnvironment()
+
+	var notices []string
+	env.OnSignalExternalWorkflow(mock.Anything, mock.Anything, "", WaitlistSignal, mock.Anything).
+		Return(func(namespace, workflowID, runID, signalName string, arg interface{}) error {
+			notice := arg.(WaitlistNotice)
+			if notice.Reassigned {
+				notices = append(notices, workflowID+" "+string(notice.Event)+" (reassigned)")
+			} else {
+				notices = append(notices, workflowID+" "+string(notice.Event))
+			}
+			return nil
+		})
+
+	admin := func(t CommandType, orderID string) Command {
+		return Command{Type: t, OrderID: orderID, Actor: "apikey:ops", Reason: "refunded out of band"}
+	}
+
+	// A forced release hands the seat to the waitlist and tells the holder.
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-2", TTL: time.Minute, Waitlist: true})
+		env.SignalWorkflow("cmd", admin(CmdForceRelease, ""))
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("o-2", s.queryState(env).HeldBy)
+		s.Equal([]string{"order::o-2 queued", "order::o-1 revoked", "order::o-2 granted"}, notices)
+		notices = nil
+
+		env.SignalWorkflow("cmd", Command{Type: CmdConfirm, OrderID: "o-2", HoldToken: 2})
+		env.SignalWorkflow("cmd", admin(CmdReassign, "o-3"))
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.True(st.IsConfirmed)
+		s.Equal("o-3", st.ConfirmedBy)
+
+		env.SignalWorkflow("cmd", admin(CmdForceUnconfirm, ""))
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-4", TTL: time.Minute, Customer: "apikey:a"})
+		env.SignalWorkflow("cmd", Command{Type: CmdReassign, OrderID: "o-5", Customer: "apikey:b", Actor: "apikey:ops", Reason: "agent rebooking"})
+	}, 3*time.Second)
+	env.RegisterDelayedCallback(func() {
+		st := s.queryState(env)
+		s.False(st.IsConfirmed)
+		s.Equal("o-5", st.HeldBy)
+		s.Equal("apikey:b", st.HeldFor)
+		s.Equal([]string{"order::o-2 revoked", "order::o-3 reassigned", "order::o-3 revoked", "order::o-4 revoked", "order::o-5 granted (reassigned)"}, notices)
+
+		var audit []string
+		for _, o := range st.Overrides {
+			audit = append(audit, string(o.Command)+" "+o.PreviousOrderID+">"+o.OrderID+" by "+o.Actor)
+		}
+		s.Equal([]string{
+			"FORCE_RELEASE o-1> by apikey:ops",
+			"REASSIGN o-2>o-3 by apikey:ops",
+			"FORCE_UNCONFIRM o-3> by apikey:ops",
+			"REASSIGN o-4>o-5 by apikey:ops",
+		}, audit)
+		s.Equal("agent rebooking", st.Overrides[3].Reason)
+	}, 4*time.Second)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_HistoryRecordsOutcomes() {
+	env := s.NewTestWorkflowEnvironment()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Minute})
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-2", TTL: time.Minute})
+		env.SignalWorkflow("cmd", Command{Type: CmdExtend, OrderID: "o-2", TTL: time.Minute})
+		env.SignalWorkflow("cmd", Command{Type: CmdConfirm, OrderID: "o-1", HoldToken: 1})
+	}, time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		var got []string
+		for _, e := range s.queryHistory(env) {
+			got = append(got, string(e.Command)+" "+e.OrderID+" "+string(e.Outcome)+" "+e.Reason+" | "+e.HeldBy+" "+e.ConfirmedBy)
+		}
+		s.Equal([]string{
+			// Restored from the previous run.
+			"RELEASE o-0 applied  |  ",
+			"HOLD o-1 applied  | o-1 ",
+			"HOLD o-2 ignored seat is held by o-1 | o-1 ",
+			"EXTEND o-2 ignored seat is not held by this order | o-1 ",
+			"CONFIRM o-1 applied  |  o-1",
+		}, got)
+	}, 2*time.Second)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", &SeatPersistedState{
+		History: []HistoryEntry{{Command: CmdRelease, OrderID: "o-0", Outcome: OutcomeApplied}},
+	})
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_ContinueAsNewKeepsBufferedCommands() {
+	// Orders o-1..o-50 take the seat in turn; only the last one keeps it.
+	var flood []Command
+	for i := 1; i <= 50; i++ {
+		orderID := "o-" + strconv.Itoa(i)
+		if i > 1 {
+			flood = append(flood, Command{Type: CmdRelease, OrderID: "o-" + strconv.Itoa(i-1), HoldToken: int64(i - 1)})
+		}
+		flood = append(flood, Command{Type: CmdHold, OrderID: orderID, TTL: time.Hour})
+	}
+
+	// The whole flood is buffered before the first run gets to handle it; the
+	// run rolls over after its first command.
+	env := s.NewTestWorkflowEnvironment()
+	env.SetContinueAsNewSuggested(true)
+	env.RegisterDelayedCallback(func() {
+		for _, cmd := range flood[:len(flood)-1] {
+			env.SignalWorkflowSkippingWorkflowTask("cmd", cmd)
+		}
+		env.SignalWorkflow("cmd", flood[len(flood)-1])
+	}, time.Second)
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+
+	s.True(env.IsWorkflowCompleted())
+	flightID, seatID, carried := s.continuedAsNew(env)
+	s.Equal("o-1", carried.HeldBy)
+	s.Equal(flood[1:], carried.Pending)
+
+	// The next run applies every carried command before new ones.
+	env = s.NewTestWorkflowEnvironment()
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-51", TTL: time.Hour})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		state := s.queryState(env)
+		s.True(state.IsHeld)
+		s.Equal("o-50", state.HeldBy)
+
+		entries := s.queryHistory(env)
+		s.Require().Len(entries, len(flood)+1)
+		for i, cmd := range flood {
+			s.Equal(cmd.Type, entries[i].Command)
+			s.Equal(cmd.OrderID, entries[i].OrderID)
+			s.Equal(OutcomeApplied, entries[i].Outcome, "command %d", i)
+		}
+		s.Equal(OutcomeIgnored, entries[len(flood)].Outcome)
+	}, 2*time.Second)
+	env.ExecuteWorkflow(SeatEntityWorkflow, flightID, seatID, carried)
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_CommandUpdateFencesHolds() {
+	env := s.NewTestWorkflowEnvironment()
+
+	// The same order holds the seat twice; the release of its first hold
+	// arrives late and must not end the second one.
+	cmds := []Command{
+		{Type: CmdHold, OrderID: "o-1", TTL: time.Minute},
+		{Type: CmdRelease, OrderID: "o-1", HoldToken: 1},
+		{Type: CmdHold, OrderID: "o-1", TTL: time.Minute},
+		{Type: CmdRelease, OrderID: "o-1", HoldToken: 1},
+		// Refreshing the hold keeps its token.
+		{Type: CmdHold, OrderID: "o-1", TTL: time.Minute},
+		{Type: CmdHold, OrderID: "o-2", TTL: time.Minute},
+		{Type: CmdConfirm, OrderID: "o-1", HoldToken: 2},
+	}
+	results := make([]CommandResult, len(cmds))
+	for i, cmd := range cmds {
+		env.RegisterDelayedCallback(func() {
+			env.UpdateWorkflow(CommandUpdate, strconv.Itoa(i), &testsuite.TestUpdateCallback{
+				OnAccept: func() {},
+				OnReject: func(err error) { s.Fail("update rejected", err) },
+				OnComplete: func(v interface{}, err error) {
+					s.Require().NoError(err)
+					results[i] = v.(CommandResult)
+				},
+			}, cmd)
+		}, time.Duration(i+1)*time.Second)
+	}
+
+	env.RegisterDelayedCallback(func() {
+		var got []string
+		for _, r := range results {
+			got = append(got, fmt.Sprintf("%s %s %d", r.Outcome, r.Reason, r.HoldToken))
+		}
+		s.Equal([]string{
+			"applied  1",
+			"applied  0",
+			"applied  2",
+			"ignored stale hold token 2",
+			"applied  2",
+			"ignored seat is held by o-1 0",
+			"applied  0",
+		}, got)
+		s.False(results[0].ExpiresAt.IsZero())
+
+		st := s.queryState(env)
+		s.True(st.IsConfirmed)
+		s.Equal("o-1", st.ConfirmedBy)
+	}, time.Duration(len(cmds)+1)*time.Second)
+
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *SeatWorkflowTestSuite) TestHistory_KeepsLatestEntries() {
+	h := newHistory(nil)
+	for i := 0; i < maxHistory+5; i++ {
+		h.add(HistoryEntry{OrderID: strconv.Itoa(i)})
+	}
+	entries := h.entries()
+	s.Len(entries, maxHistory)
+	s.Equal("5", entries[0].OrderID)
+	s.Equal(strconv.Itoa(maxHistory+4), entries[maxHistory-1].OrderID)
+
+	// A restored history keeps its order and keeps rotating.
+	h = newHistory(entries)
+	h.add(HistoryEntry{OrderID: "next"})
+	entries = h.entries()
+	s.Equal("6", entries[0].OrderID)
+	s.Equal("next", entries[maxHistory-1].OrderID)
+}
+
+func (s *SeatWorkflowTestSuite) TestSeatWorkflow_LegacyRunMovesToCurrentRules() {
+	// A seat started before reducerVersion keeps its rules for replay, and
+	// continues as new at the first Command update.
+	env := s.NewTestWorkflowEnvironment()
+	env.OnGetVersion(reducerVersion, workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow("cmd", Command{Type: CmdHold, OrderID: "o-1", TTL: time.Hour})
+	}, time.Second)
+	var updateErr error
+	env.RegisterDelayedCallback(func() {
+		env.UpdateWorkflow(CommandUpdate, "u-1", &testsuite.TestUpdateCallback{
+			OnAccept:   func() {},
+			OnReject:   func(err error) { updateErr = err },
+			OnComplete: func(_ interface{}, err error) { updateErr = err },
+		}, Command{Type: CmdHold, OrderID: "o-2", TTL: time.Hour})
+	}, 2*time.Second)
+	env.ExecuteWorkflow(SeatEntityWorkflow, "FL1", "1A", (*SeatPersistedState)(nil))
+
+	s.True(env.IsWorkflowCompleted())
+	s.ErrorContains(updateErr, "retry")
+	_, _, carried := s.continuedAsNew(env)
+	s.True(carried.IsHeld)
+	s.Equal("o-1", carried.HeldBy)
+	s.Require().Len(carried.History, 1)
+	s.Equal(OutcomeApplied, carried.History[0].Outcome)
+}
+
+// queryState returns the seat's current GetState answer.
+func (s *SeatWorkflowTestSuite) queryState(env *testsuite.TestWorkflowEnvironment) SeatState {
+	resp, err := env.QueryWorkflow("GetState")
+	s.Require().NoError(err)
+	var st SeatState
+	s.Require().NoError(resp.Get(&st))
+	return st
+}
+
+// queryHistory returns the seat's current GetHistory answer.
+func (s *SeatWorkflowTestSuite) queryHistory(env *testsuite.TestWorkflowEnvironment) []HistoryEntry {
+	resp, err := env.QueryWorkflow(GetHistoryQuery)
+	s.Require().NoError(err)
+	var entries []HistoryEntry
+	s.Require().NoError(resp.Get(&entries))
+	return entries
+}
+
+// continuedAsNew decodes the arguments of the run the finished workflow
+// continued as.
+func (s *SeatWorkflowTestSuite) continuedAsNew(env *testsuite.TestWorkflowEnvironment) (flightID, seatID string, carried *SeatPersistedState) {
+	var canErr *workflow.ContinueAsNewError
+	s.Require().ErrorAs(env.GetWorkflowError(), &canErr)
+	s.Require().NoError(converter.GetDefaultDataConverter().FromPayloads(canErr.Input, &flightID, &seatID, &carried))
+	return flightID, seatID, carried
+}
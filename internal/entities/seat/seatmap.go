@@ -0,0 +1,82 @@
+package seat
+
+// The seat map shared by every flight: rows 1-5 of seats A-F, with the aisle
+// between C and D.
+var (
+	seatRows    = []string{"1", "2", "3", "4", "5"}
+	seatColumns = []string{"A", "B", "C", "D", "E", "F"}
+)
+
+// aisleAfter is the index in seatColumns of the last seat before the aisle.
+const aisleAfter = 2
+
+// FindAdjacentSeats returns the best block of n seats that are all in free,
+// or nil if there is none. It prefers n seats side by side in one row without
+// crossing the aisle, then n seats of one row across the aisle, then n seats
+// over two adjacent rows sharing the same columns (the front row taking the
+// odd seat). Within each kind, front rows and then left-hand columns win.
+func FindAdjacentSeats(free []string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	isFree := make(map[string]bool, len(free))
+	for _, seatID := range free {
+		isFree[seatID] = true
+	}
+
+	for _, acrossAisle := range []bool{false, true} {
+		for row := range seatRows {
+			for col := range seatColumns {
+				if crossesAisle(col, n) != acrossAisle {
+					continue
+				}
+				if block := seatRun(isFree, row, col, n); block != nil {
+					return block
+				}
+			}
+		}
+	}
+
+	front, back := (n+1)/2, n/2
+	if back == 0 {
+		return nil
+	}
+	for _, acrossAisle := range []bool{false, true} {
+		for row := 0; row+1 < len(seatRows); row++ {
+			for col := range seatColumns {
+				if crossesAisle(col, front) != acrossAisle {
+					continue
+				}
+				first := seatRun(isFree, row, col, front)
+				second := seatRun(isFree, row+1, col, back)
+				if first != nil && second != nil {
+					return append(first, second...)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// seatRun returns the width seats of a row starting at column col, or nil if
+// the run leaves the row or any of its seats is not free.
+func seatRun(isFree map[string]bool, row, col, width int) []string {
+	if col+width > len(seatColumns) {
+		return nil
+	}
+	run := make([]string, 0, width)
+	for c := col; c < col+width; c++ {
+		seatID := seatRows[row] + seatColumns[c]
+		if !isFree[seatID] {
+			return nil
+		}
+		run = append(run, seatID)
+	}
+	return run
+}
+
+// crossesAisle reports whether width seats starting at column col sit on both
+// sides of the aisle.
+func crossesAisle(col, width int) bool {
+	return col <= aisleAfter && col+width-1 > aisleAfter
+}
@@ -0,0 +1,53 @@
+package seat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// allBut lists every seat except the given ones.
+func allBut(taken ...string) []string {
+	isTaken := map[string]bool{}
+	for _, seatID := range taken {
+		isTaken[seatID] = true
+	}
+	free := []string{}
+	for _, seatID := range AllSeatIDs() {
+		if !isTaken[seatID] {
+			free = append(free, seatID)
+		}
+	}
+	return free
+}
+
+func TestFindAdjacentSeats(t *testing.T) {
+	tests := []struct {
+		name string
+		free []string
+		n    int
+		want []string
+	}{
+		{"empty flight", AllSeatIDs(), 3, []string{"1A", "1B", "1C"}},
+		{"single seat", allBut("1A"), 1, []string{"1B"}},
+		{"same side before across the aisle", allBut("1A", "2A", "2F"), 3, []string{"1D", "1E", "1F"}},
+		{"across the aisle before the next row", allBut("1A", "1F"), 4, []string{"1B", "1C", "1D", "1E"}},
+		{"same row before adjacent rows", []string{"1A", "1B", "2A", "2B", "3A", "3B", "3C", "3D"}, 4, []string{"3A", "3B", "3C", "3D"}},
+		{"adjacent rows", []string{"1A", "1B", "2A", "2B", "4C", "4D", "4E"}, 4, []string{"1A", "1B", "2A", "2B"}},
+		{"odd group over two rows", []string{"2D", "2E", "3D", "3F", "3E"}, 5, nil},
+		{"front row takes the odd seat", []string{"2D", "2E", "2F", "3D", "3E"}, 5, []string{"2D", "2E", "2F", "3D", "3E"}},
+		{"no block", []string{"1A", "1C", "2B", "3A"}, 2, nil},
+		{"larger than a row", AllSeatIDs(), 8, []string{"1A", "1B", "1C", "1D", "2A", "2B", "2C", "2D"}},
+		{"nothing asked", AllSeatIDs(), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindAdjacentSeats(tt.free, tt.n)
+			if tt.want == nil {
+				require.Nil(t, got)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
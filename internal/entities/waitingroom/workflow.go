@@ -0,0 +1,397 @@
+// Package waitingroom implements the virtual waiting room of high-demand
+// flights: an entity workflow per flight that hands out queue tickets, admits
+// them in FIFO order at a fixed rate, and lets each admitted ticket create
+// exactly one order.
+package waitingroom
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Signal, update and query names of WaitingRoomWorkflow.
+const (
+	JoinSignal         = "join"
+	RedeemUpdate       = "Redeem"
+	ReturnTicketUpdate = "ReturnTicket"
+	GetTicketQuery     = "GetTicket"
+)
+
+// Application error types returned by RedeemUpdate and GetTicketQuery.
+const (
+	ErrTypeTicketNotFound    = "TicketNotFound"
+	ErrTypeTicketNotAdmitted = "TicketNotAdmitted"
+	ErrTypeTicketForbidden   = "TicketForbidden"
+)
+
+// TicketState is the lifecycle state of a queue ticket.
+type TicketState string
+
+const (
+	TicketWaiting  TicketState = "waiting"
+	TicketAdmitted TicketState = "admitted"
+	TicketRedeemed TicketState = "redeemed"
+	TicketExpired  TicketState = "expired"
+)
+
+// admitTick is the shortest interval between two admission rounds. Slower
+// rates admit one ticket per round.
+const admitTick = time.Second
+
+// idleRoundsVersion is the change ID of rooms that, with nobody waiting, run
+// their next admission round when an admission expires or a finished ticket
+// is due to be forgotten, rather than every tickInterval. Rooms started before
+// it replay ticking every tickInterval while they have tickets.
+const idleRoundsVersion = "waitingroom-idle-rounds"
+
+// maxHistoryLength bounds the history of a run when the server does not
+// suggest Continue-As-New earlier.
+const maxHistoryLength = 10000
+
+// WorkflowID returns the ID of the waiting room workflow of a flight.
+func WorkflowID(flightID string) string {
+	return "waitingroom::" + flightID
+}
+
+// Input configures WaitingRoomWorkflow. State is set when continuing as new.
+type Input struct {
+	FlightID string
+	// AdmitPerMinute is how many tickets are admitted per minute.
+	AdmitPerMinute int
+	// AdmissionTTL is how long an admitted ticket may be redeemed for an order.
+	AdmissionTTL time.Duration
+	State        *PersistedState
+}
+
+// JoinRequest is the payload of JoinSignal. TicketID is generated by the
+// caller so that it knows which ticket to ask for; a customer that already has
+// an active ticket gets that ticket instead.
+type JoinRequest struct {
+	TicketID string
+	Customer string
+}
+
+// RedeemRequest is the argument of RedeemUpdate and ReturnTicketUpdate.
+type RedeemRequest struct {
+	TicketID string
+	OrderID  string
+	Customer string
+}
+
+// Ticket is a queue ticket as kept in the workflow state.
+type Ticket struct {
+	ID         string      `json:"id"`
+	Customer   string      `json:"customer,omitempty"`
+	State      TicketState `json:"state"`
+	JoinedAt   time.Time   `json:"joinedAt"`
+	AdmittedAt time.Time   `json:"admittedAt,omitempty"`
+	ExpiresAt  time.Time   `json:"expiresAt,omitempty"`
+	OrderID    string      `json:"orderId,omitempty"`
+}
+
+// TicketStatus is the result of GetTicketQuery and the updates.
+type TicketStatus struct {
+	Ticket
+	FlightID string `json:"flightId"`
+	// Position is the 1-based place in the queue while the ticket is waiting.
+	Position int `json:"position,omitempty"`
+	// EstimatedWait is how long until a waiting ticket is admitted at the current rate.
+	EstimatedWait time.Duration `json:"estimatedWait,omitempty"`
+}
+
+// PersistedState carries the room across Continue-As-New.
+type PersistedState struct {
+	Queue   []string           `json:"queue"`
+	Tickets map[string]*Ticket `json:"tickets"`
+	Aliases map[string]string  `json:"aliases,omitempty"`
+	Credit  float64            `json:"credit,omitempty"`
+}
+
+type room struct {
+	input Input
+	// queue holds the IDs of waiting tickets in FIFO order.
+	queue   []string
+	tickets map[string]*Ticket
+	// aliases maps ticket IDs of repeated joins to the customer's active ticket.
+	aliases map[string]string
+	// credit accumulates fractional admissions between rounds.
+	credit float64
+}
+
+func newRoom(input Input) *room {
+	r := &room{input: input, tickets: make(map[string]*Ticket), aliases: make(map[string]string)}
+	if s := input.State; s != nil {
+		r.queue = s.Queue
+		if s.Tickets != nil {
+			r.tickets = s.Tickets
+		}
+		if s.Aliases != nil {
+			r.aliases = s.Aliases
+		}
+		r.credit = s.Credit
+	}
+	return r
+}
+
+// WaitingRoomWorkflow admits the customers of a flight at input.AdmitPerMinute.
+// Its workflow ID should be WorkflowID(flightID); it is started on the first
+// join with SignalWithStart and runs until the flight is no longer sold.
+func WaitingRoomWorkflow(ctx workflow.Context, input Input) error {
+	logger := workflow.GetLogger(ctx)
+	logger.Info("Starting WaitingRoomWorkflow", "FlightID", input.FlightID, "AdmitPerMinute", input.AdmitPerMinute)
+
+	r := newRoom(input)
+
+	if err := workflow.SetQueryHandler(ctx, GetTicketQuery, func(ticketID string) (TicketStatus, error) {
+		return r.status(ticketID)
+	}); err != nil {
+		return err
+	}
+	if err := workflow.SetUpdateHandlerWithOptions(ctx, RedeemUpdate,
+		func(ctx workflow.Context, req RedeemRequest) (TicketStatus, error) {
+			return r.redeem(req, workflow.Now(ctx))
+		},
+		workflow.UpdateHandlerOptions{Validator: func(ctx workflow.Context, req RedeemRequest) error {
+			_, err := r.redeemable(req, workflow.Now(ctx))
+			return err
+		}},
+	); err != nil {
+		return err
+	}
+	if err := workflow.SetUpdateHandler(ctx, ReturnTicketUpdate,
+		func(ctx workflow.Context, req RedeemRequest) (TicketStatus, error) {
+			return r.returnTicket(req)
+		},
+	); err != nil {
+		return err
+	}
+
+	joinChan := workflow.GetSignalChannel(ctx, JoinSignal)
+	idleRounds := workflow.GetVersion(ctx, idleRoundsVersion, workflow.DefaultVersion, 1) != workflow.DefaultVersion
+	var tick workflow.Future
+	var tickAt time.Time
+	cancelTick := func() {}
+
+	for {
+		sel := workflow.NewSelector(ctx)
+		sel.AddReceive(joinChan, func(c workflow.ReceiveChannel, more bool) {
+			var req JoinRequest
+			c.Receive(ctx, &req)
+			r.join(req, workflow.Now(ctx))
+		})
+
+		// Admission rounds only run while there is something to admit or expire.
+		if idleRounds {
+			// A join may need a round before the pending one.
+			now := workflow.Now(ctx)
+			if due, ok := r.nextRound(now); ok && (tick == nil || due.Before(tickAt)) {
+				cancelTick()
+				var tickCtx workflow.Context
+				tickCtx, cancelTick = workflow.WithCancel(ctx)
+				d := max(due.Sub(now), admitTick)
+				tick, tickAt = workflow.NewTimer(tickCtx, d), now.Add(d)
+			}
+		} else if tick == nil && r.busy() {
+			tick = workflow.NewTimer(ctx, r.tickInterval())
+		}
+		if tick != nil {
+			sel.AddFuture(tick, func(f workflow.Future) {
+				tick = nil
+				admitted := r.admit(workflow.Now(ctx))
+				if admitted > 0 {
+					logger.Info("Admitted tickets", "Count", admitted, "Waiting", len(r.queue))
+				}
+			})
+		}
+
+		sel.Select(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info := workflow.GetInfo(ctx)
+		if info.GetContinueAsNewSuggested() || info.GetCurrentHistoryLength() > maxHistoryLength {
+			// Joins already delivered to this run must not be lost.
+			for {
+				var req JoinRequest
+				if !joinChan.ReceiveAsync(&req) {
+					break
+				}
+				r.join(req, workflow.Now(ctx))
+			}
+			if err := workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) }); err != nil {
+				return err
+			}
+			logger.Info("Continuing as new to trim history", "Waiting", len(r.queue), "Tickets", len(r.tickets))
+			next := input
+			next.State = &PersistedState{Queue: r.queue, Tickets: r.tickets, Aliases: r.aliases, Credit: r.credit}
+			return workflow.NewContinueAsNewError(ctx, WaitingRoomWorkflow, next)
+		}
+	}
+}
+
+// join queues a new ticket, unless the ID is known or the customer already
+// has a waiting or admitted ticket, which the new ID then refers to.
+func (r *room) join(req JoinRequest, now time.Time) {
+	if req.TicketID == "" || r.tickets[req.TicketID] != nil || r.aliases[req.TicketID] != "" {
+		return
+	}
+	if req.Customer != "" {
+		for _, t := range r.tickets {
+			if t.Customer == req.Customer && (t.State == TicketWaiting || t.State == TicketAdmitted) {
+				r.aliases[req.TicketID] = t.ID
+				return
+			}
+		}
+	}
+	r.tickets[req.TicketID] = &Ticket{ID: req.TicketID, Customer: req.Customer, State: TicketWaiting, JoinedAt: now}
+	r.queue = append(r.queue, req.TicketID)
+}
+
+func (r *room) busy() bool {
+	return len(r.queue) > 0 || len(r.tickets) > 0
+}
+
+// nextRound returns when the next admission round is due at now: after
+// tickInterval while tickets are waiting, otherwise when the first admission
+// expires or finished ticket is to be forgotten. It reports false when the
+// room has no tickets.
+func (r *room) nextRound(now time.Time) (time.Time, bool) {
+	if len(r.queue) > 0 {
+		return now.Add(r.tickInterval()), true
+	}
+	var next time.Time
+	for _, t := range r.tickets {
+		due := t.ExpiresAt
+		switch t.State {
+		case TicketAdmitted:
+		case TicketRedeemed, TicketExpired:
+			due = due.Add(r.input.AdmissionTTL)
+		default:
+			continue
+		}
+		if next.IsZero() || due.Before(next) {
+			next = due
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// tickInterval is the time between admission rounds: one ticket per round for
+// slow rates, batches of tickets every admitTick for fast ones.
+func (r *room) tickInterval() time.Duration {
+	if r.input.AdmitPerMinute <= 0 {
+		return time.Minute
+	}
+	return max(time.Minute/time.Duration(r.input.AdmitPerMinute), admitTick)
+}
+
+// admit runs an admission round: it admits the tickets at the head of the
+// queue that the rate allows, expires admitted tickets that were not redeemed
+// in time, and forgets finished tickets once their admission window is over.
+// It returns the number of admitted tickets.
+func (r *room) admit(now time.Time) int {
+	for id, t := range r.tickets {
+		switch {
+		case t.State == TicketAdmitted && !now.Before(t.ExpiresAt):
+			t.State = TicketExpired
+		case (t.State == TicketRedeemed || t.State == TicketExpired) && now.After(t.ExpiresAt.Add(r.input.AdmissionTTL)):
+			delete(r.tickets, id)
+		}
+	}
+	for alias, id := range r.aliases {
+		if r.tickets[id] == nil {
+			delete(r.aliases, alias)
+		}
+	}
+
+	if len(r.queue) == 0 {
+		r.credit = 0
+		return 0
+	}
+	r.credit += float64(r.input.AdmitPerMinute) * r.tickInterval().Minutes()
+	n := min(int(r.credit), len(r.queue))
+	r.credit -= float64(n)
+	for _, id := range r.queue[:n] {
+		t := r.tickets[id]
+		t.State = TicketAdmitted
+		t.AdmittedAt = now
+		t.ExpiresAt = now.Add(r.input.AdmissionTTL)
+	}
+	r.queue = append([]string(nil), r.queue[n:]...)
+	return n
+}
+
+func (r *room) lookup(ticketID string) *Ticket {
+	if id, ok := r.aliases[ticketID]; ok {
+		ticketID = id
+	}
+	return r.tickets[ticketID]
+}
+
+func (r *room) status(ticketID string) (TicketStatus, error) {
+	t := r.lookup(ticketID)
+	if t == nil {
+		return TicketStatus{}, temporal.NewApplicationError("ticket not found", ErrTypeTicketNotFound)
+	}
+	status := TicketStatus{Ticket: *t, FlightID: r.input.FlightID}
+	if t.State == TicketWaiting {
+		for i, id := range r.queue {
+			if id == t.ID {
+				status.Position = i + 1
+				break
+			}
+		}
+		if r.input.AdmitPerMinute > 0 {
+			status.EstimatedWait = time.Duration(status.Position) * time.Minute / time.Duration(r.input.AdmitPerMinute)
+		}
+	}
+	return status, nil
+}
+
+// redeemable checks that req may create an order with its ticket. Redeeming
+// again for the same order succeeds, so that a retried request is idempotent.
+func (r *room) redeemable(req RedeemRequest, now time.Time) (*Ticket, error) {
+	t := r.lookup(req.TicketID)
+	switch {
+	case t == nil:
+		return nil, temporal.NewApplicationError("ticket not found", ErrTypeTicketNotFound)
+	case t.Customer != "" && t.Customer != req.Customer:
+		return nil, temporal.NewApplicationError("ticket belongs to another caller", ErrTypeTicketForbidden)
+	case t.State == TicketRedeemed && t.OrderID == req.OrderID:
+		return t, nil
+	case t.State == TicketRedeemed:
+		return nil, temporal.NewApplicationError("ticket was already used for another order", ErrTypeTicketNotAdmitted)
+	case t.State == TicketWaiting:
+		return nil, temporal.NewApplicationError("ticket has not been admitted yet", ErrTypeTicketNotAdmitted)
+	case t.State == TicketExpired || !now.Before(t.ExpiresAt):
+		return nil, temporal.NewApplicationError("ticket admission has expired", ErrTypeTicketNotAdmitted)
+	}
+	return t, nil
+}
+
+func (r *room) redeem(req RedeemRequest, now time.Time) (TicketStatus, error) {
+	t, err := r.redeemable(req, now)
+	if err != nil {
+		return TicketStatus{}, err
+	}
+	t.State = TicketRedeemed
+	t.OrderID = req.OrderID
+	return r.status(t.ID)
+}
+
+// returnTicket undoes a redemption whose order could not be started. The
+// ticket stays admitted until its original expiry.
+func (r *room) returnTicket(req RedeemRequest) (TicketStatus, error) {
+	t := r.lookup(req.TicketID)
+	if t == nil {
+		return TicketStatus{}, temporal.NewApplicationError("ticket not found", ErrTypeTicketNotFound)
+	}
+	if t.State == TicketRedeemed && t.OrderID == req.OrderID {
+		t.State = TicketAdmitted
+		t.OrderID = ""
+	}
+	return r.status(t.ID)
+}
@@ -0,0 +1,208 @@
+package waitingroom
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+type WaitingRoomWorkflowTestSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+}
+
+func TestWaitingRoomWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(WaitingRoomWorkflowTestSuite))
+}
+
+func (s *WaitingRoomWorkflowTestSuite) ticket(env *testsuite.TestWorkflowEnvironment, ticketID string) TicketStatus {
+	resp, err := env.QueryWorkflow(GetTicketQuery, ticketID)
+	s.Require().NoError(err)
+	var status TicketStatus
+	s.Require().NoError(resp.Get(&status))
+	return status
+}
+
+// redeem sends RedeemUpdate; check is called with its outcome once the
+// workflow has processed it.
+func (s *WaitingRoomWorkflowTestSuite) redeem(env *testsuite.TestWorkflowEnvironment, req RedeemRequest, check func(TicketStatus, error)) {
+	env.UpdateWorkflow(RedeemUpdate, "", &testsuite.TestUpdateCallback{
+		OnReject: func(err error) { check(TicketStatus{}, err) },
+		OnAccept: func() {},
+		OnComplete: func(v interface{}, err error) {
+			status, _ := v.(TicketStatus)
+			check(status, err)
+		},
+	}, req)
+}
+
+func (s *WaitingRoomWorkflowTestSuite) rejectedWith(errType string) func(TicketStatus, error) {
+	return func(_ TicketStatus, err error) {
+		var appErr *temporal.ApplicationError
+		if s.True(errors.As(err, &appErr), "expected an application error, got %v", err) {
+			s.Equal(errType, appErr.Type())
+		}
+	}
+}
+
+func (s *WaitingRoomWorkflowTestSuite) TestAdmitsInOrderAtRate() {
+	env := s.NewTestWorkflowEnvironment()
+	at := func(d time.Duration, f func()) { env.RegisterDelayedCallback(f, d) }
+
+	at(time.Millisecond, func() {
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t1", Customer: "apikey:a"})
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t2", Customer: "apikey:b"})
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t3", Customer: "apikey:c"})
+		// A second join of the same customer refers to its existing ticket.
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t4", Customer: "apikey:b"})
+	})
+	at(2*time.Millisecond, func() {
+		s.Equal(TicketWaiting, s.ticket(env, "t1").State)
+		s.Equal(1, s.ticket(env, "t1").Position)
+		s.Equal(3, s.ticket(env, "t3").Position)
+		s.Equal(time.Minute, s.ticket(env, "t3").EstimatedWait)
+		s.Equal("t2", s.ticket(env, "t4").ID)
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-1", Customer: "apikey:a"}, s.rejectedWith(ErrTypeTicketNotAdmitted))
+	})
+
+	// Three per minute admits one ticket every 20 seconds.
+	at(30*time.Second, func() {
+		s.Equal(TicketAdmitted, s.ticket(env, "t1").State)
+		s.Equal(TicketWaiting, s.ticket(env, "t2").State)
+		s.Equal(1, s.ticket(env, "t2").Position)
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-1", Customer: "apikey:b"}, s.rejectedWith(ErrTypeTicketForbidden))
+	})
+	at(31*time.Second, func() {
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-1", Customer: "apikey:a"}, func(status TicketStatus, err error) {
+			s.NoError(err)
+			s.Equal(TicketRedeemed, status.State)
+			s.Equal("o-1", status.OrderID)
+		})
+	})
+	at(32*time.Second, func() {
+		// A ticket creates a single order.
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-2", Customer: "apikey:a"}, s.rejectedWith(ErrTypeTicketNotAdmitted))
+	})
+
+	// t2 is admitted at 40s and expires unredeemed after the admission TTL.
+	at(4*time.Minute+50*time.Second, func() {
+		s.Equal(TicketExpired, s.ticket(env, "t2").State)
+		s.Equal(TicketAdmitted, s.ticket(env, "t3").State)
+		env.CancelWorkflow()
+	})
+
+	env.ExecuteWorkflow(WaitingRoomWorkflow, Input{FlightID: "F-100", AdmitPerMinute: 3, AdmissionTTL: 4 * time.Minute})
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *WaitingRoomWorkflowTestSuite) TestReturnTicket() {
+	env := s.NewTestWorkflowEnvironment()
+	at := func(d time.Duration, f func()) { env.RegisterDelayedCallback(f, d) }
+	succeeds := func(_ TicketStatus, err error) { s.NoError(err) }
+
+	at(time.Millisecond, func() {
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t1", Customer: "apikey:a"})
+	})
+	at(5*time.Second, func() {
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-1", Customer: "apikey:a"}, succeeds)
+	})
+	at(6*time.Second, func() {
+		// Starting o-1 failed, so the ticket is handed back.
+		env.UpdateWorkflow(ReturnTicketUpdate, "", &testsuite.TestUpdateCallback{
+			OnReject:   func(err error) { s.Fail("return rejected", err) },
+			OnAccept:   func() {},
+			OnComplete: func(interface{}, error) {},
+		}, RedeemRequest{TicketID: "t1", OrderID: "o-1"})
+	})
+	at(7*time.Second, func() {
+		s.Equal(TicketAdmitted, s.ticket(env, "t1").State)
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-2", Customer: "apikey:a"}, succeeds)
+	})
+	at(8*time.Second, func() {
+		s.Equal("o-2", s.ticket(env, "t1").OrderID)
+		env.CancelWorkflow()
+	})
+
+	env.ExecuteWorkflow(WaitingRoomWorkflow, Input{FlightID: "F-100", AdmitPerMinute: 60, AdmissionTTL: time.Minute})
+	s.True(env.IsWorkflowCompleted())
+}
+
+// idleRoom runs a room whose only ticket is redeemed at 2s and forgotten
+// once its admission window is over, and returns how many timers it scheduled
+// in ten minutes.
+func (s *WaitingRoomWorkflowTestSuite) idleRoom(env *testsuite.TestWorkflowEnvironment) int {
+	at := func(d time.Duration, f func()) { env.RegisterDelayedCallback(f, d) }
+	timers := 0
+	env.SetOnTimerScheduledListener(func(string, time.Duration) { timers++ })
+
+	at(time.Millisecond, func() {
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t1", Customer: "apikey:a"})
+	})
+	at(2*time.Second, func() {
+		s.redeem(env, RedeemRequest{TicketID: "t1", OrderID: "o-1", Customer: "apikey:a"}, func(_ TicketStatus, err error) { s.NoError(err) })
+	})
+	at(5*time.Minute, func() {
+		_, err := env.QueryWorkflow(GetTicketQuery, "t1")
+		s.Error(err)
+	})
+	at(10*time.Minute, env.CancelWorkflow)
+
+	env.ExecuteWorkflow(WaitingRoomWorkflow, Input{FlightID: "F-100", AdmitPerMinute: 60, AdmissionTTL: time.Minute})
+	s.True(env.IsWorkflowCompleted())
+	return timers
+}
+
+func (s *WaitingRoomWorkflowTestSuite) TestIdleRoomDoesNotTick() {
+	env := s.NewTestWorkflowEnvironment()
+	// The admission round, then the end of the admission and its cleanup.
+	s.LessOrEqual(s.idleRoom(env), 4)
+}
+
+func (s *WaitingRoomWorkflowTestSuite) TestIdleRoomTicksBeforeVersion() {
+	env := s.NewTestWorkflowEnvironment()
+	// A room started before idle rounds replays ticking every second while it
+	// keeps the ticket.
+	env.OnGetVersion(idleRoundsVersion, workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	s.Greater(s.idleRoom(env), 100)
+}
+
+func (s *WaitingRoomWorkflowTestSuite) TestIdleRoomAdmitsNewJoinsAtRate() {
+	env := s.NewTestWorkflowEnvironment()
+	at := func(d time.Duration, f func()) { env.RegisterDelayedCallback(f, d) }
+
+	// t2 joins while the room waits for t1's admission to end, and is
+	// admitted on the next round rather than then.
+	at(time.Millisecond, func() {
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t1", Customer: "apikey:a"})
+	})
+	at(10*time.Second, func() {
+		env.SignalWorkflow(JoinSignal, JoinRequest{TicketID: "t2", Customer: "apikey:b"})
+	})
+	at(12*time.Second, func() {
+		s.Equal(TicketAdmitted, s.ticket(env, "t2").State)
+		env.CancelWorkflow()
+	})
+
+	env.ExecuteWorkflow(WaitingRoomWorkflow, Input{FlightID: "F-100", AdmitPerMinute: 60, AdmissionTTL: 10 * time.Minute})
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *WaitingRoomWorkflowTestSuite) TestContinueAsNewKeepsQueue() {
+	r := newRoom(Input{FlightID: "F-100", AdmitPerMinute: 60, AdmissionTTL: time.Minute, State: &PersistedState{
+		Queue:   []string{"t2"},
+		Tickets: map[string]*Ticket{"t2": {ID: "t2", State: TicketWaiting}},
+	}})
+
+	status, err := r.status("t2")
+	s.NoError(err)
+	s.Equal(1, status.Position)
+
+	s.Equal(1, r.admit(time.Now()))
+	status, _ = r.status("t2")
+	s.Equal(TicketAdmitted, status.State)
+}
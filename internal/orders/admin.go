@@ -0,0 +1,327 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+	"go.temporal.io/api/serviceerror"
+)
+
+// ErrAdminRequired is returned when a caller that is not an administrator uses
+// the admin API.
+var ErrAdminRequired = errors.New("admin access required")
+
+// SeatError is returned when an admin operation does not apply to a seat.
+type SeatError struct {
+	// Code is domain.CodeSeatNotFound, CodeConflict or CodeInvalidRequest.
+	Code   string
+	Detail string
+}
+
+func (e *SeatError) Error() string { return e.Detail }
+
+// authorizeAdmin checks that the authenticated principal in ctx is one of the
+// configured admins. Callers without a principal, which includes every caller
+// while authentication is disabled, are never admins.
+func (s *Service) authorizeAdmin(ctx context.Context) error {
+	if p, ok := auth.FromContext(ctx); ok && slices.Contains(s.admins, p.ID) {
+		return nil
+	}
+	return ErrAdminRequired
+}
+
+// BlockSeat takes a seat out of sale for an operational reason, until
+// req.ExpiresAt or until UnblockSeat. Seats held or confirmed by an order
+// cannot be blocked. Blocking an already blocked seat replaces its block.
+func (s *Service) BlockSeat(ctx context.Context, flightID, seatID string, req domain.BlockSeatRequest) error {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if err := checkSeatID(seatID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return &SeatError{Code: domain.CodeInvalidRequest, Detail: "A reason is required to block a seat"}
+	}
+	var ttl time.Duration
+	if req.ExpiresAt != nil {
+		if ttl = time.Until(*req.ExpiresAt); ttl <= 0 {
+			return &SeatError{Code: domain.CodeInvalidRequest, Detail: "expiresAt must be in the future"}
+		}
+	}
+
+	state, err := s.querySeat(ctx, flightID, seatID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case state.IsConfirmed:
+		return &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is confirmed by an order", seatID)}
+	case state.IsHeld:
+		return &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is held by an order", seatID)}
+	}
+
+	return s.signalSeat(ctx, flightID, seatID, seat.Command{
+		Type:   seat.CmdBlock,
+		TTL:    ttl,
+		Reason: req.Reason,
+		Actor:  auth.Caller(ctx),
+	})
+}
+
+// UnblockSeat puts a blocked seat back on sale. Unblocking a seat that is not
+// blocked does nothing.
+func (s *Service) UnblockSeat(ctx context.Context, flightID, seatID string) error {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if err := checkSeatID(seatID); err != nil {
+		return err
+	}
+	return s.signalSeat(ctx, flightID, seatID, seat.Command{Type: seat.CmdUnblock, Actor: auth.Caller(ctx)})
+}
+
+// BlockedSeats lists the blocked seats of a flight.
+func (s *Service) BlockedSeats(ctx context.Context, flightID string) ([]domain.SeatBlock, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return nil, err
+	}
+	blocks := []domain.SeatBlock{}
+	for _, seatID := range seat.AllSeatIDs() {
+		state, err := s.querySeat(ctx, flightID, seatID)
+		if err != nil {
+			return nil, err
+		}
+		if state.Block == nil {
+			continue
+		}
+		blocks = append(blocks, toSeatBlock(seatID, state.Block))
+	}
+	return blocks, nil
+}
+
+// Seat returns the full state of a seat, including its waitlist, block and
+// the audit trail of admin overrides.
+func (s *Service) Seat(ctx context.Context, flightID, seatID string) (domain.SeatDetails, error) {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return domain.SeatDetails{}, err
+	}
+	if err := checkSeatID(seatID); err != nil {
+		return domain.SeatDetails{}, err
+	}
+	state, err := s.querySeat(ctx, flightID, seatID)
+	if err != nil {
+		return domain.SeatDetails{}, err
+	}
+
+	details := domain.SeatDetails{
+		FlightID:    flightID,
+		SeatID:      seatID,
+		Status:      seatStatus(state),
+		HeldBy:      state.HeldBy,
+		HeldFor:     state.HeldFor,
+		ConfirmedBy: state.ConfirmedBy,
+		Waitlist:    []string{},
+		Overrides:   []domain.SeatOverride{},
+	}
+	if expiresAt, err := time.Parse(time.RFC3339, state.ExpiresAt); err == nil && state.IsHeld {
+		details.HoldExpiresAt = &expiresAt
+	}
+	details.Waitlist = append(details.Waitlist, state.Waitlist...)
+	if state.Block != nil {
+		block := toSeatBlock(seatID, state.Block)
+		details.Block = &block
+	}
+	for _, o := range state.Overrides {
+		details.Overrides = append(details.Overrides, domain.SeatOverride{
+			Command:         string(o.Command),
+			Reason:          o.Reason,
+			Actor:           o.Actor,
+			At:              o.At,
+			PreviousOrderID: o.PreviousOrderID,
+			OrderID:         o.OrderID,
+		})
+	}
+	return details, nil
+}
+
+// SeatHistory returns the latest commands processed by a seat with their
+// outcome, oldest first. Like availability, it is open to every caller.
+func (s *Service) SeatHistory(ctx context.Context, flightID, seatID string) (domain.SeatHistory, error) {
+	if err := checkSeatID(seatID); err != nil {
+		return domain.SeatHistory{}, err
+	}
+	history := domain.SeatHistory{FlightID: flightID, SeatID: seatID, Entries: []domain.SeatHistoryEntry{}}
+	resp, err := s.temporal.QueryWorkflow(ctx, seat.WorkflowID(flightID, seatID), "", seat.GetHistoryQuery)
+	var notFoundErr *serviceerror.NotFound
+	if errors.As(err, &notFoundErr) {
+		return history, nil
+	}
+	if err != nil {
+		return history, err
+	}
+	var entries []seat.HistoryEntry
+	if err := resp.Get(&entries); err != nil {
+		return history, fmt.Errorf("decode seat history: %w", err)
+	}
+	for _, e := range entries {
+		history.Entries = append(history.Entries, domain.SeatHistoryEntry{
+			At:          e.At,
+			Command:     string(e.Command),
+			OrderID:     e.OrderID,
+			Actor:       e.Actor,
+			Outcome:     string(e.Outcome),
+			Reason:      e.Reason,
+			HeldBy:      e.HeldBy,
+			ConfirmedBy: e.ConfirmedBy,
+		})
+	}
+	return history, nil
+}
+
+// ForceReleaseSeat releases the hold of a seat whatever order holds it. The
+// seat goes to its waitlist, and the holding order drops it from its selection.
+func (s *Service) ForceReleaseSeat(ctx context.Context, flightID, seatID string, req domain.SeatOverrideRequest) error {
+	return s.overrideSeat(ctx, flightID, seatID, req.Reason, func(state seat.SeatState) (seat.Command, error) {
+		if !state.IsHeld {
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is not held", seatID)}
+		}
+		return seat.Command{Type: seat.CmdForceRelease}, nil
+	})
+}
+
+// ForceUnconfirmSeat puts a confirmed seat back on sale, e.g. after its order
+// was refunded out of band.
+func (s *Service) ForceUnconfirmSeat(ctx context.Context, flightID, seatID string, req domain.SeatOverrideRequest) error {
+	return s.overrideSeat(ctx, flightID, seatID, req.Reason, func(state seat.SeatState) (seat.Command, error) {
+		if !state.IsConfirmed {
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is not confirmed", seatID)}
+		}
+		return seat.Command{Type: seat.CmdForceUnconfirm}, nil
+	})
+}
+
+// ReassignSeat moves the hold or confirmation of a seat to req.OrderID, an
+// order of the same flight: a hold moves to an order that is still open, a
+// confirmation to a confirmed order.
+func (s *Service) ReassignSeat(ctx context.Context, flightID, seatID string, req domain.SeatOverrideRequest) error {
+	return s.overrideSeat(ctx, flightID, seatID, req.Reason, func(state seat.SeatState) (seat.Command, error) {
+		switch {
+		case !ValidOrderID(req.OrderID):
+			return seat.Command{}, &SeatError{Code: domain.CodeInvalidRequest, Detail: "orderId of the order to reassign the seat to is required"}
+		case !state.IsHeld && !state.IsConfirmed:
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is neither held nor confirmed; the order can hold it itself", seatID)}
+		case state.HeldBy == req.OrderID || state.ConfirmedBy == req.OrderID:
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s already belongs to order %s", seatID, req.OrderID)}
+		}
+		order, err := s.queryStatus(ctx, WorkflowID(req.OrderID))
+		if err != nil {
+			return seat.Command{}, err
+		}
+		switch {
+		case order.FlightID != "" && order.FlightID != flightID:
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Order %s is for flight %s", req.OrderID, order.FlightID)}
+		case state.IsConfirmed && order.State != "CONFIRMED":
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is confirmed; order %s is %s, not CONFIRMED", seatID, req.OrderID, order.State)}
+		case !state.IsConfirmed && (order.State == "CONFIRMED" || order.State == "FAILED" || order.State == "EXPIRED"):
+			return seat.Command{}, &SeatError{Code: domain.CodeConflict, Detail: fmt.Sprintf("Seat %s is held; order %s is already %s", seatID, req.OrderID, order.State)}
+		}
+		return seat.Command{Type: seat.CmdReassign, OrderID: req.OrderID, Customer: order.Customer}, nil
+	})
+}
+
+// overrideSeat sends the audited admin override that build returns for the
+// seat's current state, unless build rejects the state. The seat entity
+// applies the override regardless of which order holds the seat.
+func (s *Service) overrideSeat(ctx context.Context, flightID, seatID, reason string, build func(seat.SeatState) (seat.Command, error)) error {
+	if err := s.authorizeAdmin(ctx); err != nil {
+		return err
+	}
+	if err := checkSeatID(seatID); err != nil {
+		return err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return &SeatError{Code: domain.CodeInvalidRequest, Detail: "A reason is required to override a seat"}
+	}
+	state, err := s.querySeat(ctx, flightID, seatID)
+	if err != nil {
+		return err
+	}
+	cmd, err := build(state)
+	if err != nil {
+		return err
+	}
+	cmd.Reason, cmd.Actor = reason, auth.Caller(ctx)
+	return s.signalSeat(ctx, flightID, seatID, cmd)
+}
+
+// seatStatus names the availability category of a seat.
+func seatStatus(state seat.SeatState) string {
+	switch {
+	case state.IsConfirmed:
+		return "confirmed"
+	case state.Block != nil:
+		return "blocked"
+	case state.IsHeld:
+		return "held"
+	default:
+		return "available"
+	}
+}
+
+func toSeatBlock(seatID string, b *seat.Block) domain.SeatBlock {
+	block := domain.SeatBlock{
+		SeatID:    seatID,
+		Reason:    b.Reason,
+		BlockedBy: b.Actor,
+		BlockedAt: b.BlockedAt,
+	}
+	if !b.ExpiresAt.IsZero() {
+		expiresAt := b.ExpiresAt
+		block.ExpiresAt = &expiresAt
+	}
+	return block
+}
+
+func checkSeatID(seatID string) error {
+	if !slices.Contains(seat.AllSeatIDs(), seatID) {
+		return &SeatError{Code: domain.CodeSeatNotFound, Detail: fmt.Sprintf("Seat %s does not exist", seatID)}
+	}
+	return nil
+}
+
+// querySeat queries the state of a seat entity. A seat whose entity has not
+// been started yet has never been used and is free.
+func (s *Service) querySeat(ctx context.Context, flightID, seatID string) (seat.SeatState, error) {
+	var state seat.SeatState
+	resp, err := s.temporal.QueryWorkflow(ctx, seat.WorkflowID(flightID, seatID), "", "GetState")
+	var notFoundErr *serviceerror.NotFound
+	if errors.As(err, &notFoundErr) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := resp.Get(&state); err != nil {
+		return state, fmt.Errorf("decode seat state: %w", err)
+	}
+	return state, nil
+}
+
+// signalSeat sends a command to a seat entity, starting it if needed, and
+// tells the watchers of the flight.
+func (s *Service) signalSeat(ctx context.Context, flightID, seatID string, cmd seat.Command) error {
+	opts := seat.StartOptions(flightID, seatID)
+	if _, err := s.temporal.SignalWithStartWorkflow(ctx, opts.ID, "cmd", cmd, opts, seat.SeatEntityWorkflow, flightID, seatID, (*seat.SeatPersistedState)(nil)); err != nil {
+		return err
+	}
+	s.hub.Notify(flightTopic(flightID))
+	return nil
+}
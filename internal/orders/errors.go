@@ -0,0 +1,62 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"go.temporal.io/api/serviceerror"
+)
+
+// ErrorCode classifies an error returned by the Service into one of the stable
+// domain error codes. Transports map the code to their own status (HTTP status,
+// gRPC code); errors without a more specific mapping are domain.CodeInternal.
+func ErrorCode(err error) string {
+	var (
+		notFoundErr       *serviceerror.NotFound
+		alreadyStartedErr *serviceerror.WorkflowExecutionAlreadyStarted
+		alreadyExistsErr  *serviceerror.AlreadyExists
+		preconditionErr   *serviceerror.FailedPrecondition
+		unavailableErr    *serviceerror.Unavailable
+		exhaustedErr      *serviceerror.ResourceExhausted
+		deadlineErr       *serviceerror.DeadlineExceeded
+		limitErr          *LimitError
+		ticketErr         *TicketError
+		seatErr           *SeatError
+	)
+
+	switch {
+	case errors.As(err, &limitErr):
+		return limitErr.Code
+	case errors.As(err, &ticketErr):
+		return ticketErr.Code
+	case errors.As(err, &seatErr):
+		return seatErr.Code
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrAdminRequired):
+		return domain.CodeForbidden
+	case errors.As(err, &notFoundErr):
+		return domain.CodeOrderNotFound
+	case errors.As(err, &alreadyStartedErr):
+		return domain.CodeOrderAlreadyExists
+	case errors.As(err, &alreadyExistsErr):
+		return domain.CodeConflict
+	case errors.As(err, &preconditionErr):
+		return domain.CodePreconditionFailed
+	case errors.As(err, &unavailableErr), errors.As(err, &exhaustedErr),
+		errors.As(err, &deadlineErr), errors.Is(err, context.DeadlineExceeded):
+		return domain.CodeServiceUnavailable
+	default:
+		return domain.CodeInternal
+	}
+}
+
+// contentVersion hashes the JSON encoding of v into a snapshot version, for
+// values that have no version of their own.
+func contentVersion(v any) int64 {
+	data, _ := json.Marshal(v)
+	hash := fnv.New64a()
+	hash.Write(data)
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,348 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+	"github.com/EyalShahaf/temporal-seats/internal/realtime"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	"github.com/oklog/ulid/v2"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// Service wraps the Temporal calls behind the order API, so that every
+// transport (HTTP, WebSocket, gRPC) starts, signals and queries the order and
+// seat workflows the same way. It also owns the hub that watches orders and
+// flights for streaming clients.
+type Service struct {
+	temporal client.Client
+	hub      *realtime.Hub
+	limits   config.LimitsConfig
+	admins   []string
+
+	waitingRoom config.WaitingRoomConfig
+}
+
+// NewService creates a Service whose watched orders and flights are re-queried
+// every cfg.SSETick, whose orders are subject to cfg.Limits, whose flights
+// in cfg.WaitingRoom only take orders with an admitted ticket and whose admin
+// operations are reserved to cfg.Auth.Admins.
+func NewService(temporal client.Client, cfg config.Config) *Service {
+	return &Service{
+		temporal:    temporal,
+		hub:         realtime.NewHub(cfg.SSETick),
+		limits:      cfg.Limits,
+		admins:      cfg.Auth.Admins,
+		waitingRoom: cfg.WaitingRoom,
+	}
+}
+
+// WorkflowID returns the ID of the order workflow for orderID.
+func WorkflowID(orderID string) string {
+	return "order::" + orderID
+}
+
+// orderIDPattern restricts client-supplied order IDs to URL- and workflow-ID-safe values.
+var orderIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// NewOrderID generates a sortable order ID.
+func NewOrderID() string {
+	return ulid.Make().String()
+}
+
+// ValidOrderID reports whether a client-supplied order ID is acceptable.
+func ValidOrderID(orderID string) bool {
+	return orderIDPattern.MatchString(orderID)
+}
+
+// ErrForbidden is returned when the authenticated caller does not own the order.
+var ErrForbidden = errors.New("order belongs to another principal")
+
+// LimitError is returned when a seat selection exceeds a hold limit.
+type LimitError struct {
+	// Code is domain.CodeTooManySeats or domain.CodeHoldLimitExceeded.
+	Code   string
+	Detail string
+}
+
+func (e *LimitError) Error() string { return e.Detail }
+
+// Create starts the order workflow, owned by the authenticated principal in ctx.
+// Order IDs are never reused, even after the previous order has closed: starting
+// an existing order fails with *serviceerror.WorkflowExecutionAlreadyStarted.
+// Flights behind a waiting room need an admitted ticket of the caller, which
+// the order uses up (see TicketError).
+// req.OrderID must already be set.
+func (s *Service) Create(ctx context.Context, req domain.CreateOrderRequest) (client.WorkflowRun, error) {
+	orderID, flightID, ticketID := req.OrderID, req.FlightID, req.TicketID
+	if max := s.limits.MaxSeatsPerOrder; max > 0 && req.AssignSeats > max {
+		return nil, &LimitError{Code: domain.CodeTooManySeats, Detail: fmt.Sprintf("An order can hold at most %d seats", max)}
+	}
+	if !s.waitingRoom.Enabled(flightID) {
+		return s.start(ctx, req)
+	}
+	if ticketID == "" {
+		return nil, &TicketError{
+			Code:   domain.CodeTicketRequired,
+			Detail: fmt.Sprintf("Flight %s is behind a waiting room; join its queue and create the order with an admitted ticket", flightID),
+		}
+	}
+	if err := s.redeemTicket(ctx, flightID, ticketID, orderID); err != nil {
+		return nil, err
+	}
+	run, err := s.start(ctx, req)
+	// A retried request finds its own order already started and keeps the ticket used.
+	var alreadyStartedErr *serviceerror.WorkflowExecutionAlreadyStarted
+	if err != nil && !errors.As(err, &alreadyStartedErr) {
+		s.returnTicket(ctx, flightID, ticketID, orderID)
+	}
+	return run, err
+}
+
+func (s *Service) start(ctx context.Context, req domain.CreateOrderRequest) (client.WorkflowRun, error) {
+	opts := client.StartWorkflowOptions{
+		ID:                                       WorkflowID(req.OrderID),
+		TaskQueue:                                "order-tq",
+		WorkflowIDReusePolicy:                    enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+		WorkflowIDConflictPolicy:                 enumspb.WORKFLOW_ID_CONFLICT_POLICY_FAIL,
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+	}
+	input := workflows.OrderInput{
+		OrderID:  req.OrderID,
+		FlightID: req.FlightID,
+		Customer: auth.Caller(ctx),
+		Limits: workflows.HoldLimits{
+			MaxSeatsPerOrder:        s.limits.MaxSeatsPerOrder,
+			MaxHeldSeatsPerCustomer: s.limits.MaxHeldSeatsPerCustomer,
+		},
+		Waitlist:    req.Waitlist,
+		AssignSeats: req.AssignSeats,
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		input.Owner = p.ID
+	}
+	return s.temporal.ExecuteWorkflow(ctx, opts, workflows.OrderOrchestrationWorkflow, input)
+}
+
+// UpdateSeats signals the order workflow with a new seat selection, after
+// checking it against the hold limits (see LimitError).
+// Seat entity workflows are started automatically via SignalWithStart
+// when the order workflow calls the SeatSignalActivity.
+func (s *Service) UpdateSeats(ctx context.Context, orderID string, seats []string) error {
+	if max := s.limits.MaxSeatsPerOrder; max > 0 && len(seats) > max {
+		return &LimitError{Code: domain.CodeTooManySeats, Detail: fmt.Sprintf("An order can hold at most %d seats", max)}
+	}
+	if err := s.authorizeSeats(ctx, orderID, seats); err != nil {
+		return err
+	}
+	workflowID := WorkflowID(orderID)
+	if err := s.temporal.SignalWorkflow(ctx, workflowID, "", workflows.UpdateSeatsSignal, seats); err != nil {
+		return err
+	}
+	s.hub.Notify(workflowID)
+	return nil
+}
+
+// SubmitPayment signals the order workflow with a payment code.
+func (s *Service) SubmitPayment(ctx context.Context, orderID, code string) error {
+	if err := s.Authorize(ctx, orderID); err != nil {
+		return err
+	}
+	workflowID := WorkflowID(orderID)
+	if err := s.temporal.SignalWorkflow(ctx, workflowID, "", workflows.SubmitPaymentSignal, code); err != nil {
+		return err
+	}
+	s.hub.Notify(workflowID)
+	return nil
+}
+
+// Status queries the current state of the order workflow.
+func (s *Service) Status(ctx context.Context, orderID string) (workflows.OrderState, error) {
+	state, err := s.queryStatus(ctx, WorkflowID(orderID))
+	if err != nil {
+		return state, err
+	}
+	if err := checkOwner(ctx, state); err != nil {
+		return workflows.OrderState{}, err
+	}
+	return state, nil
+}
+
+// Authorize checks that the authenticated principal in ctx owns the order.
+// Without a principal (authentication disabled) every caller is allowed.
+func (s *Service) Authorize(ctx context.Context, orderID string) error {
+	if _, ok := auth.FromContext(ctx); !ok {
+		return nil
+	}
+	_, err := s.Status(ctx, orderID)
+	return err
+}
+
+// authorizeSeats is Authorize plus the per-customer hold limit: the seats the
+// order's customer holds through other orders on the flight, plus the new
+// selection, must not exceed MaxHeldSeatsPerCustomer. Only selections adding
+// seats to the order's own are counted, as the others cannot raise the
+// customer's holds. The order workflow checks the limit again when it
+// receives the selection.
+func (s *Service) authorizeSeats(ctx context.Context, orderID string, seats []string) error {
+	max := s.limits.MaxHeldSeatsPerCustomer
+	if max <= 0 || len(seats) == 0 {
+		return s.Authorize(ctx, orderID)
+	}
+
+	state, err := s.Status(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if state.Customer == "" || !slices.ContainsFunc(seats, func(id string) bool { return !slices.Contains(state.Seats, id) }) {
+		return nil
+	}
+	held, err := seat.CountHeldSeats(ctx, s.temporal, state.FlightID, state.Customer, orderID)
+	if err != nil {
+		return err
+	}
+	if held+len(seats) > max {
+		return &LimitError{
+			Code:   domain.CodeHoldLimitExceeded,
+			Detail: fmt.Sprintf("A customer can hold at most %d seats on this flight; %d are already held by other orders", max, held),
+		}
+	}
+	return nil
+}
+
+func checkOwner(ctx context.Context, state workflows.OrderState) error {
+	if p, ok := auth.FromContext(ctx); ok && p.ID != state.Owner {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *Service) queryStatus(ctx context.Context, workflowID string) (workflows.OrderState, error) {
+	var state workflows.OrderState
+	resp, err := s.temporal.QueryWorkflow(ctx, workflowID, "", workflows.GetStatusQuery)
+	if err != nil {
+		return state, err
+	}
+	if err := resp.Get(&state); err != nil {
+		return state, fmt.Errorf("decode order state: %w", err)
+	}
+	return state, nil
+}
+
+// WatchOrder subscribes to changes of the order. Updates carry a
+// workflows.OrderState and are only delivered when the order version changes.
+// The subscription is not authorized; callers check Authorize first.
+//
+// The watcher polls GetStatus every SSETick, and right away after a change
+// made through the Service; changes the workflow makes by itself, such as
+// hold expiry or waitlist grants, are seen on the next poll. Waiting for
+// changes inside the workflow would add to the history of the order, which
+// never closes.
+func (s *Service) WatchOrder(orderID string) *realtime.Subscription {
+	workflowID := WorkflowID(orderID)
+	return s.hub.Subscribe(workflowID, func(ctx context.Context) (realtime.Snapshot, error) {
+		state, err := s.queryStatus(ctx, workflowID)
+		if err != nil {
+			return realtime.Snapshot{}, err
+		}
+		return realtime.Snapshot{Version: state.Version, Value: state}, nil
+	})
+}
+
+// WaitForChange blocks until the order moves past current's version, wait
+// elapses or ctx is done, and returns the latest known state.
+func (s *Service) WaitForChange(ctx context.Context, orderID string, current workflows.OrderState, wait time.Duration) workflows.OrderState {
+	sub := s.WatchOrder(orderID)
+	defer sub.Close()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return current
+		case <-timer.C:
+			return current
+		case u, ok := <-sub.C:
+			if !ok || u.Err != nil {
+				return current
+			}
+			if u.Version != current.Version {
+				return u.Value.(workflows.OrderState)
+			}
+		}
+	}
+}
+
+// WatchFlight subscribes to seat availability changes of the flight. Updates
+// carry a domain.FlightAvailability.
+func (s *Service) WatchFlight(flightID string) *realtime.Subscription {
+	return s.hub.Subscribe(flightTopic(flightID), func(ctx context.Context) (realtime.Snapshot, error) {
+		avail := s.Availability(ctx, flightID)
+		// Availability has no version of its own, so a hash of its content is used.
+		return realtime.Snapshot{Version: contentVersion(avail), Value: avail}, nil
+	})
+}
+
+// flightTopic is the hub key of the availability of a flight.
+func flightTopic(flightID string) string {
+	return "flight::" + flightID
+}
+
+// Availability queries every seat entity of a flight and groups the seats by state.
+func (s *Service) Availability(ctx context.Context, flightID string) domain.FlightAvailability {
+	allSeats := seat.AllSeatIDs()
+	resp := domain.FlightAvailability{
+		FlightID:  flightID,
+		Available: []string{},
+		Held:      []string{},
+		Confirmed: []string{},
+		Blocked:   []string{},
+		Total:     len(allSeats),
+	}
+
+	// Query each seat's state
+	for _, seatID := range allSeats {
+		wfID := seat.WorkflowID(flightID, seatID)
+
+		// Try to query the seat workflow
+		qr, err := s.temporal.QueryWorkflow(ctx, wfID, "", "GetState")
+		if err != nil {
+			// If workflow doesn't exist, seat is available
+			resp.Available = append(resp.Available, seatID)
+			continue
+		}
+
+		var state seat.SeatState
+		if err := qr.Get(&state); err != nil {
+			// If we can't get state, assume available
+			resp.Available = append(resp.Available, seatID)
+			continue
+		}
+
+		// Categorize seat based on state
+		switch seatStatus(state) {
+		case "confirmed":
+			resp.Confirmed = append(resp.Confirmed, seatID)
+		case "blocked":
+			resp.Blocked = append(resp.Blocked, seatID)
+		case "held":
+			resp.Held = append(resp.Held, seatID)
+		default:
+			resp.Available = append(resp.Available, seatID)
+		}
+	}
+
+	return resp
+}
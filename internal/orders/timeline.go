@@ -0,0 +1,234 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/activities"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// Timeline reads the history of the order workflow and renders what happened
+// to the order: the signals it received, the activities it ran with their
+// results, the timers that fired and how the workflow ended. The order's
+// owner and the admins may read it.
+func (s *Service) Timeline(ctx context.Context, orderID string) (domain.OrderTimeline, error) {
+	workflowID := WorkflowID(orderID)
+	state, err := s.queryStatus(ctx, workflowID)
+	if err != nil {
+		return domain.OrderTimeline{}, err
+	}
+	if s.authorizeAdmin(ctx) != nil {
+		if err := checkOwner(ctx, state); err != nil {
+			return domain.OrderTimeline{}, err
+		}
+	}
+
+	r := timelineRenderer{scheduled: map[int64]scheduledActivity{}, timers: map[int64]time.Duration{}}
+	timeline := domain.OrderTimeline{OrderID: orderID, State: state.State, Events: []domain.TimelineEvent{}}
+	iter := s.temporal.GetWorkflowHistory(ctx, workflowID, "", false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			return domain.OrderTimeline{}, fmt.Errorf("read order history: %w", err)
+		}
+		if e, ok := r.render(event); ok {
+			timeline.Events = append(timeline.Events, e)
+		}
+	}
+	return timeline, nil
+}
+
+// scheduledActivity is an activity waiting for its outcome in the history.
+type scheduledActivity struct {
+	name    string
+	input   any
+	summary string
+}
+
+// timelineRenderer turns history events into timeline events. Activities and
+// timers appear once, when they finish, so it remembers when they started.
+type timelineRenderer struct {
+	scheduled map[int64]scheduledActivity
+	timers    map[int64]time.Duration
+}
+
+func (r *timelineRenderer) render(event *historypb.HistoryEvent) (domain.TimelineEvent, bool) {
+	e := domain.TimelineEvent{At: event.GetEventTime().AsTime()}
+
+	switch event.GetEventType() {
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED:
+		var input workflows.OrderInput
+		decodePayloads(event.GetWorkflowExecutionStartedEventAttributes().GetInput(), &input)
+		e.Kind, e.Name, e.Input = "workflow", "started", input
+		e.Summary = fmt.Sprintf("Order created for flight %s", input.FlightID)
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED:
+		attrs := event.GetWorkflowExecutionSignaledEventAttributes()
+		e.Kind, e.Name = "signal", attrs.GetSignalName()
+		e.Input, e.Summary = describeSignal(attrs.GetSignalName(), attrs.GetInput())
+
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED:
+		attrs := event.GetActivityTaskScheduledEventAttributes()
+		name := attrs.GetActivityType().GetName()
+		input, summary := describeActivity(name, attrs.GetInput())
+		r.scheduled[event.GetEventId()] = scheduledActivity{name: name, input: input, summary: summary}
+		return e, false
+
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED:
+		attrs := event.GetActivityTaskCompletedEventAttributes()
+		r.activity(&e, attrs.GetScheduledEventId(), "completed")
+		e.Result = decodeAny(attrs.GetResult())
+
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_FAILED:
+		attrs := event.GetActivityTaskFailedEventAttributes()
+		r.activity(&e, attrs.GetScheduledEventId(), "failed")
+		e.Error = attrs.GetFailure().GetMessage()
+
+	case enumspb.EVENT_TYPE_ACTIVITY_TASK_TIMED_OUT:
+		attrs := event.GetActivityTaskTimedOutEventAttributes()
+		r.activity(&e, attrs.GetScheduledEventId(), "timed out")
+		e.Error = attrs.GetFailure().GetMessage()
+
+	case enumspb.EVENT_TYPE_TIMER_STARTED:
+		r.timers[event.GetEventId()] = event.GetTimerStartedEventAttributes().GetStartToFireTimeout().AsDuration()
+		return e, false
+
+	case enumspb.EVENT_TYPE_TIMER_FIRED:
+		d := r.timers[event.GetTimerFiredEventAttributes().GetStartedEventId()]
+		e.Kind, e.Name = "timer", "fired"
+		e.Summary = fmt.Sprintf("Timer fired after %s", d.Round(time.Second))
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED:
+		e.Kind, e.Name, e.Summary = "workflow", "completed", "Workflow completed"
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_FAILED:
+		e.Kind, e.Name, e.Summary = "workflow", "failed", "Workflow failed"
+		e.Error = event.GetWorkflowExecutionFailedEventAttributes().GetFailure().GetMessage()
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_CANCELED:
+		e.Kind, e.Name, e.Summary = "workflow", "canceled", "Workflow canceled"
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_TERMINATED:
+		e.Kind, e.Name, e.Summary = "workflow", "terminated", "Workflow terminated"
+		e.Error = event.GetWorkflowExecutionTerminatedEventAttributes().GetReason()
+
+	case enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_TIMED_OUT:
+		e.Kind, e.Name, e.Summary = "workflow", "timed out", "Workflow timed out"
+
+	default:
+		return e, false
+	}
+	return e, true
+}
+
+// activity fills e with the activity scheduled by scheduledEventID, which
+// ended with outcome.
+func (r *timelineRenderer) activity(e *domain.TimelineEvent, scheduledEventID int64, outcome string) {
+	a := r.scheduled[scheduledEventID]
+	delete(r.scheduled, scheduledEventID)
+	e.Kind, e.Name, e.Input = "activity", a.name, a.input
+	e.Summary = fmt.Sprintf("%s %s", a.summary, outcome)
+}
+
+func describeSignal(name string, payloads *commonpb.Payloads) (any, string) {
+	switch name {
+	case workflows.UpdateSeatsSignal:
+		var seats []string
+		decodePayloads(payloads, &seats)
+		if len(seats) == 0 {
+			return seats, "Seat selection cleared"
+		}
+		return seats, fmt.Sprintf("Seats selected: %s", strings.Join(seats, ", "))
+	case workflows.SubmitPaymentSignal:
+		var code string
+		decodePayloads(payloads, &code)
+		code = redactPaymentCode(code)
+		return code, fmt.Sprintf("Payment submitted with code %s", code)
+	case seat.WaitlistSignal:
+		var notice seat.WaitlistNotice
+		decodePayloads(payloads, &notice)
+		return notice, fmt.Sprintf("Waitlist of seat %s: %s", notice.SeatID, notice.Event)
+	default:
+		return decodeAny(payloads), fmt.Sprintf("Signal %s received", name)
+	}
+}
+
+func describeActivity(name string, payloads *commonpb.Payloads) (any, string) {
+	switch name {
+	case "SeatSignalActivity":
+		var in activities.SeatSignalInput
+		decodePayloads(payloads, &in)
+		return in, fmt.Sprintf("%s of seat %s", in.Cmd.Type, in.SeatID)
+	case "SeatBatchActivity":
+		var in activities.SeatBatchInput
+		decodePayloads(payloads, &in)
+		return in, fmt.Sprintf("%s of seats %s", in.Cmd.Type, strings.Join(in.SeatIDs, ", "))
+	case "ValidatePaymentActivity":
+		var orderID, code string
+		decodePayloads(payloads, &orderID, &code)
+		code = redactPaymentCode(code)
+		return []string{orderID, code}, fmt.Sprintf("Payment validation with code %s", code)
+	case "CountHeldSeatsActivity":
+		var in activities.CountHeldSeatsInput
+		decodePayloads(payloads, &in)
+		return in, "Hold limit check"
+	case "FreeSeatsActivity":
+		var in activities.FreeSeatsInput
+		decodePayloads(payloads, &in)
+		return in, "Free seat lookup for seat assignment"
+	case "ConfirmOrderActivity":
+		return decodeAny(payloads), "Order confirmation"
+	case "FailOrderActivity":
+		return decodeAny(payloads), "Order failure handling"
+	default:
+		return decodeAny(payloads), fmt.Sprintf("Activity %s", name)
+	}
+}
+
+// redactPaymentCode masks a payment code. Long codes keep their last two
+// characters, enough to tell attempts apart without exposing the code.
+func redactPaymentCode(code string) string {
+	if len(code) < 8 {
+		return strings.Repeat("*", len(code))
+	}
+	return strings.Repeat("*", len(code)-2) + code[len(code)-2:]
+}
+
+// decodePayloads decodes payloads into valuePtrs in order. A payload that
+// does not decode leaves its value zero: the timeline is best effort.
+func decodePayloads(payloads *commonpb.Payloads, valuePtrs ...any) {
+	dc := converter.GetDefaultDataConverter()
+	for i, p := range payloads.GetPayloads() {
+		if i < len(valuePtrs) {
+			_ = dc.FromPayload(p, valuePtrs[i])
+		}
+	}
+}
+
+// decodeAny decodes payloads of an unknown type: nil without payloads, the
+// value of a single payload or the list of values.
+func decodeAny(payloads *commonpb.Payloads) any {
+	values := make([]any, len(payloads.GetPayloads()))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	decodePayloads(payloads, ptrs...)
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return values[0]
+	default:
+		return values
+	}
+}
@@ -0,0 +1,169 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/waitingroom"
+	"github.com/EyalShahaf/temporal-seats/internal/realtime"
+	"github.com/oklog/ulid/v2"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// TicketError is returned when a waiting room ticket cannot be used.
+type TicketError struct {
+	// Code is domain.CodeTicketRequired, CodeTicketNotFound, CodeTicketNotAdmitted,
+	// CodeForbidden, or CodeInvalidRequest for flights without a waiting room.
+	Code   string
+	Detail string
+}
+
+func (e *TicketError) Error() string { return e.Detail }
+
+// JoinQueue puts the caller in ctx into the waiting room of a flight, starting
+// the room on first use, and returns the caller's ticket. A caller that already
+// has a waiting or admitted ticket for the flight gets that ticket back.
+func (s *Service) JoinQueue(ctx context.Context, flightID string) (domain.QueueTicket, error) {
+	if !s.waitingRoom.Enabled(flightID) {
+		return domain.QueueTicket{}, &TicketError{
+			Code:   domain.CodeInvalidRequest,
+			Detail: fmt.Sprintf("Flight %s has no waiting room; create orders directly", flightID),
+		}
+	}
+
+	ticketID := ulid.Make().String()
+	opts := client.StartWorkflowOptions{
+		ID:        waitingroom.WorkflowID(flightID),
+		TaskQueue: "seat-tq",
+	}
+	input := waitingroom.Input{
+		FlightID:       flightID,
+		AdmitPerMinute: s.waitingRoom.AdmitPerMinute,
+		AdmissionTTL:   s.waitingRoom.AdmissionTTL,
+	}
+	join := waitingroom.JoinRequest{TicketID: ticketID, Customer: auth.Caller(ctx)}
+	if _, err := s.temporal.SignalWithStartWorkflow(ctx, opts.ID, waitingroom.JoinSignal, join, opts, waitingroom.WaitingRoomWorkflow, input); err != nil {
+		return domain.QueueTicket{}, err
+	}
+	return s.Ticket(ctx, flightID, ticketID)
+}
+
+// Ticket queries the current state of a waiting room ticket.
+func (s *Service) Ticket(ctx context.Context, flightID, ticketID string) (domain.QueueTicket, error) {
+	status, err := s.queryTicket(ctx, flightID, ticketID)
+	if err != nil {
+		return domain.QueueTicket{}, err
+	}
+	return toQueueTicket(status), nil
+}
+
+func (s *Service) queryTicket(ctx context.Context, flightID, ticketID string) (waitingroom.TicketStatus, error) {
+	var status waitingroom.TicketStatus
+	resp, err := s.temporal.QueryWorkflow(ctx, waitingroom.WorkflowID(flightID), "", waitingroom.GetTicketQuery, ticketID)
+	if err != nil {
+		return status, ticketError(err)
+	}
+	if err := resp.Get(&status); err != nil {
+		return status, fmt.Errorf("decode ticket: %w", err)
+	}
+	return status, nil
+}
+
+// WatchTicket subscribes to changes of a waiting room ticket, such as its
+// queue position. Updates carry a domain.QueueTicket.
+func (s *Service) WatchTicket(flightID, ticketID string) *realtime.Subscription {
+	return s.hub.Subscribe(waitingroom.WorkflowID(flightID)+"::"+ticketID, func(ctx context.Context) (realtime.Snapshot, error) {
+		ticket, err := s.Ticket(ctx, flightID, ticketID)
+		if err != nil {
+			return realtime.Snapshot{}, err
+		}
+		return realtime.Snapshot{Version: contentVersion(ticket), Value: ticket}, nil
+	})
+}
+
+// redeemTicket marks the ticket as used by orderID, failing unless it is
+// admitted and belongs to the caller in ctx.
+func (s *Service) redeemTicket(ctx context.Context, flightID, ticketID, orderID string) error {
+	return s.updateTicket(ctx, flightID, waitingroom.RedeemUpdate, waitingroom.RedeemRequest{
+		TicketID: ticketID,
+		OrderID:  orderID,
+		Customer: auth.Caller(ctx),
+	})
+}
+
+// returnTicket undoes redeemTicket after the order could not be started, so
+// that the customer does not lose its place.
+func (s *Service) returnTicket(ctx context.Context, flightID, ticketID, orderID string) {
+	err := s.updateTicket(ctx, flightID, waitingroom.ReturnTicketUpdate, waitingroom.RedeemRequest{
+		TicketID: ticketID,
+		OrderID:  orderID,
+	})
+	if err != nil {
+		log.Printf("Failed to return ticket %s of flight %s: %v", ticketID, flightID, err)
+	}
+}
+
+func (s *Service) updateTicket(ctx context.Context, flightID, update string, req waitingroom.RedeemRequest) error {
+	handle, err := s.temporal.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   waitingroom.WorkflowID(flightID),
+		UpdateName:   update,
+		Args:         []interface{}{req},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err == nil {
+		err = handle.Get(ctx, nil)
+	}
+	return ticketError(err)
+}
+
+// ticketError turns the errors of the waiting room workflow into TicketErrors.
+// A missing room has no tickets, and the only failing query is for an unknown ticket.
+func ticketError(err error) error {
+	var (
+		appErr      *temporal.ApplicationError
+		notFoundErr *serviceerror.NotFound
+		queryErr    *serviceerror.QueryFailed
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &appErr):
+		switch appErr.Type() {
+		case waitingroom.ErrTypeTicketNotFound:
+			return &TicketError{Code: domain.CodeTicketNotFound, Detail: "Ticket not found"}
+		case waitingroom.ErrTypeTicketForbidden:
+			return &TicketError{Code: domain.CodeForbidden, Detail: "Ticket belongs to another caller"}
+		case waitingroom.ErrTypeTicketNotAdmitted:
+			return &TicketError{Code: domain.CodeTicketNotAdmitted, Detail: "Ticket cannot create an order: " + appErr.Message()}
+		}
+	case errors.As(err, &notFoundErr), errors.As(err, &queryErr):
+		return &TicketError{Code: domain.CodeTicketNotFound, Detail: "Ticket not found"}
+	}
+	return err
+}
+
+func toQueueTicket(status waitingroom.TicketStatus) domain.QueueTicket {
+	ticket := domain.QueueTicket{
+		TicketID:             status.ID,
+		FlightID:             status.FlightID,
+		State:                string(status.State),
+		Position:             status.Position,
+		EstimatedWaitSeconds: int(status.EstimatedWait.Seconds()),
+		OrderID:              status.OrderID,
+	}
+	if !status.AdmittedAt.IsZero() {
+		admittedAt := status.AdmittedAt
+		ticket.AdmittedAt = &admittedAt
+	}
+	if !status.ExpiresAt.IsZero() {
+		expiresAt := status.ExpiresAt
+		ticket.ExpiresAt = &expiresAt
+	}
+	return ticket
+}
@@ -0,0 +1,156 @@
+// Package ratelimit limits how fast each client may call the API: token
+// buckets for request rates and counters for concurrent streaming connections.
+// Clients are identified by key, normally auth.Caller (API key, JWT subject or
+// IP address).
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"golang.org/x/time/rate"
+)
+
+// Limits are the separate budgets of every client. A nil budget is unlimited.
+type Limits struct {
+	// Mutations limits requests that start or signal workflows.
+	Mutations *Limiter
+	// Availability limits reads: flight availability, each of which queries
+	// every seat, order status and queue tickets.
+	Availability *Limiter
+	// Streams caps open SSE, WebSocket and gRPC watch connections and status long-polls.
+	Streams *Conns
+}
+
+// New creates the budgets described by cfg.
+func New(cfg config.RateLimitConfig) Limits {
+	return Limits{
+		Mutations:    NewLimiter(cfg.MutationsPerMinute, cfg.MutationBurst),
+		Availability: NewLimiter(cfg.AvailabilityPerMinute, cfg.AvailabilityBurst),
+		Streams:      NewConns(cfg.MaxStreams),
+	}
+}
+
+// sweepInterval is how often idle buckets are looked for.
+const sweepInterval = time.Minute
+
+// Limiter keeps a token bucket per client: a client may send burst requests at
+// once, refilled at perMinute requests per minute. It is safe for concurrent use.
+type Limiter struct {
+	limit rate.Limit
+	burst int
+	// idle is how long a bucket takes to refill completely; idle buckets are
+	// full and can be forgotten without changing what a client may do.
+	idle time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewLimiter creates a Limiter, or returns nil (unlimited) when perMinute is not positive.
+func NewLimiter(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limit := rate.Limit(float64(perMinute) / 60)
+	return &Limiter{
+		limit:   limit,
+		burst:   burst,
+		idle:    time.Duration(float64(burst) / float64(limit) * float64(time.Second)),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the client's bucket. If the bucket is empty it
+// returns false and how long until a token is available.
+func (l *Limiter) Allow(key string) (ok bool, retryAfter time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > l.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Conns counts the open connections of each client. It is safe for concurrent use.
+type Conns struct {
+	max int
+
+	mu   sync.Mutex
+	open map[string]int
+}
+
+// NewConns creates a Conns allowing max connections per client, or returns nil
+// (unlimited) when max is not positive.
+func NewConns(max int) *Conns {
+	if max <= 0 {
+		return nil
+	}
+	return &Conns{max: max, open: make(map[string]int)}
+}
+
+// Acquire opens a connection for the client unless it already has the maximum
+// open. The returned release function closes it again and must be called once.
+func (c *Conns) Acquire(key string) (release func(), ok bool) {
+	if c == nil {
+		return func() {}, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.open[key] >= c.max {
+		return nil, false
+	}
+	c.open[key]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.open[key]--; c.open[key] <= 0 {
+				delete(c.open, key)
+			}
+		})
+	}, true
+}
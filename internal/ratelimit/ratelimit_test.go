@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_BurstThenRefill(t *testing.T) {
+	l := NewLimiter(60, 2) // one token per second
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow("apikey:a")
+		require.True(t, ok)
+	}
+	ok, retryAfter := l.Allow("apikey:a")
+	require.False(t, ok)
+	require.Equal(t, time.Second, retryAfter)
+
+	// Other clients have their own bucket.
+	ok, _ = l.Allow("ip:10.0.0.1")
+	require.True(t, ok)
+
+	// A rejected request does not use up the token that is refilling.
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("apikey:a")
+	require.True(t, ok)
+}
+
+func TestLimiter_ForgetsIdleClients(t *testing.T) {
+	l := NewLimiter(60, 2)
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+
+	l.Allow("apikey:a")
+	now = now.Add(2 * sweepInterval)
+	l.Allow("apikey:b")
+	require.Len(t, l.buckets, 1)
+	require.Contains(t, l.buckets, "apikey:b")
+}
+
+func TestConns(t *testing.T) {
+	c := NewConns(2)
+
+	release1, ok := c.Acquire("apikey:a")
+	require.True(t, ok)
+	_, ok = c.Acquire("apikey:a")
+	require.True(t, ok)
+	_, ok = c.Acquire("apikey:a")
+	require.False(t, ok)
+
+	// Releasing twice frees only one connection.
+	release1()
+	release1()
+	_, ok = c.Acquire("apikey:a")
+	require.True(t, ok)
+	_, ok = c.Acquire("apikey:a")
+	require.False(t, ok)
+}
+
+func TestNew_Disabled(t *testing.T) {
+	limits := New(config.RateLimitConfig{})
+	require.Nil(t, limits.Mutations)
+	require.Nil(t, limits.Availability)
+	require.Nil(t, limits.Streams)
+
+	ok, _ := limits.Mutations.Allow("apikey:a")
+	require.True(t, ok)
+	release, ok := limits.Streams.Acquire("apikey:a")
+	require.True(t, ok)
+	release()
+}
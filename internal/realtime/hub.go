@@ -0,0 +1,196 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Snapshot is a versioned value published by a Hub. Version only needs to
+// change whenever Value changes; it does not have to be ordered.
+type Snapshot struct {
+	Version int64
+	Value   any
+}
+
+// Update is delivered to subscribers. Err is set when the source failed;
+// the subscription channel is closed right after an error update.
+type Update struct {
+	Snapshot
+	Err error
+}
+
+// Source fetches the current snapshot of a topic.
+type Source func(ctx context.Context) (Snapshot, error)
+
+// Hub fans out changes of watched topics (e.g. an order workflow) to any number
+// of subscribers. Each topic is polled by a single watcher regardless of how
+// many clients are subscribed, and subscribers only receive a new update when
+// the snapshot version changes. Notify shortens the wait for changes the
+// caller made; other changes are seen on the next poll.
+type Hub struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	topics map[string]*topic
+}
+
+type topic struct {
+	subs   map[*Subscription]struct{}
+	latest *Snapshot
+	wake   chan struct{}
+	cancel context.CancelFunc
+}
+
+// Subscription receives updates for a single topic until it is closed.
+type Subscription struct {
+	C <-chan Update
+
+	ch     chan Update
+	hub    *Hub
+	key    string
+	closed bool
+}
+
+// NewHub creates a Hub that re-fetches each watched topic every interval.
+func NewHub(interval time.Duration) *Hub {
+	return &Hub{
+		interval: interval,
+		timeout:  10 * time.Second,
+		topics:   make(map[string]*topic),
+	}
+}
+
+// Subscribe registers for updates on key. If no watcher is running for key yet,
+// one is started using src. A new subscriber immediately receives the latest
+// known snapshot, if any.
+func (h *Hub) Subscribe(key string, src Source) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t, ok := h.topics[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		t = &topic{
+			subs:   make(map[*Subscription]struct{}),
+			wake:   make(chan struct{}, 1),
+			cancel: cancel,
+		}
+		h.topics[key] = t
+		go h.watch(ctx, key, t, src)
+	}
+
+	ch := make(chan Update, 1)
+	sub := &Subscription{C: ch, ch: ch, hub: h, key: key}
+	t.subs[sub] = struct{}{}
+	if t.latest != nil {
+		ch <- Update{Snapshot: *t.latest}
+	}
+	return sub
+}
+
+// Notify asks the watcher of key to re-fetch immediately instead of waiting
+// for the next tick. It is a no-op when nobody is watching key.
+func (h *Hub) Notify(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t, ok := h.topics[key]; ok {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close unsubscribes and stops the topic watcher once no subscribers remain.
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+
+	t, ok := h.topics[s.key]
+	if !ok {
+		return
+	}
+	delete(t.subs, s)
+	if len(t.subs) == 0 {
+		t.cancel()
+		delete(h.topics, s.key)
+	}
+}
+
+func (h *Hub) watch(ctx context.Context, key string, t *topic, src Source) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		snap, err := src(fetchCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			h.fail(key, t, err)
+			return
+		}
+		h.publish(t, snap)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-t.wake:
+		}
+	}
+}
+
+// publish delivers snap to all subscribers if its version differs from the
+// last published one. Slow subscribers only ever see the newest snapshot.
+func (h *Hub) publish(t *topic, snap Snapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t.latest != nil && t.latest.Version == snap.Version {
+		return
+	}
+	t.latest = &snap
+	for sub := range t.subs {
+		sub.send(Update{Snapshot: snap})
+	}
+}
+
+// fail delivers err to all subscribers, closes their channels and removes the topic.
+func (h *Hub) fail(key string, t *topic, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range t.subs {
+		sub.send(Update{Err: err})
+		sub.closed = true
+		close(sub.ch)
+	}
+	t.subs = nil
+	t.cancel()
+	if h.topics[key] == t {
+		delete(h.topics, key)
+	}
+}
+
+// send replaces any undelivered update with u. Callers must hold hub.mu.
+func (s *Subscription) send(u Update) {
+	select {
+	case <-s.ch:
+	default:
+	}
+	s.ch <- u
+}
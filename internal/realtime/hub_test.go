@@ -0,0 +1,96 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_PublishesOnlyOnVersionChange(t *testing.T) {
+	hub := NewHub(5 * time.Millisecond)
+
+	var calls atomic.Int64
+	src := func(ctx context.Context) (Snapshot, error) {
+		n := calls.Add(1)
+		// Version changes every third fetch.
+		v := (n + 2) / 3
+		return Snapshot{Version: v, Value: v}, nil
+	}
+
+	sub := hub.Subscribe("order::1", src)
+	defer sub.Close()
+
+	var versions []int64
+	for len(versions) < 3 {
+		select {
+		case u := <-sub.C:
+			require.NoError(t, u.Err)
+			versions = append(versions, u.Version)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for update")
+		}
+	}
+	require.Equal(t, []int64{1, 2, 3}, versions)
+}
+
+func TestHub_SharesWatcherBetweenSubscribers(t *testing.T) {
+	hub := NewHub(time.Hour)
+
+	var calls atomic.Int64
+	src := func(ctx context.Context) (Snapshot, error) {
+		calls.Add(1)
+		return Snapshot{Version: 1}, nil
+	}
+
+	first := hub.Subscribe("order::1", src)
+	defer first.Close()
+	<-first.C
+
+	second := hub.Subscribe("order::1", src)
+	defer second.Close()
+	u := <-second.C
+	require.Equal(t, int64(1), u.Version)
+	require.Equal(t, int64(1), calls.Load())
+}
+
+func TestHub_NotifyRefetchesImmediately(t *testing.T) {
+	hub := NewHub(time.Hour)
+
+	var calls atomic.Int64
+	src := func(ctx context.Context) (Snapshot, error) {
+		return Snapshot{Version: calls.Add(1)}, nil
+	}
+
+	sub := hub.Subscribe("order::1", src)
+	defer sub.Close()
+	<-sub.C
+
+	hub.Notify("order::1")
+	select {
+	case u := <-sub.C:
+		require.Equal(t, int64(2), u.Version)
+	case <-time.After(time.Second):
+		t.Fatal("Notify did not trigger a refetch")
+	}
+}
+
+func TestHub_ErrorClosesSubscription(t *testing.T) {
+	hub := NewHub(time.Hour)
+	boom := errors.New("boom")
+
+	sub := hub.Subscribe("order::1", func(ctx context.Context) (Snapshot, error) {
+		return Snapshot{}, boom
+	})
+
+	u, ok := <-sub.C
+	require.True(t, ok)
+	require.ErrorIs(t, u.Err, boom)
+
+	_, ok = <-sub.C
+	require.False(t, ok)
+	sub.Close() // must be safe after the hub closed it
+}
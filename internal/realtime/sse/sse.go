@@ -0,0 +1,95 @@
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	ID   string // optional; sets the client's Last-Event-ID
+	Name string // optional; empty means the default "message" event
+	Data any    // marshaled as JSON
+}
+
+// WriteHeaders sets the standard SSE response headers.
+func WriteHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+}
+
+// Write encodes ev onto w in the text/event-stream format.
+func Write(w io.Writer, ev Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", ev.ID)
+	}
+	if ev.Name != "" {
+		fmt.Fprintf(&b, "event: %s\n", ev.Name)
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// WriteComment writes a comment line, which clients ignore. It is used as a keep-alive.
+func WriteComment(w io.Writer, text string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", text)
+	return err
+}
+
+// Reader decodes a text/event-stream. Data of the events it returns is a json.RawMessage.
+type Reader struct {
+	scanner *bufio.Scanner
+}
+
+// NewReader returns a Reader decoding events from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	return &Reader{scanner: scanner}
+}
+
+// Next returns the next event, skipping comments. It returns io.EOF when the stream ends.
+func (r *Reader) Next() (Event, error) {
+	var ev Event
+	var data []string
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if line == "" {
+			if data == nil {
+				continue // comment-only block or stray blank line
+			}
+			ev.Data = json.RawMessage(strings.Join(data, "\n"))
+			return ev, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
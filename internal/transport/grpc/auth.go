@@ -0,0 +1,104 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// reflectionPrefix is the method prefix of the server reflection service, which needs no credentials.
+const reflectionPrefix = "/grpc.reflection."
+
+// authenticator authenticates calls with the same credentials as the HTTP API:
+// "x-api-key" or "authorization: Bearer <jwt>" metadata.
+type authenticator struct {
+	authn auth.Authenticator
+}
+
+func (a authenticator) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a authenticator) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate stores the caller's principal in ctx, like the HTTP middleware.
+func (a authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if strings.HasPrefix(method, reflectionPrefix) {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	creds := auth.Credentials{APIKey: first(md.Get("x-api-key"))}
+	if scheme, token, ok := strings.Cut(first(md.Get("authorization")), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		creds.BearerToken = strings.TrimSpace(token)
+	}
+
+	p, err := a.authn.Authenticate(ctx, creds)
+	if err != nil {
+		detail := "Invalid credentials"
+		if errors.Is(err, auth.ErrNoCredentials) {
+			detail = "Credentials are required"
+		} else {
+			log.Printf("Rejected credentials for %s: %v", method, err)
+		}
+		return ctx, newStatusError(domain.CodeUnauthorized, detail, "")
+	}
+	return auth.WithPrincipal(ctx, p), nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// clientIPUnary and clientIPStream store the caller's network address in the
+// context, identifying unauthenticated callers for per-customer limits.
+func clientIPUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withClientIP(ctx), req)
+}
+
+func clientIPStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withClientIP(ss.Context())})
+}
+
+func withClientIP(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ctx
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return auth.WithClientIP(ctx, host)
+}
+
+// contextStream overrides the context of a server stream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
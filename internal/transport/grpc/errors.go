@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"errors"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the ErrorInfo domain of every error returned by the ReservationService.
+const errorDomain = "temporal-seats"
+
+// grpcCodes maps the stable domain error codes to gRPC status codes.
+var grpcCodes = map[string]codes.Code{
+	domain.CodeInvalidRequest:     codes.InvalidArgument,
+	domain.CodeInvalidOrderID:     codes.InvalidArgument,
+	domain.CodeUnauthorized:       codes.Unauthenticated,
+	domain.CodeForbidden:          codes.PermissionDenied,
+	domain.CodeOrderNotFound:      codes.NotFound,
+	domain.CodeOrderAlreadyExists: codes.AlreadyExists,
+	domain.CodeConflict:           codes.Aborted,
+	domain.CodeTooManySeats:       codes.InvalidArgument,
+	domain.CodeHoldLimitExceeded:  codes.FailedPrecondition,
+	domain.CodeTicketRequired:     codes.FailedPrecondition,
+	domain.CodeTicketNotFound:     codes.NotFound,
+	domain.CodeTicketNotAdmitted:  codes.FailedPrecondition,
+	domain.CodePreconditionFailed: codes.FailedPrecondition,
+	domain.CodeRateLimited:        codes.ResourceExhausted,
+	domain.CodeServiceUnavailable: codes.Unavailable,
+	domain.CodeInternal:           codes.Internal,
+}
+
+// newStatusError builds a gRPC status error for a domain error code. The code
+// itself is attached as the reason of an ErrorInfo detail, so clients can branch
+// on the same codes as HTTP clients do on Problem.Code.
+func newStatusError(code, detail, orderID string) error {
+	grpcCode, ok := grpcCodes[code]
+	if !ok {
+		grpcCode = codes.Internal
+	}
+	st := status.New(grpcCode, detail)
+	info := &errdetails.ErrorInfo{Reason: code, Domain: errorDomain}
+	if orderID != "" {
+		info.Metadata = map[string]string{"orderId": orderID}
+	}
+	if withDetails, err := st.WithDetails(info); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func invalidArgument(detail, orderID string) error {
+	return newStatusError(domain.CodeInvalidRequest, detail, orderID)
+}
+
+// temporalStatus maps an error from the Temporal client to a gRPC status error,
+// using the same classification as the HTTP API's problem responses.
+func temporalStatus(err error, orderID, detail string) error {
+	// Waiting room ticket errors carry their own detail, including when forbidden.
+	var ticketErr *orders.TicketError
+	if errors.As(err, &ticketErr) {
+		return newStatusError(ticketErr.Code, ticketErr.Detail, orderID)
+	}
+	switch code := orders.ErrorCode(err); code {
+	case domain.CodeForbidden:
+		return newStatusError(code, "Order belongs to another caller", orderID)
+	case domain.CodeOrderNotFound:
+		return newStatusError(code, "Order not found", orderID)
+	case domain.CodeOrderAlreadyExists:
+		return newStatusError(code, "Order already exists", orderID)
+	case domain.CodeServiceUnavailable:
+		return newStatusError(code, "Temporal is unavailable, try again later", orderID)
+	case domain.CodeTooManySeats, domain.CodeHoldLimitExceeded:
+		return newStatusError(code, err.Error(), orderID)
+	default:
+		return newStatusError(code, detail, orderID)
+	}
+}
@@ -0,0 +1,63 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	reservationv1 "github.com/EyalShahaf/temporal-seats/api/reservation/v1"
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// streamRetryAfter is suggested to callers over their stream limit, which frees
+// up when one of their streams closes rather than at a known time.
+const streamRetryAfter = 5 * time.Second
+
+// rateLimiter applies the HTTP API's per-caller budgets to the ReservationService.
+type rateLimiter struct {
+	limits ratelimit.Limits
+}
+
+func (l rateLimiter) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var budget *ratelimit.Limiter
+	switch info.FullMethod {
+	case reservationv1.ReservationService_CreateOrder_FullMethodName,
+		reservationv1.ReservationService_UpdateSeats_FullMethodName,
+		reservationv1.ReservationService_SubmitPayment_FullMethodName:
+		budget = l.limits.Mutations
+	case reservationv1.ReservationService_GetStatus_FullMethodName,
+		reservationv1.ReservationService_GetFlightAvailability_FullMethodName:
+		budget = l.limits.Availability
+	}
+	if ok, retryAfter := budget.Allow(auth.Caller(ctx)); !ok {
+		return nil, rateLimitedError("Too many requests, slow down", retryAfter)
+	}
+	return handler(ctx, req)
+}
+
+func (l rateLimiter) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if info.FullMethod != reservationv1.ReservationService_WatchOrder_FullMethodName {
+		return handler(srv, ss)
+	}
+	release, ok := l.limits.Streams.Acquire(auth.Caller(ss.Context()))
+	if !ok {
+		return rateLimitedError("Too many open streams, close one first", streamRetryAfter)
+	}
+	defer release()
+	return handler(srv, ss)
+}
+
+// rateLimitedError is a ResourceExhausted status carrying a RetryInfo detail,
+// the gRPC counterpart of the HTTP API's Retry-After header.
+func rateLimitedError(detail string, retryAfter time.Duration) error {
+	st := status.Convert(newStatusError(domain.CodeRateLimited, detail, ""))
+	if withRetry, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = withRetry
+	}
+	return st.Err()
+}
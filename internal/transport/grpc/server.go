@@ -0,0 +1,222 @@
+package grpc
+
+import (
+	"context"
+	"log"
+	"regexp"
+
+	reservationv1 "github.com/EyalShahaf/temporal-seats/api/reservation/v1"
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// These mirror the request schemas of the HTTP API's OpenAPI document.
+var (
+	seatIDPattern      = regexp.MustCompile(`^[1-9][0-9]?[A-F]$`)
+	paymentCodePattern = regexp.MustCompile(`^[0-9]{5}$`)
+)
+
+// NewServer creates a gRPC server serving the ReservationService, with server
+// reflection enabled so that tools like grpcurl can discover it. authn
+// authenticates calls; nil disables authentication. Calls are rate limited
+// per caller with limits, the same budgets as the HTTP API's.
+func NewServer(svc *orders.Service, limits ratelimit.Limits, authn auth.Authenticator) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{clientIPUnary}
+	stream := []grpc.StreamServerInterceptor{clientIPStream}
+	if authn != nil {
+		a := authenticator{authn: authn}
+		unary = append(unary, a.unary)
+		stream = append(stream, a.stream)
+	}
+	limiter := rateLimiter{limits: limits}
+	unary = append(unary, limiter.unary)
+	stream = append(stream, limiter.stream)
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	reservationv1.RegisterReservationServiceServer(server, NewReservationServer(svc))
+	reflection.Register(server)
+	return server
+}
+
+// ReservationServer implements the ReservationService on the same Temporal
+// calls as the HTTP OrderHandler.
+type ReservationServer struct {
+	reservationv1.UnimplementedReservationServiceServer
+
+	orders *orders.Service
+}
+
+// NewReservationServer creates a new ReservationServer on svc.
+func NewReservationServer(svc *orders.Service) *ReservationServer {
+	return &ReservationServer{orders: svc}
+}
+
+func (s *ReservationServer) CreateOrder(ctx context.Context, req *reservationv1.CreateOrderRequest) (*reservationv1.CreateOrderResponse, error) {
+	if req.FlightId == "" {
+		return nil, invalidArgument("flight_id is required", req.OrderId)
+	}
+	if req.AssignSeats < 0 {
+		return nil, invalidArgument("assign_seats must not be negative", req.OrderId)
+	}
+
+	// Generate a sortable ID unless the client supplied its own.
+	orderID := req.OrderId
+	if orderID == "" {
+		orderID = orders.NewOrderID()
+	} else if !orders.ValidOrderID(orderID) {
+		return nil, newStatusError(domain.CodeInvalidOrderID, "Invalid order ID", orderID)
+	}
+
+	log.Println("gRPC CreateOrder with OrderID:", orderID)
+
+	we, err := s.orders.Create(ctx, domain.CreateOrderRequest{
+		FlightID:    req.FlightId,
+		OrderID:     orderID,
+		TicketID:    req.TicketId,
+		Waitlist:    req.Waitlist,
+		AssignSeats: int(req.AssignSeats),
+	})
+	if err != nil {
+		if code := orders.ErrorCode(err); code == domain.CodeInternal || code == domain.CodeServiceUnavailable {
+			log.Printf("Failed to start workflow: %v", err)
+		}
+		return nil, temporalStatus(err, orderID, "Failed to start order process")
+	}
+
+	log.Printf("Started workflow. WorkflowID=%s, RunID=%s\n", we.GetID(), we.GetRunID())
+	return &reservationv1.CreateOrderResponse{OrderId: orderID}, nil
+}
+
+func (s *ReservationServer) UpdateSeats(ctx context.Context, req *reservationv1.UpdateSeatsRequest) (*reservationv1.UpdateSeatsResponse, error) {
+	if err := validateOrderID(req.OrderId); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(req.Seats))
+	for _, seatID := range req.Seats {
+		if !seatIDPattern.MatchString(seatID) {
+			return nil, invalidArgument("Invalid seat ID "+seatID, req.OrderId)
+		}
+		if seen[seatID] {
+			return nil, invalidArgument("Duplicate seat ID "+seatID, req.OrderId)
+		}
+		seen[seatID] = true
+	}
+
+	if err := s.orders.UpdateSeats(ctx, req.OrderId, req.Seats); err != nil {
+		log.Printf("Failed to signal workflow: %v", err)
+		return nil, temporalStatus(err, req.OrderId, "Failed to update seats")
+	}
+	return &reservationv1.UpdateSeatsResponse{}, nil
+}
+
+func (s *ReservationServer) SubmitPayment(ctx context.Context, req *reservationv1.SubmitPaymentRequest) (*reservationv1.SubmitPaymentResponse, error) {
+	if err := validateOrderID(req.OrderId); err != nil {
+		return nil, err
+	}
+	if !paymentCodePattern.MatchString(req.Code) {
+		return nil, invalidArgument("Payment code must be 5 digits", req.OrderId)
+	}
+
+	if err := s.orders.SubmitPayment(ctx, req.OrderId, req.Code); err != nil {
+		log.Printf("Failed to signal workflow: %v", err)
+		return nil, temporalStatus(err, req.OrderId, "Failed to submit payment")
+	}
+	return &reservationv1.SubmitPaymentResponse{}, nil
+}
+
+func (s *ReservationServer) GetStatus(ctx context.Context, req *reservationv1.GetStatusRequest) (*reservationv1.OrderStatus, error) {
+	if err := validateOrderID(req.OrderId); err != nil {
+		return nil, err
+	}
+
+	state, err := s.orders.Status(ctx, req.OrderId)
+	if err != nil {
+		return nil, temporalStatus(err, req.OrderId, "Failed to get order status")
+	}
+	return toOrderStatus(req.OrderId, state), nil
+}
+
+// WatchOrder streams the order state every time its version changes, until the
+// client cancels the call or the order can no longer be queried.
+func (s *ReservationServer) WatchOrder(req *reservationv1.WatchOrderRequest, stream grpc.ServerStreamingServer[reservationv1.OrderStatus]) error {
+	if err := validateOrderID(req.OrderId); err != nil {
+		return err
+	}
+
+	if err := s.orders.Authorize(stream.Context(), req.OrderId); err != nil {
+		return temporalStatus(err, req.OrderId, "Failed to get order status")
+	}
+
+	sub := s.orders.WatchOrder(req.OrderId)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case u, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			if u.Err != nil {
+				return temporalStatus(u.Err, req.OrderId, "Failed to get order status")
+			}
+			if u.Version <= req.SinceVersion {
+				continue
+			}
+			if err := stream.Send(toOrderStatus(req.OrderId, u.Value.(workflows.OrderState))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *ReservationServer) GetFlightAvailability(ctx context.Context, req *reservationv1.GetFlightAvailabilityRequest) (*reservationv1.FlightAvailability, error) {
+	if req.FlightId == "" {
+		return nil, invalidArgument("flight_id is required", "")
+	}
+
+	avail := s.orders.Availability(ctx, req.FlightId)
+	return &reservationv1.FlightAvailability{
+		FlightId:  avail.FlightID,
+		Available: avail.Available,
+		Held:      avail.Held,
+		Confirmed: avail.Confirmed,
+		Blocked:   avail.Blocked,
+		Total:     int32(avail.Total),
+	}, nil
+}
+
+func validateOrderID(orderID string) error {
+	if orderID == "" {
+		return invalidArgument("order_id is required", "")
+	}
+	if !orders.ValidOrderID(orderID) {
+		return newStatusError(domain.CodeInvalidOrderID, "Invalid order ID", orderID)
+	}
+	return nil
+}
+
+// toOrderStatus maps the workflow state to the OrderStatus message.
+func toOrderStatus(orderID string, state workflows.OrderState) *reservationv1.OrderStatus {
+	status := &reservationv1.OrderStatus{
+		OrderId:       orderID,
+		State:         state.State,
+		Seats:         state.Seats,
+		AttemptsLeft:  int32(state.AttemptsLeft),
+		LastError:     state.LastPaymentErr,
+		PaymentStatus: state.PaymentStatus,
+		Version:       state.Version,
+		SeatsError:    state.SeatsError,
+		Waitlisted:    state.Waitlisted,
+	}
+	if !state.HoldExpiresAt.IsZero() {
+		status.HoldExpiresAt = timestamppb.New(state.HoldExpiresAt)
+	}
+	return status
+}
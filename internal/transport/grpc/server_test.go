@@ -0,0 +1,393 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	reservationv1 "github.com/EyalShahaf/temporal-seats/api/reservation/v1"
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// MockTemporalClient is a mock for the Temporal client.
+type MockTemporalClient struct {
+	client.Client
+	mock.Mock
+}
+
+func (m *MockTemporalClient) ExecuteWorkflow(ctx context.Context, options client.StartWorkflowOptions, workflow interface{}, args ...interface{}) (client.WorkflowRun, error) {
+	ret := m.Called(ctx, options, workflow, args)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(client.WorkflowRun), ret.Error(1)
+}
+
+func (m *MockTemporalClient) SignalWorkflow(ctx context.Context, workflowID string, runID string, signalName string, arg interface{}) error {
+	return m.Called(ctx, workflowID, runID, signalName, arg).Error(0)
+}
+
+func (m *MockTemporalClient) QueryWorkflow(ctx context.Context, workflowID string, runID string, queryType string, args ...interface{}) (converter.EncodedValue, error) {
+	ret := m.Called(ctx, workflowID, runID, queryType)
+	var value converter.EncodedValue
+	if v := ret.Get(0); v != nil {
+		value = v.(converter.EncodedValue)
+	}
+	return value, ret.Error(1)
+}
+
+type MockWorkflowRun struct {
+	client.WorkflowRun
+}
+
+func (m *MockWorkflowRun) GetID() string    { return "mock-workflow-id" }
+func (m *MockWorkflowRun) GetRunID() string { return "mock-run-id" }
+
+type mockEncodedValue struct {
+	value interface{}
+}
+
+func (m mockEncodedValue) HasValue() bool { return m.value != nil }
+
+func (m mockEncodedValue) Get(valuePtr interface{}) error {
+	b, err := json.Marshal(m.value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, valuePtr)
+}
+
+// newTestClient serves the ReservationService over an in-memory connection.
+func newTestClient(t *testing.T, temporal client.Client) reservationv1.ReservationServiceClient {
+	return newAuthTestClient(t, temporal, nil)
+}
+
+// newAuthTestClient is newTestClient with authentication enabled.
+func newAuthTestClient(t *testing.T, temporal client.Client, authn auth.Authenticator) reservationv1.ReservationServiceClient {
+	cfg := config.Load()
+	cfg.SSETick = 10 * time.Millisecond
+	cfg.Limits = config.LimitsConfig{}
+	return newConfigTestClient(t, cfg, temporal, authn)
+}
+
+// newConfigTestClient serves the ReservationService configured by cfg.
+func newConfigTestClient(t *testing.T, cfg config.Config, temporal client.Client, authn auth.Authenticator) reservationv1.ReservationServiceClient {
+	t.Helper()
+	return newServiceTestClient(t, orders.NewService(temporal, cfg), ratelimit.New(cfg.RateLimit), authn)
+}
+
+// newServiceTestClient serves the ReservationService on svc and limits.
+func newServiceTestClient(t *testing.T, svc *orders.Service, limits ratelimit.Limits, authn auth.Authenticator) reservationv1.ReservationServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := NewServer(svc, limits, authn)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return reservationv1.NewReservationServiceClient(conn)
+}
+
+// requireErrorCode asserts that err is a gRPC status with the given code and domain error code.
+func requireErrorCode(t *testing.T, err error, grpcCode codes.Code, code string) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	require.True(t, ok, "not a gRPC status: %v", err)
+	require.Equal(t, grpcCode, st.Code())
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			require.Equal(t, code, info.Reason)
+			return
+		}
+	}
+	t.Fatalf("no ErrorInfo detail in %v", st)
+}
+
+func TestReservationServer_CreateOrder_GeneratesID(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(o client.StartWorkflowOptions) bool {
+			return len(o.ID) > len("order::") && o.TaskQueue == "order-tq"
+		}), mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	resp, err := c.CreateOrder(context.Background(), &reservationv1.CreateOrderRequest{FlightId: "FL-1"})
+	require.NoError(t, err)
+	require.Len(t, resp.OrderId, 26) // ULID
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestReservationServer_CreateOrder_AssignsSeats(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Limits = config.LimitsConfig{MaxSeatsPerOrder: 4}
+	c := newConfigTestClient(t, cfg, mockTemporal, nil)
+
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) == 1 && args[0].(workflows.OrderInput).AssignSeats == 3
+		})).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	_, err := c.CreateOrder(context.Background(), &reservationv1.CreateOrderRequest{FlightId: "FL-1", OrderId: "o-1", AssignSeats: 3})
+	require.NoError(t, err)
+
+	_, err = c.CreateOrder(context.Background(), &reservationv1.CreateOrderRequest{FlightId: "FL-1", OrderId: "o-2", AssignSeats: 5})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeTooManySeats)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestReservationServer_CreateOrder_Duplicate(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, serviceerror.NewWorkflowExecutionAlreadyStarted("already started", "", "")).
+		Once()
+
+	_, err := c.CreateOrder(context.Background(), &reservationv1.CreateOrderRequest{FlightId: "FL-1", OrderId: "o-1"})
+	requireErrorCode(t, err, codes.AlreadyExists, domain.CodeOrderAlreadyExists)
+}
+
+func TestReservationServer_RejectsInvalidRequests(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+	ctx := context.Background()
+
+	_, err := c.CreateOrder(ctx, &reservationv1.CreateOrderRequest{OrderId: "o-1"})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeInvalidRequest)
+
+	_, err = c.CreateOrder(ctx, &reservationv1.CreateOrderRequest{FlightId: "FL-1", OrderId: "bad::id"})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeInvalidOrderID)
+
+	_, err = c.CreateOrder(ctx, &reservationv1.CreateOrderRequest{FlightId: "FL-1", OrderId: "o-1", AssignSeats: -1})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeInvalidRequest)
+
+	_, err = c.UpdateSeats(ctx, &reservationv1.UpdateSeatsRequest{OrderId: "o-1", Seats: []string{"1A", "Z9"}})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeInvalidRequest)
+
+	_, err = c.UpdateSeats(ctx, &reservationv1.UpdateSeatsRequest{OrderId: "o-1", Seats: []string{"1A", "1A"}})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeInvalidRequest)
+
+	_, err = c.SubmitPayment(ctx, &reservationv1.SubmitPaymentRequest{OrderId: "o-1", Code: "1234"})
+	requireErrorCode(t, err, codes.InvalidArgument, domain.CodeInvalidRequest)
+
+	mockTemporal.AssertNotCalled(t, "ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockTemporal.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReservationServer_UpdateSeatsAndPayment(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+	ctx := context.Background()
+
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.UpdateSeatsSignal, []string{"1A", "1B"}).
+		Return(nil).
+		Once()
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.SubmitPaymentSignal, "12345").
+		Return(nil).
+		Once()
+
+	_, err := c.UpdateSeats(ctx, &reservationv1.UpdateSeatsRequest{OrderId: "o-1", Seats: []string{"1A", "1B"}})
+	require.NoError(t, err)
+	_, err = c.SubmitPayment(ctx, &reservationv1.SubmitPaymentRequest{OrderId: "o-1", Code: "12345"})
+	require.NoError(t, err)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestReservationServer_GetStatus(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+	ctx := context.Background()
+
+	expiresAt := time.Date(2025, 10, 4, 18, 45, 0, 0, time.UTC)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{
+			State: "SEATS_SELECTED", Seats: []string{"1A"}, HoldExpiresAt: expiresAt, AttemptsLeft: 3, Version: 2,
+		}}, nil).
+		Once()
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::missing", "", workflows.GetStatusQuery).
+		Return(nil, serviceerror.NewNotFound("workflow not found")).
+		Once()
+
+	resp, err := c.GetStatus(ctx, &reservationv1.GetStatusRequest{OrderId: "o-1"})
+	require.NoError(t, err)
+	require.Equal(t, "SEATS_SELECTED", resp.State)
+	require.Equal(t, []string{"1A"}, resp.Seats)
+	require.Equal(t, expiresAt, resp.HoldExpiresAt.AsTime())
+	require.EqualValues(t, 3, resp.AttemptsLeft)
+	require.EqualValues(t, 2, resp.Version)
+
+	_, err = c.GetStatus(ctx, &reservationv1.GetStatusRequest{OrderId: "missing"})
+	requireErrorCode(t, err, codes.NotFound, domain.CodeOrderNotFound)
+}
+
+func TestReservationServer_WatchOrder(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1}}, nil).
+		Once()
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "SEATS_SELECTED", Seats: []string{"1A"}, Version: 2}}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := c.WatchOrder(ctx, &reservationv1.WatchOrderRequest{OrderId: "o-1"})
+	require.NoError(t, err)
+
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "PENDING", first.State)
+	require.EqualValues(t, 1, first.Version)
+
+	// Unchanged versions are not re-sent.
+	second, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "SEATS_SELECTED", second.State)
+	require.EqualValues(t, 2, second.Version)
+}
+
+func TestReservationServer_WatchOrder_NotFound(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newTestClient(t, mockTemporal)
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::missing", "", workflows.GetStatusQuery).
+		Return(nil, serviceerror.NewNotFound("workflow not found"))
+
+	stream, err := c.WatchOrder(context.Background(), &reservationv1.WatchOrderRequest{OrderId: "missing"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	requireErrorCode(t, err, codes.NotFound, domain.CodeOrderNotFound)
+}
+
+func TestReservationServer_Authentication(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	c := newAuthTestClient(t, mockTemporal, auth.APIKeys{"key-a": "partner-a", "key-b": "partner-b"})
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1, Owner: "apikey:partner-a"}}, nil)
+
+	_, err := c.GetStatus(context.Background(), &reservationv1.GetStatusRequest{OrderId: "o-1"})
+	requireErrorCode(t, err, codes.Unauthenticated, domain.CodeUnauthorized)
+
+	asB := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "key-b")
+	_, err = c.GetStatus(asB, &reservationv1.GetStatusRequest{OrderId: "o-1"})
+	requireErrorCode(t, err, codes.PermissionDenied, domain.CodeForbidden)
+
+	_, err = c.UpdateSeats(asB, &reservationv1.UpdateSeatsRequest{OrderId: "o-1", Seats: []string{"1A"}})
+	requireErrorCode(t, err, codes.PermissionDenied, domain.CodeForbidden)
+
+	stream, err := c.WatchOrder(asB, &reservationv1.WatchOrderRequest{OrderId: "o-1"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	requireErrorCode(t, err, codes.PermissionDenied, domain.CodeForbidden)
+
+	asA := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "key-a")
+	resp, err := c.GetStatus(asA, &reservationv1.GetStatusRequest{OrderId: "o-1"})
+	require.NoError(t, err)
+	require.Equal(t, "PENDING", resp.State)
+	mockTemporal.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReservationServer_SharesRateLimits(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	limits := ratelimit.New(config.RateLimitConfig{MutationsPerMinute: 1, MutationBurst: 2})
+	c := newServiceTestClient(t, orders.NewService(mockTemporal, cfg), limits, auth.APIKeys{"key-a": "partner-a"})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "key-a")
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "SEATS_SELECTED", Version: 1, Owner: "apikey:partner-a"}}, nil)
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.SubmitPaymentSignal, "12345").
+		Return(nil).
+		Once()
+
+	// The other transports spent the caller's first token of the same budget.
+	ok, _ := limits.Mutations.Allow("apikey:partner-a")
+	require.True(t, ok)
+	_, err := c.SubmitPayment(ctx, &reservationv1.SubmitPaymentRequest{OrderId: "o-1", Code: "12345"})
+	require.NoError(t, err)
+	_, err = c.SubmitPayment(ctx, &reservationv1.SubmitPaymentRequest{OrderId: "o-1", Code: "12345"})
+	requireErrorCode(t, err, codes.ResourceExhausted, domain.CodeRateLimited)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestReservationServer_RateLimits(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Limits = config.LimitsConfig{}
+	cfg.RateLimit = config.RateLimitConfig{MutationsPerMinute: 1, MutationBurst: 1}
+	c := newConfigTestClient(t, cfg, mockTemporal, nil)
+	ctx := context.Background()
+
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.SubmitPaymentSignal, "12345").
+		Return(nil).
+		Once()
+
+	_, err := c.SubmitPayment(ctx, &reservationv1.SubmitPaymentRequest{OrderId: "o-1", Code: "12345"})
+	require.NoError(t, err)
+
+	_, err = c.SubmitPayment(ctx, &reservationv1.SubmitPaymentRequest{OrderId: "o-1", Code: "12345"})
+	requireErrorCode(t, err, codes.ResourceExhausted, domain.CodeRateLimited)
+	var retryDelay time.Duration
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			retryDelay = info.RetryDelay.AsDuration()
+		}
+	}
+	require.InDelta(t, time.Minute, retryDelay, float64(time.Second))
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestReservationServer_CreateOrder_RequiresTicket(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.WaitingRoom = config.WaitingRoomConfig{Flights: []string{"FL-1"}, AdmitPerMinute: 60, AdmissionTTL: time.Minute}
+	c := newConfigTestClient(t, cfg, mockTemporal, nil)
+
+	_, err := c.CreateOrder(context.Background(), &reservationv1.CreateOrderRequest{FlightId: "FL-1", OrderId: "o-1"})
+	requireErrorCode(t, err, codes.FailedPrecondition, domain.CodeTicketRequired)
+	mockTemporal.AssertNotCalled(t, "ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+)
+
+// seatProblem maps an error of a seat operation to a problem about the flight.
+func seatProblem(err error, flightID, detail string) domain.Problem {
+	problem := temporalProblem(err, "", detail)
+	problem.FlightID = flightID
+	return problem
+}
+
+// blockSeatHandler takes a seat out of sale for an operational reason.
+func (h *OrderHandler) blockSeatHandler(w http.ResponseWriter, r *http.Request) {
+	flightID, seatID := r.PathValue("flightID"), r.PathValue("seatID")
+
+	var req domain.BlockSeatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem := newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Invalid request body")
+		problem.FlightID = flightID
+		writeProblem(w, r, problem)
+		return
+	}
+
+	log.Printf("Handler called: blockSeatHandler for seat %s of flight %s, reason %q\n", seatID, flightID, req.Reason)
+
+	if err := h.orders.BlockSeat(r.Context(), flightID, seatID, req); err != nil {
+		problem := seatProblem(err, flightID, "Failed to block seat")
+		if problem.Status >= http.StatusInternalServerError {
+			log.Printf("Failed to block seat: %v", err)
+		}
+		writeProblem(w, r, problem)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// unblockSeatHandler puts a blocked seat back on sale.
+func (h *OrderHandler) unblockSeatHandler(w http.ResponseWriter, r *http.Request) {
+	flightID, seatID := r.PathValue("flightID"), r.PathValue("seatID")
+	log.Printf("Handler called: unblockSeatHandler for seat %s of flight %s\n", seatID, flightID)
+
+	if err := h.orders.UnblockSeat(r.Context(), flightID, seatID); err != nil {
+		problem := seatProblem(err, flightID, "Failed to unblock seat")
+		if problem.Status >= http.StatusInternalServerError {
+			log.Printf("Failed to unblock seat: %v", err)
+		}
+		writeProblem(w, r, problem)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// listBlockedSeatsHandler lists the blocked seats of a flight with their reasons.
+func (h *OrderHandler) listBlockedSeatsHandler(w http.ResponseWriter, r *http.Request) {
+	flightID := r.PathValue("flightID")
+
+	blocks, err := h.orders.BlockedSeats(r.Context(), flightID)
+	if err != nil {
+		writeProblem(w, r, seatProblem(err, flightID, "Failed to list blocked seats"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blocks)
+}
+
+// getSeatHandler returns the full state of a seat, including the audit trail
+// of admin overrides.
+func (h *OrderHandler) getSeatHandler(w http.ResponseWriter, r *http.Request) {
+	flightID, seatID := r.PathValue("flightID"), r.PathValue("seatID")
+
+	details, err := h.orders.Seat(r.Context(), flightID, seatID)
+	if err != nil {
+		writeProblem(w, r, seatProblem(err, flightID, "Failed to get seat"))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// getSeatHistoryHandler returns the latest commands processed by a seat.
+func (h *OrderHandler) getSeatHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	flightID, seatID := r.PathValue("flightID"), r.PathValue("seatID")
+
+	history, err := h.orders.SeatHistory(r.Context(), flightID, seatID)
+	if err != nil {
+		writeProblem(w, r, seatProblem(err, flightID, "Failed to get seat history"))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// overrideSeatHandler serves an admin override of a seat with the given
+// orders.Service method.
+func (h *OrderHandler) overrideSeatHandler(override func(ctx context.Context, flightID, seatID string, req domain.SeatOverrideRequest) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flightID, seatID := r.PathValue("flightID"), r.PathValue("seatID")
+
+		var req domain.SeatOverrideRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			problem := newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Invalid request body")
+			problem.FlightID = flightID
+			writeProblem(w, r, problem)
+			return
+		}
+
+		log.Printf("Handler called: overrideSeatHandler %s for seat %s of flight %s, reason %q\n", r.URL.Path, seatID, flightID, req.Reason)
+
+		if err := override(r.Context(), flightID, seatID, req); err != nil {
+			problem := seatProblem(err, flightID, "Failed to override seat")
+			if problem.Status >= http.StatusInternalServerError {
+				log.Printf("Failed to override seat: %v", err)
+			}
+			writeProblem(w, r, problem)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+)
+
+// publicPaths are served without authentication.
+var publicPaths = map[string]bool{
+	"/health":       true,
+	"/openapi.json": true,
+}
+
+// authenticate rejects API requests without valid credentials with a 401
+// problem and stores the caller's principal in the request context, where the
+// order service uses it to record and check order ownership. A nil
+// authenticator disables authentication.
+func authenticate(authn auth.Authenticator, next http.Handler) http.Handler {
+	if authn == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p, err := authn.Authenticate(r.Context(), requestCredentials(r))
+		if err != nil {
+			detail := "Invalid credentials"
+			if errors.Is(err, auth.ErrNoCredentials) {
+				detail = "Credentials are required"
+			} else {
+				log.Printf("Rejected credentials for %s: %v", r.URL.Path, err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="temporal-seats"`)
+			writeProblem(w, r, newProblem(http.StatusUnauthorized, domain.CodeUnauthorized, detail))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// withClientIP stores the caller's network address in the request context,
+// identifying unauthenticated callers for per-customer limits. Forwarding
+// headers are not trusted, since any client can set them.
+func withClientIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithClientIP(r.Context(), host)))
+	})
+}
+
+// requestCredentials reads the API key (X-API-Key) and bearer token
+// (Authorization). Browsers cannot set headers on EventSource and WebSocket
+// connections, so GET requests may pass them as api_key/access_token instead.
+func requestCredentials(r *http.Request) auth.Credentials {
+	creds := auth.Credentials{APIKey: r.Header.Get("X-API-Key")}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		creds.BearerToken = strings.TrimSpace(token)
+	}
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		if creds.APIKey == "" {
+			creds.APIKey = q.Get("api_key")
+		}
+		if creds.BearerToken == "" {
+			creds.BearerToken = q.Get("access_token")
+		}
+	}
+	return creds
+}
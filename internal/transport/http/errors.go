@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+)
+
+// problemTypePrefix namespaces the RFC 7807 problem type URIs by error code.
+const problemTypePrefix = "urn:temporal-seats:problem:"
+
+// newProblem builds a problem for an HTTP status and stable error code.
+func newProblem(status int, code, detail string) domain.Problem {
+	return domain.Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// orderProblem is newProblem with the order the error relates to.
+func orderProblem(status int, code, detail, orderID string) domain.Problem {
+	p := newProblem(status, code, detail)
+	p.OrderID = orderID
+	return p
+}
+
+// writeProblem writes p as an application/problem+json response.
+func writeProblem(w http.ResponseWriter, r *http.Request, p domain.Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// ticketStatuses maps the codes of orders.TicketError to HTTP statuses.
+var ticketStatuses = map[string]int{
+	domain.CodeInvalidRequest:    http.StatusBadRequest,
+	domain.CodeForbidden:         http.StatusForbidden,
+	domain.CodeTicketRequired:    http.StatusForbidden,
+	domain.CodeTicketNotFound:    http.StatusNotFound,
+	domain.CodeTicketNotAdmitted: http.StatusConflict,
+}
+
+// seatStatuses maps the codes of orders.SeatError to HTTP statuses.
+var seatStatuses = map[string]int{
+	domain.CodeInvalidRequest: http.StatusBadRequest,
+	domain.CodeSeatNotFound:   http.StatusNotFound,
+	domain.CodeConflict:       http.StatusConflict,
+}
+
+// temporalProblem maps an error from the Temporal client to a problem. Errors
+// without a more specific mapping become a 500 with the given detail.
+func temporalProblem(err error, orderID, detail string) domain.Problem {
+	// Waiting room ticket errors carry their own detail, including when forbidden.
+	var ticketErr *orders.TicketError
+	if errors.As(err, &ticketErr) {
+		return orderProblem(ticketStatuses[ticketErr.Code], ticketErr.Code, ticketErr.Detail, orderID)
+	}
+	var seatErr *orders.SeatError
+	if errors.As(err, &seatErr) {
+		return orderProblem(seatStatuses[seatErr.Code], seatErr.Code, seatErr.Detail, orderID)
+	}
+	if errors.Is(err, orders.ErrAdminRequired) {
+		return orderProblem(http.StatusForbidden, domain.CodeForbidden, "Admin access required", orderID)
+	}
+
+	switch code := orders.ErrorCode(err); code {
+	case domain.CodeForbidden:
+		return orderProblem(http.StatusForbidden, code, "Order belongs to another caller", orderID)
+	case domain.CodeOrderNotFound:
+		return orderProblem(http.StatusNotFound, code, "Order not found", orderID)
+	case domain.CodeOrderAlreadyExists:
+		return orderProblem(http.StatusConflict, code, "Order already exists", orderID)
+	case domain.CodeConflict:
+		return orderProblem(http.StatusConflict, code, detail, orderID)
+	case domain.CodeTooManySeats:
+		return orderProblem(http.StatusUnprocessableEntity, code, err.Error(), orderID)
+	case domain.CodeHoldLimitExceeded:
+		return orderProblem(http.StatusConflict, code, err.Error(), orderID)
+	case domain.CodePreconditionFailed:
+		return orderProblem(http.StatusPreconditionFailed, code, detail, orderID)
+	case domain.CodeServiceUnavailable:
+		return orderProblem(http.StatusServiceUnavailable, code, "Temporal is unavailable, try again later", orderID)
+	default:
+		return orderProblem(http.StatusInternalServerError, domain.CodeInternal, detail, orderID)
+	}
+}
@@ -1,61 +1,102 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/EyalShahaf/temporal-seats/internal/config"
 	"github.com/EyalShahaf/temporal-seats/internal/domain"
-	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
-	"github.com/EyalShahaf/temporal-seats/internal/workflows"
-	"go.temporal.io/api/serviceerror"
-	"go.temporal.io/sdk/client"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
 )
 
 // OrderHandler holds dependencies for the order API handlers.
 type OrderHandler struct {
-	temporal client.Client
+	cfg    config.Config
+	orders *orders.Service
+	spec   *apiSpec
+	limits ratelimit.Limits
 }
 
-// NewOrderHandler creates a new OrderHandler with its dependencies.
-func NewOrderHandler(temporal client.Client) *OrderHandler {
-	return &OrderHandler{temporal: temporal}
+// NewOrderHandler creates a new OrderHandler with its dependencies. svc and
+// limits are shared with the other transports, so that a caller has one budget
+// and every transport's changes reach every watcher.
+func NewOrderHandler(cfg config.Config, svc *orders.Service, limits ratelimit.Limits) *OrderHandler {
+	return &OrderHandler{
+		cfg:    cfg,
+		orders: svc,
+		spec:   mustLoadAPISpec(),
+		limits: limits,
+	}
 }
 
 func (h *OrderHandler) attachOrderRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /orders", h.createOrderHandler)
-	mux.HandleFunc("POST /orders/{id}/seats", h.updateSeatsHandler)
-	mux.HandleFunc("POST /orders/{id}/payment", h.submitPaymentHandler)
-	mux.HandleFunc("GET /orders/{id}/status", h.getStatusHandler)
-	mux.HandleFunc("GET /orders/{id}/events", h.sseHandler)
-	mux.HandleFunc("GET /flights/{flightID}/available-seats", h.getAvailableSeatsHandler)
+	// The unversioned routes keep their original payload shapes; /v1 serves the domain DTOs.
+	h.attachVersionedRoutes(mux, "", apiLegacy)
+	h.attachVersionedRoutes(mux, "/v1", apiV1)
+}
+
+func (h *OrderHandler) attachVersionedRoutes(mux *http.ServeMux, prefix string, version apiVersion) {
+	handle := func(method, path string, handler http.HandlerFunc) {
+		mux.HandleFunc(method+" "+prefix+path, withAPIVersion(version, handler))
+	}
+	handle("POST", "/orders", rateLimited(h.limits.Mutations, h.createOrderHandler))
+	handle("POST", "/orders/{id}/seats", rateLimited(h.limits.Mutations, h.updateSeatsHandler))
+	handle("POST", "/orders/{id}/payment", rateLimited(h.limits.Mutations, h.submitPaymentHandler))
+	handle("GET", "/orders/{id}/status", rateLimited(h.limits.Availability, longPollLimited(h.limits.Streams, h.getStatusHandler)))
+	handle("GET", "/orders/{id}/timeline", rateLimited(h.limits.Availability, h.getTimelineHandler))
+	handle("GET", "/orders/{id}/events", streamLimited(h.limits.Streams, h.sseHandler))
+	handle("GET", "/flights/{flightID}/available-seats", rateLimited(h.limits.Availability, h.getAvailableSeatsHandler))
+	handle("GET", "/flights/{flightID}/seats/{seatID}/history", rateLimited(h.limits.Availability, h.getSeatHistoryHandler))
+	handle("POST", "/flights/{flightID}/queue", rateLimited(h.limits.Mutations, h.joinQueueHandler))
+	handle("GET", "/flights/{flightID}/queue/{ticketID}", rateLimited(h.limits.Availability, h.getTicketHandler))
+	handle("GET", "/flights/{flightID}/queue/{ticketID}/events", streamLimited(h.limits.Streams, h.ticketEventsHandler))
+	handle("GET", "/admin/flights/{flightID}/blocked-seats", rateLimited(h.limits.Availability, h.listBlockedSeatsHandler))
+	handle("PUT", "/admin/flights/{flightID}/seats/{seatID}/block", rateLimited(h.limits.Mutations, h.blockSeatHandler))
+	handle("DELETE", "/admin/flights/{flightID}/seats/{seatID}/block", rateLimited(h.limits.Mutations, h.unblockSeatHandler))
+	handle("GET", "/admin/flights/{flightID}/seats/{seatID}", rateLimited(h.limits.Availability, h.getSeatHandler))
+	handle("POST", "/admin/flights/{flightID}/seats/{seatID}/force-release", rateLimited(h.limits.Mutations, h.overrideSeatHandler(h.orders.ForceReleaseSeat)))
+	handle("POST", "/admin/flights/{flightID}/seats/{seatID}/force-unconfirm", rateLimited(h.limits.Mutations, h.overrideSeatHandler(h.orders.ForceUnconfirmSeat)))
+	handle("POST", "/admin/flights/{flightID}/seats/{seatID}/reassign", rateLimited(h.limits.Mutations, h.overrideSeatHandler(h.orders.ReassignSeat)))
+	handle("GET", "/ws", streamLimited(h.limits.Streams, h.wsHandler))
 }
 
 func (h *OrderHandler) createOrderHandler(w http.ResponseWriter, r *http.Request) {
 	var req domain.CreateOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Invalid request body"))
 		return
 	}
-
-	log.Println("Handler called: createOrderHandler with OrderID:", req.OrderID)
-
-	opts := client.StartWorkflowOptions{
-		ID:        "order::" + req.OrderID,
-		TaskQueue: "order-tq",
+	if req.AssignSeats < 0 {
+		writeProblem(w, r, newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "assignSeats must not be negative"))
+		return
 	}
-	input := workflows.OrderInput{
-		OrderID:  req.OrderID,
-		FlightID: req.FlightID,
+
+	// Generate a sortable ID unless the client supplied its own.
+	if req.OrderID == "" {
+		req.OrderID = orders.NewOrderID()
+	} else if !orders.ValidOrderID(req.OrderID) {
+		writeProblem(w, r, orderProblem(http.StatusBadRequest, domain.CodeInvalidOrderID, "Invalid order ID", req.OrderID))
+		return
 	}
 
-	we, err := h.temporal.ExecuteWorkflow(r.Context(), opts, workflows.OrderOrchestrationWorkflow, input)
+	log.Println("Handler called: createOrderHandler with OrderID:", req.OrderID)
+
+	we, err := h.orders.Create(r.Context(), req)
 	if err != nil {
-		log.Printf("Failed to start workflow: %v", err)
-		http.Error(w, "Failed to start order process", http.StatusInternalServerError)
+		problem := temporalProblem(err, req.OrderID, "Failed to start order process")
+		problem.FlightID = req.FlightID
+		if problem.Code == domain.CodeOrderAlreadyExists {
+			problem.OrderStatus = h.existingOrderStatus(r.Context(), req.OrderID)
+		} else {
+			log.Printf("Failed to start workflow: %v", err)
+		}
+		writeProblem(w, r, problem)
 		return
 	}
 
@@ -67,25 +108,30 @@ func (h *OrderHandler) createOrderHandler(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(resp)
 }
 
+// existingOrderStatus returns the status of an already existing order, or nil if it cannot be queried.
+func (h *OrderHandler) existingOrderStatus(ctx context.Context, orderID string) *domain.GetStatusResponse {
+	state, err := h.orders.Status(ctx, orderID)
+	if err != nil {
+		return nil
+	}
+	status := toStatusResponse(state)
+	return &status
+}
+
 func (h *OrderHandler) updateSeatsHandler(w http.ResponseWriter, r *http.Request) {
 	orderID := r.PathValue("id")
-	workflowID := "order::" + orderID
 
 	var req domain.UpdateSeatsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, orderProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Invalid request body", orderID))
 		return
 	}
 
 	log.Printf("Handler called: updateSeatsHandler for order %s with seats %v\n", orderID, req.Seats)
 
-	// Seat entity workflows will be started automatically via SignalWithStart
-	// when the order workflow calls the SeatSignalActivity
-
-	err := h.temporal.SignalWorkflow(r.Context(), workflowID, "", workflows.UpdateSeatsSignal, req.Seats)
-	if err != nil {
+	if err := h.orders.UpdateSeats(r.Context(), orderID, req.Seats); err != nil {
 		log.Printf("Failed to signal workflow: %v", err)
-		http.Error(w, "Failed to update seats", http.StatusInternalServerError)
+		writeProblem(w, r, temporalProblem(err, orderID, "Failed to update seats"))
 		return
 	}
 
@@ -94,208 +140,126 @@ func (h *OrderHandler) updateSeatsHandler(w http.ResponseWriter, r *http.Request
 
 func (h *OrderHandler) submitPaymentHandler(w http.ResponseWriter, r *http.Request) {
 	orderID := r.PathValue("id")
-	workflowID := "order::" + orderID
 
 	var req domain.SubmitPaymentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, orderProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Invalid request body", orderID))
 		return
 	}
 
 	log.Printf("Handler called: submitPaymentHandler for order %s with payment code: %s\n", orderID, req.Code)
 
-	err := h.temporal.SignalWorkflow(r.Context(), workflowID, "", workflows.SubmitPaymentSignal, req.Code)
-	if err != nil {
-		var notFoundErr *serviceerror.NotFound
-		if errors.As(err, &notFoundErr) {
-			http.Error(w, "Order not found", http.StatusNotFound)
-			return
-		}
+	if err := h.orders.SubmitPayment(r.Context(), orderID, req.Code); err != nil {
 		log.Printf("Failed to signal workflow: %v", err)
-		http.Error(w, "Failed to submit payment", http.StatusInternalServerError)
+		writeProblem(w, r, temporalProblem(err, orderID, "Failed to submit payment"))
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// maxStatusWait caps the ?wait= long-poll duration of getStatusHandler.
+const maxStatusWait = 60 * time.Second
+
+// getStatusHandler returns the order state with an ETag derived from its version
+// and API version (see apiVersion.orderETag).
+// A request with a matching If-None-Match gets 304; with ?wait=30s it first blocks
+// until the order changes or the wait elapses.
 func (h *OrderHandler) getStatusHandler(w http.ResponseWriter, r *http.Request) {
 	orderID := r.PathValue("id")
 	log.Printf("Handler called: getStatusHandler for order %s\n", orderID)
 
-	workflowID := "order::" + orderID
-	resp, err := h.temporal.QueryWorkflow(r.Context(), workflowID, "", workflows.GetStatusQuery)
+	wait, err := parseWait(r.URL.Query().Get("wait"))
 	if err != nil {
-		var notFoundErr *serviceerror.NotFound
-		if errors.As(err, &notFoundErr) {
-			http.Error(w, "Order not found", http.StatusNotFound)
-			return
-		}
+		writeProblem(w, r, orderProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Invalid wait duration", orderID))
+		return
+	}
 
-		log.Printf("Failed to query workflow: %v", err)
-		http.Error(w, "Failed to get order status", http.StatusInternalServerError)
+	state, err := h.orders.Status(r.Context(), orderID)
+	if err != nil {
+		problem := temporalProblem(err, orderID, "Failed to get order status")
+		if problem.Status != http.StatusNotFound {
+			log.Printf("Failed to query workflow: %v", err)
+		}
+		writeProblem(w, r, problem)
 		return
 	}
 
-	var state workflows.OrderState
-	if err := resp.Get(&state); err != nil {
-		log.Printf("Failed to decode workflow state: %v", err)
-		http.Error(w, "Failed to get order status", http.StatusInternalServerError)
+	api := apiVersionOf(r)
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if wait > 0 && etagMatches(ifNoneMatch, api.orderETag(state.Version)) {
+		state = h.orders.WaitForChange(r.Context(), orderID, state, wait)
+	}
+
+	etag := api.orderETag(state.Version)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(ifNoneMatch, etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	// For now, we'll just return the raw workflow state.
-	// Later, we can map this to the domain.GetStatusResponse DTO.
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(state)
+	json.NewEncoder(w).Encode(api.orderState(state))
 }
 
-func (h *OrderHandler) sseHandler(w http.ResponseWriter, r *http.Request) {
+// getTimelineHandler returns what happened to an order, read from its workflow history.
+func (h *OrderHandler) getTimelineHandler(w http.ResponseWriter, r *http.Request) {
 	orderID := r.PathValue("id")
-	workflowID := "order::" + orderID
-	log.Printf("Handler called: sseHandler for order %s\n", orderID)
+	log.Printf("Handler called: getTimelineHandler for order %s\n", orderID)
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
+	timeline, err := h.orders.Timeline(r.Context(), orderID)
+	if err != nil {
+		problem := temporalProblem(err, orderID, "Failed to get order timeline")
+		if problem.Status >= http.StatusInternalServerError {
+			log.Printf("Failed to read order history: %v", err)
+		}
+		writeProblem(w, r, problem)
 		return
 	}
 
-	headersWritten := false
-
-	writeUpdate := func() bool {
-		resp, err := h.temporal.QueryWorkflow(r.Context(), workflowID, "", workflows.GetStatusQuery)
-		if err != nil {
-			var notFoundErr *serviceerror.NotFound
-			if errors.As(err, &notFoundErr) {
-				http.Error(w, "Order not found", http.StatusNotFound)
-				return false
-			}
-
-			// Don't log here as it will be noisy if workflow is not found yet, just stop streaming
-			return false
-		}
-
-		var state workflows.OrderState
-		if err := resp.Get(&state); err != nil {
-			log.Printf("Failed to decode workflow state for SSE: %v", err)
-			return true // try again next tick
-		}
-
-		if !headersWritten {
-			w.Header().Set("Content-Type", "text/event-stream")
-			w.Header().Set("Cache-Control", "no-cache")
-			w.Header().Set("Connection", "keep-alive")
-			headersWritten = true
-		}
-
-		jsonData, err := json.Marshal(state)
-		if err != nil {
-			log.Printf("Failed to marshal state for SSE: %v", err)
-			return true
-		}
-
-		if _, err := w.Write([]byte("data: ")); err != nil {
-			return false
-		}
-		if _, err := w.Write(jsonData); err != nil {
-			return false
-		}
-		if _, err := w.Write([]byte("\n\n")); err != nil {
-			return false
-		}
-
-		flusher.Flush()
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timeline)
+}
 
-		return true
+// parseWait parses the ?wait= long-poll duration, capped at maxStatusWait.
+func parseWait(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
 	}
-
-	if !writeUpdate() {
-		return
-	}
-
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-r.Context().Done():
-			log.Println("Client disconnected from SSE stream")
-			return
-		case <-ticker.C:
-			if !writeUpdate() {
-				return
-			}
-		}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, errors.New("invalid wait duration")
 	}
+	return min(d, maxStatusWait), nil
 }
 
-// generateAllSeats creates a list of all possible seat IDs for a flight
-func generateAllSeats() []string {
-	seats := []string{}
-	rows := []string{"1", "2", "3", "4", "5"}
-	cols := []string{"A", "B", "C", "D", "E", "F"}
-
-	for _, row := range rows {
-		for _, col := range cols {
-			seats = append(seats, row+col)
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using weak comparison as required for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
 		}
 	}
-	return seats
+	return false
 }
 
 // getAvailableSeatsHandler returns the availability status of all seats for a flight
 func (h *OrderHandler) getAvailableSeatsHandler(w http.ResponseWriter, r *http.Request) {
 	flightID := r.PathValue("flightID")
 	if flightID == "" {
-		http.Error(w, "Flight ID is required", http.StatusBadRequest)
+		writeProblem(w, r, newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, "Flight ID is required"))
 		return
 	}
 
 	log.Println("Handler called: getAvailableSeatsHandler for flight", flightID)
 
-	allSeats := generateAllSeats()
-	available := []string{}
-	held := []string{}
-	confirmed := []string{}
-
-	// Query each seat's state
-	for _, seatID := range allSeats {
-		wfID := fmt.Sprintf("seat::%s::%s", flightID, seatID)
-
-		// Try to query the seat workflow
-		resp, err := h.temporal.QueryWorkflow(r.Context(), wfID, "", "GetState")
-		if err != nil {
-			// If workflow doesn't exist, seat is available
-			available = append(available, seatID)
-			continue
-		}
-
-		var state seat.SeatState
-		if err := resp.Get(&state); err != nil {
-			// If we can't get state, assume available
-			available = append(available, seatID)
-			continue
-		}
-
-		// Categorize seat based on state
-		if state.IsConfirmed {
-			confirmed = append(confirmed, seatID)
-		} else if state.IsHeld {
-			held = append(held, seatID)
-		} else {
-			available = append(available, seatID)
-		}
-	}
-
-	response := map[string]interface{}{
-		"flightID":  flightID,
-		"available": available,
-		"held":      held,
-		"confirmed": confirmed,
-		"total":     len(allSeats),
-	}
-
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(apiVersionOf(r).flightAvailability(h.orders.Availability(r.Context(), flightID)))
 }
@@ -1,18 +1,39 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/config"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
+	"github.com/EyalShahaf/temporal-seats/internal/entities/waitingroom"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
 	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
 	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // MockTemporalClient is a mock for the Temporal client.
@@ -21,6 +42,16 @@ type MockTemporalClient struct {
 	mock.Mock
 }
 
+// newTestHandler creates an OrderHandler with its own service and rate limits.
+func newTestHandler(cfg config.Config, temporal client.Client) *OrderHandler {
+	return NewOrderHandler(cfg, orders.NewService(temporal, cfg), ratelimit.New(cfg.RateLimit))
+}
+
+// newTestRouter creates a router with its own service and rate limits.
+func newTestRouter(cfg config.Config, temporal client.Client, authn auth.Authenticator) http.Handler {
+	return NewRouter(cfg, orders.NewService(temporal, cfg), ratelimit.New(cfg.RateLimit), authn)
+}
+
 type flusherResponseRecorder struct {
 	*httptest.ResponseRecorder
 }
@@ -53,7 +84,7 @@ func (m *MockWorkflowRun) GetRunID() string {
 
 func TestOrderHandler_CreateOrder(t *testing.T) {
 	mockTemporal := new(MockTemporalClient)
-	handler := NewOrderHandler(mockTemporal)
+	handler := newTestHandler(config.Load(), mockTemporal)
 
 	// Define what the mock should expect and return
 	mockTemporal.
@@ -106,9 +137,67 @@ func (m *MockTemporalClient) QueryWorkflow(
 	return value, ret.Error(1)
 }
 
+func (m *MockTemporalClient) SignalWithStartWorkflow(
+	ctx context.Context,
+	workflowID string,
+	signalName string,
+	signalArg interface{},
+	options client.StartWorkflowOptions,
+	workflow interface{},
+	workflowArgs ...interface{},
+) (client.WorkflowRun, error) {
+	ret := m.Called(ctx, workflowID, signalName, signalArg, options, workflow, workflowArgs)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(client.WorkflowRun), ret.Error(1)
+}
+
+func (m *MockTemporalClient) UpdateWorkflow(ctx context.Context, options client.UpdateWorkflowOptions) (client.WorkflowUpdateHandle, error) {
+	ret := m.Called(ctx, options)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(client.WorkflowUpdateHandle), ret.Error(1)
+}
+
+func (m *MockTemporalClient) GetWorkflowHistory(ctx context.Context, workflowID string, runID string, isLongPoll bool, filterType enumspb.HistoryEventFilterType) client.HistoryEventIterator {
+	return m.Called(ctx, workflowID, runID, isLongPoll, filterType).Get(0).(client.HistoryEventIterator)
+}
+
+// mockHistoryIterator iterates over a fixed workflow history.
+type mockHistoryIterator struct {
+	events []*historypb.HistoryEvent
+}
+
+func (it *mockHistoryIterator) HasNext() bool { return len(it.events) > 0 }
+
+func (it *mockHistoryIterator) Next() (*historypb.HistoryEvent, error) {
+	event := it.events[0]
+	it.events = it.events[1:]
+	return event, nil
+}
+
+// mockUpdateHandle is a completed update whose outcome is err.
+type mockUpdateHandle struct {
+	client.WorkflowUpdateHandle
+	err error
+}
+
+func (h mockUpdateHandle) Get(ctx context.Context, valuePtr interface{}) error {
+	return h.err
+}
+
+// withoutHoldLimits is cfg with the hold limits disabled, so that seat updates
+// are signaled without first counting the customer's holds.
+func withoutHoldLimits(cfg config.Config) config.Config {
+	cfg.Limits = config.LimitsConfig{}
+	return cfg
+}
+
 func TestOrderHandler_UpdateSeats(t *testing.T) {
 	mockTemporal := new(MockTemporalClient)
-	handler := NewOrderHandler(mockTemporal)
+	handler := newTestHandler(withoutHoldLimits(config.Load()), mockTemporal)
 
 	orderID := "test-order-seats"
 	seats := []string{"1A", "1B"}
@@ -138,7 +227,7 @@ func TestOrderHandler_UpdateSeats(t *testing.T) {
 
 func TestOrderHandler_GetStatus_NotFound(t *testing.T) {
 	mockTemporal := new(MockTemporalClient)
-	handler := NewOrderHandler(mockTemporal)
+	handler := newTestHandler(config.Load(), mockTemporal)
 
 	orderID := "missing-order"
 
@@ -166,7 +255,7 @@ func TestOrderHandler_GetStatus_NotFound(t *testing.T) {
 
 func TestOrderHandler_SSE_NotFound(t *testing.T) {
 	mockTemporal := new(MockTemporalClient)
-	handler := NewOrderHandler(mockTemporal)
+	handler := newTestHandler(config.Load(), mockTemporal)
 
 	orderID := "missing-order"
 
@@ -191,3 +280,1409 @@ func TestOrderHandler_SSE_NotFound(t *testing.T) {
 	require.Contains(t, rr.Body.String(), "Order not found")
 	mockTemporal.AssertExpectations(t)
 }
+
+// mockEncodedValue is a converter.EncodedValue backed by a plain Go value.
+type mockEncodedValue struct {
+	value interface{}
+}
+
+func (m mockEncodedValue) HasValue() bool {
+	return m.value != nil
+}
+
+func (m mockEncodedValue) Get(valuePtr interface{}) error {
+	b, err := json.Marshal(m.value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, valuePtr)
+}
+
+// readSSE collects "event:" and "id:" lines from an SSE stream until n events were read.
+func readSSE(t *testing.T, body io.Reader, n int) (names, ids []string) {
+	t.Helper()
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() && len(names) < n {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			names = append(names, strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "id: "):
+			ids = append(ids, strings.TrimPrefix(line, "id: "))
+		}
+	}
+	return names, ids
+}
+
+func TestOrderHandler_SSE_NamedEvents(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.HoldExpiryWarning = time.Hour
+	handler := newTestHandler(cfg, mockTemporal)
+
+	orderID := "sse-order"
+	state := workflows.OrderState{
+		State:         "SEATS_SELECTED",
+		Seats:         []string{"1A"},
+		HoldExpiresAt: time.Now().Add(10 * time.Minute),
+		AttemptsLeft:  2,
+		PaymentStatus: "retrying",
+		Version:       7,
+	}
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: state}, nil)
+
+	mux := http.NewServeMux()
+	handler.attachOrderRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/orders/" + orderID + "/events")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	names, ids := readSSE(t, resp.Body, 3)
+	require.Equal(t, []string{"state", "payment", "expiry-warning"}, names)
+	require.Equal(t, []string{"7", "7"}, ids)
+}
+
+func TestOrderHandler_SSE_ResumeSkipsKnownVersion(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.SSEKeepAlive = 10 * time.Millisecond
+	handler := newTestHandler(cfg, mockTemporal)
+
+	orderID := "sse-resume"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 3}}, nil)
+
+	mux := http.NewServeMux()
+	handler.attachOrderRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/orders/"+orderID+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "3")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The first thing on the wire must be a keep-alive, not a replay of version 3.
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": keep-alive\n", line)
+}
+
+func TestOrderHandler_WebSocket_SubscribeAndCommand(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	orderID := "ws-order"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1}}, nil)
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::"+orderID, "", workflows.UpdateSeatsSignal, []string{"2C"}).
+		Return(nil).
+		Once()
+
+	mux := http.NewServeMux()
+	handler.attachOrderRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	require.NoError(t, conn.WriteJSON(domain.WSClientMessage{Type: domain.WSSubscribe, ID: "1", OrderID: orderID}))
+
+	// The ack and the first state push may arrive in either order.
+	got := map[string]domain.WSServerMessage{}
+	for len(got) < 2 {
+		var msg domain.WSServerMessage
+		require.NoError(t, conn.ReadJSON(&msg))
+		got[msg.Type] = msg
+	}
+	require.Equal(t, "1", got[domain.WSAck].ID)
+	require.Equal(t, orderID, got[domain.WSState].OrderID)
+	require.Equal(t, int64(1), got[domain.WSState].Version)
+
+	require.NoError(t, conn.WriteJSON(domain.WSClientMessage{Type: domain.WSUpdateSeats, ID: "2", OrderID: orderID, Seats: []string{"2C"}}))
+	var ack domain.WSServerMessage
+	require.NoError(t, conn.ReadJSON(&ack))
+	require.Equal(t, domain.WSAck, ack.Type)
+	require.Equal(t, "2", ack.ID)
+
+	require.NoError(t, conn.WriteJSON(domain.WSClientMessage{Type: "bogus", ID: "3"}))
+	var errMsg domain.WSServerMessage
+	require.NoError(t, conn.ReadJSON(&errMsg))
+	require.Equal(t, domain.WSError, errMsg.Type)
+	require.Equal(t, "3", errMsg.ID)
+
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestOrderHandler_WebSocket_SubscriptionLimits(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.RateLimit = config.RateLimitConfig{AvailabilityPerMinute: 1, AvailabilityBurst: wsMaxSubscriptions + 1}
+	handler := newTestHandler(cfg, mockTemporal)
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, mock.MatchedBy(func(id string) bool { return strings.HasPrefix(id, "order::") }), "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1}}, nil)
+
+	mux := http.NewServeMux()
+	handler.attachOrderRoutes(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// reply subscribes and returns the ack or error for it, skipping state pushes.
+	reply := func(id, orderID string) domain.WSServerMessage {
+		t.Helper()
+		require.NoError(t, conn.WriteJSON(domain.WSClientMessage{Type: domain.WSSubscribe, ID: id, OrderID: orderID}))
+		for {
+			var msg domain.WSServerMessage
+			require.NoError(t, conn.ReadJSON(&msg))
+			if msg.ID == id {
+				return msg
+			}
+		}
+	}
+
+	for i := 0; i < wsMaxSubscriptions; i++ {
+		require.Equal(t, domain.WSAck, reply(strconv.Itoa(i), "o-"+strconv.Itoa(i)).Type)
+	}
+	// Past the cap, new orders are refused until the client unsubscribes.
+	msg := reply("full", "o-extra")
+	require.Equal(t, domain.WSError, msg.Type)
+	require.Equal(t, domain.CodeRateLimited, msg.Code)
+
+	// Every subscribe is charged to the availability budget, which is now empty.
+	require.NoError(t, conn.WriteJSON(domain.WSClientMessage{Type: domain.WSUnsubscribe, ID: "u", OrderID: "o-0"}))
+	msg = reply("again", "o-0")
+	require.Equal(t, domain.WSError, msg.Type)
+	require.Equal(t, domain.CodeRateLimited, msg.Code)
+	require.Equal(t, "Too many requests, slow down", msg.Error)
+}
+
+func TestOrderHandler_GetStatus_ETag(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	orderID := "etag-order"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 4}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/"+orderID+"/status", nil)
+	req.SetPathValue("id", orderID)
+	rr := httptest.NewRecorder()
+	handler.getStatusHandler(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `"4"`, rr.Header().Get("ETag"))
+
+	req = httptest.NewRequest(http.MethodGet, "/orders/"+orderID+"/status", nil)
+	req.SetPathValue("id", orderID)
+	req.Header.Set("If-None-Match", `W/"4"`)
+	rr = httptest.NewRecorder()
+	handler.getStatusHandler(rr, req)
+
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.String())
+
+	// The /v1 payload has another shape, so it has another tag.
+	mux := http.NewServeMux()
+	handler.attachOrderRoutes(mux)
+	req = httptest.NewRequest(http.MethodGet, "/v1/orders/"+orderID+"/status", nil)
+	req.Header.Set("If-None-Match", `"4"`)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `"v1-4"`, rr.Header().Get("ETag"))
+}
+
+func TestOrderHandler_GetStatus_LongPoll(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.SSETick = 10 * time.Millisecond
+	handler := newTestHandler(cfg, mockTemporal)
+
+	orderID := "long-poll-order"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1}}, nil).
+		Twice()
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "SEATS_SELECTED", Version: 2}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/"+orderID+"/status?wait=5s", nil)
+	req.SetPathValue("id", orderID)
+	req.Header.Set("If-None-Match", `"1"`)
+	rr := httptest.NewRecorder()
+	handler.getStatusHandler(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `"2"`, rr.Header().Get("ETag"))
+	require.Contains(t, rr.Body.String(), "SEATS_SELECTED")
+}
+
+func TestOrderHandler_GetStatus_LongPollTimesOut(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	orderID := "idle-order"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/"+orderID+"/status?wait=50ms", nil)
+	req.SetPathValue("id", orderID)
+	req.Header.Set("If-None-Match", `"1"`)
+	rr := httptest.NewRecorder()
+	handler.getStatusHandler(rr, req)
+
+	require.Equal(t, http.StatusNotModified, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/orders/"+orderID+"/status?wait=soon", nil)
+	req.SetPathValue("id", orderID)
+	rr = httptest.NewRecorder()
+	handler.getStatusHandler(rr, req)
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestOrderHandler_CreateOrder_GeneratesID(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	var startedID string
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			startedID = opts.ID
+			return opts.WorkflowExecutionErrorWhenAlreadyStarted
+		}), mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{"flightID":"test-flight"}`))
+	rr := httptest.NewRecorder()
+	handler.createOrderHandler(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	var resp domain.CreateOrderResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Len(t, resp.OrderID, 26) // ULID
+	require.Equal(t, "order::"+resp.OrderID, startedID)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestOrderHandler_CreateOrder_InvalidID(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	for _, id := range []string{"seat::FL::1A", "has space", "-leading-dash", strings.Repeat("x", 65)} {
+		body, _ := json.Marshal(domain.CreateOrderRequest{OrderID: id, FlightID: "test-flight"})
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler.createOrderHandler(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code, id)
+	}
+	mockTemporal.AssertNotCalled(t, "ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOrderHandler_CreateOrder_Duplicate(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, serviceerror.NewWorkflowExecutionAlreadyStarted("already started", "", "run-id")).
+		Once()
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "SEATS_SELECTED", Seats: []string{"1A"}, AttemptsLeft: 3}}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{"orderID":"o-1","flightID":"test-flight"}`))
+	rr := httptest.NewRecorder()
+	handler.createOrderHandler(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+	require.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	var problem domain.Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	require.Equal(t, domain.CodeOrderAlreadyExists, problem.Code)
+	require.Equal(t, "o-1", problem.OrderID)
+	require.NotNil(t, problem.OrderStatus)
+	require.Equal(t, "SEATS_SELECTED", problem.OrderStatus.State)
+	require.Equal(t, []string{"1A"}, problem.OrderStatus.Seats)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestOrderHandler_UpdateSeats_NotFound(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(withoutHoldLimits(config.Load()), mockTemporal)
+
+	orderID := "missing-order"
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::"+orderID, "", workflows.UpdateSeatsSignal, []string{"1A"}).
+		Return(serviceerror.NewNotFound("workflow not found")).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/"+orderID+"/seats", bytes.NewBufferString(`{"seats":["1A"]}`))
+	req.SetPathValue("id", orderID)
+	rr := httptest.NewRecorder()
+	handler.updateSeatsHandler(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	require.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	var problem domain.Problem
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	require.Equal(t, domain.CodeOrderNotFound, problem.Code)
+	require.Equal(t, orderID, problem.OrderID)
+	require.Equal(t, http.StatusNotFound, problem.Status)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestTemporalProblem_StatusMapping(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{serviceerror.NewNotFound("x"), http.StatusNotFound, domain.CodeOrderNotFound},
+		{serviceerror.NewWorkflowExecutionAlreadyStarted("x", "", ""), http.StatusConflict, domain.CodeOrderAlreadyExists},
+		{serviceerror.NewAlreadyExists("x"), http.StatusConflict, domain.CodeConflict},
+		{serviceerror.NewFailedPrecondition("x"), http.StatusPreconditionFailed, domain.CodePreconditionFailed},
+		{serviceerror.NewUnavailable("x"), http.StatusServiceUnavailable, domain.CodeServiceUnavailable},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, domain.CodeServiceUnavailable},
+		{&orders.TicketError{Code: domain.CodeTicketRequired}, http.StatusForbidden, domain.CodeTicketRequired},
+		{&orders.TicketError{Code: domain.CodeTicketNotAdmitted}, http.StatusConflict, domain.CodeTicketNotAdmitted},
+		{errors.New("boom"), http.StatusInternalServerError, domain.CodeInternal},
+	}
+	for _, tc := range cases {
+		p := temporalProblem(tc.err, "o-1", "Failed")
+		require.Equal(t, tc.status, p.Status, tc.err.Error())
+		require.Equal(t, tc.code, p.Code, tc.err.Error())
+		require.Equal(t, "o-1", p.OrderID)
+	}
+}
+
+func TestOrderHandler_V1RoutesUseDomainDTOs(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	handler := newTestHandler(config.Load(), mockTemporal)
+
+	orderID := "v1-order"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::"+orderID, "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{
+			State: "SEATS_SELECTED", Seats: []string{"1A"}, AttemptsLeft: 3, Version: 2, Customer: "ip:192.0.2.9",
+			HoldTokens: map[string]int64{"1A": 7},
+		}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, mock.MatchedBy(func(id string) bool { return strings.HasPrefix(id, "seat::") }), "", "GetState").
+		Return(nil, serviceerror.NewNotFound("seat not started"))
+
+	mux := http.NewServeMux()
+	handler.attachOrderRoutes(mux)
+
+	get := func(path string) map[string]interface{} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+		return body
+	}
+
+	v1 := get("/v1/orders/" + orderID + "/status")
+	require.Equal(t, "SEATS_SELECTED", v1["state"])
+	require.Equal(t, float64(2), v1["version"])
+	require.NotContains(t, v1, "State")
+
+	legacy := get("/orders/" + orderID + "/status")
+	require.Equal(t, "SEATS_SELECTED", legacy["State"])
+	require.NotContains(t, legacy, "state")
+	// The caller identities and hold tokens stay on the server, even in the
+	// raw legacy payload.
+	require.NotContains(t, legacy, "Customer")
+	require.NotContains(t, legacy, "Owner")
+	require.NotContains(t, legacy, "HoldTokens")
+	require.NotContains(t, v1, "holdTokens")
+
+	// So does the SSE state event, which shares the payload.
+	data, err := json.Marshal(orderEvents(apiLegacy, nil, workflows.OrderState{
+		State: "PENDING", Owner: "apikey:a", Customer: "apikey:a", HoldTokens: map[string]int64{"1A": 7},
+	})[0].Data)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "apikey:a")
+	require.NotContains(t, string(data), "HoldTokens")
+
+	require.Equal(t, "FL-1", get("/v1/flights/FL-1/available-seats")["flightId"])
+	require.Equal(t, "FL-1", get("/flights/FL-1/available-seats")["flightID"])
+}
+
+func TestRouter_Health(t *testing.T) {
+	router := newTestRouter(config.Load(), new(MockTemporalClient), nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	// SSE clients resume with Last-Event-ID, so CORS must allow it.
+	require.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), "Last-Event-ID")
+}
+
+func TestRouter_CORSPreflight(t *testing.T) {
+	router := newTestRouter(config.Load(), new(MockTemporalClient), nil)
+
+	// The admin block routes are PUT and DELETE.
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete} {
+		req := httptest.NewRequest(http.MethodOptions, "/v1/admin/flights/FL-1/seats/1A/block", nil)
+		req.Header.Set("Origin", "http://localhost:5173")
+		req.Header.Set("Access-Control-Request-Method", method)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusNoContent, rr.Code, method)
+		require.Contains(t, strings.Split(rr.Header().Get("Access-Control-Allow-Methods"), ","), method)
+	}
+}
+
+func TestRouter_ServesOpenAPIDocument(t *testing.T) {
+	router := newTestRouter(config.Load(), new(MockTemporalClient), nil)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var doc map[string]interface{}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&doc))
+	require.Equal(t, "3.0.3", doc["openapi"])
+}
+
+func TestAPISpec_DescribesEveryRoute(t *testing.T) {
+	spec := mustLoadAPISpec()
+
+	for _, prefix := range []string{"", "/v1"} {
+		for _, route := range []struct{ method, path string }{
+			{http.MethodPost, "/orders"},
+			{http.MethodPost, "/orders/o-1/seats"},
+			{http.MethodPost, "/orders/o-1/payment"},
+			{http.MethodGet, "/orders/o-1/status"},
+			{http.MethodGet, "/orders/o-1/timeline"},
+			{http.MethodGet, "/orders/o-1/events"},
+			{http.MethodGet, "/flights/FL-1/available-seats"},
+			{http.MethodGet, "/flights/FL-1/seats/1A/history"},
+			{http.MethodGet, "/admin/flights/FL-1/blocked-seats"},
+			{http.MethodPut, "/admin/flights/FL-1/seats/1A/block"},
+			{http.MethodDelete, "/admin/flights/FL-1/seats/1A/block"},
+			{http.MethodGet, "/admin/flights/FL-1/seats/1A"},
+			{http.MethodPost, "/admin/flights/FL-1/seats/1A/force-release"},
+			{http.MethodPost, "/admin/flights/FL-1/seats/1A/force-unconfirm"},
+			{http.MethodPost, "/admin/flights/FL-1/seats/1A/reassign"},
+			{http.MethodGet, "/ws"},
+		} {
+			req := httptest.NewRequest(route.method, prefix+route.path, nil)
+			_, _, err := spec.router.FindRoute(req)
+			require.NoError(t, err, "%s %s", route.method, prefix+route.path)
+		}
+	}
+}
+
+func TestRouter_RejectsInvalidRequestsBeforeTemporal(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	router := newTestRouter(config.Load(), mockTemporal, nil)
+
+	cases := []struct {
+		name, path, body string
+	}{
+		{"missing flightID", "/orders", `{"orderID":"o-1"}`},
+		{"missing flightId on v1", "/v1/orders", `{"flightID":"FL-1"}`},
+		{"invalid seat ID", "/orders/o-1/seats", `{"seats":["1A","Z9"]}`},
+		{"duplicate seats", "/v1/orders/o-1/seats", `{"seats":["1A","1A"]}`},
+		{"short payment code", "/orders/o-1/payment", `{"code":"1234"}`},
+		{"non-numeric payment code", "/v1/orders/o-1/payment", `{"code":"E2E-OK"}`},
+		{"invalid order ID in path", "/orders/bad::id/payment", `{"code":"12345"}`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code, tc.name)
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem), tc.name)
+		require.Equal(t, domain.CodeInvalidRequest, problem.Code, tc.name)
+	}
+
+	// Nothing invalid may reach Temporal.
+	mockTemporal.AssertNotCalled(t, "ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockTemporal.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRouter_AcceptsValidPayment(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	router := newTestRouter(config.Load(), mockTemporal, nil)
+
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.SubmitPaymentSignal, "12345").
+		Return(nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/o-1/payment", bytes.NewBufferString(`{"code":"12345"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_RequiresCredentials(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	router := newTestRouter(config.Load(), mockTemporal, auth.APIKeys{"key-a": "partner-a"})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/orders/o-1/status", nil),
+		httptest.NewRequest(http.MethodGet, "/v1/flights/FL-1/available-seats", nil),
+		httptest.NewRequest(http.MethodGet, "/orders/o-1/status?api_key=wrong", nil),
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusUnauthorized, rr.Code, req.URL.String())
+		require.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, domain.CodeUnauthorized, problem.Code)
+	}
+
+	// Health and the API document stay public.
+	for _, path := range []string{"/health", "/openapi.json"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+	}
+	require.Empty(t, mockTemporal.Calls)
+}
+
+func TestRouter_CreateOrderRecordsOwner(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Limits = config.LimitsConfig{MaxSeatsPerOrder: 4, MaxHeldSeatsPerCustomer: 6}
+	router := newTestRouter(cfg, mockTemporal, auth.APIKeys{"key-a": "partner-a"})
+
+	// The owner is also the customer the hold limits apply to.
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, []interface{}{workflows.OrderInput{
+			OrderID: "o-1", FlightID: "FL-1", Owner: "apikey:partner-a", Customer: "apikey:partner-a",
+			Limits: workflows.HoldLimits{MaxSeatsPerOrder: 4, MaxHeldSeatsPerCustomer: 6}, Waitlist: true,
+		}}).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/orders", bytes.NewBufferString(`{"orderId":"o-1","flightId":"FL-1","waitlist":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "key-a")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_CreateOrderAssignsSeats(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Limits = config.LimitsConfig{MaxSeatsPerOrder: 4}
+	router := newTestRouter(cfg, mockTemporal, nil)
+
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, []interface{}{workflows.OrderInput{
+			OrderID: "o-1", FlightID: "FL-1", Customer: "ip:192.0.2.1",
+			Limits: workflows.HoldLimits{MaxSeatsPerOrder: 4}, AssignSeats: 4,
+		}}).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	send := func(body string) (int, domain.Problem) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:40000"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var problem domain.Problem
+		if rr.Code != http.StatusCreated {
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		}
+		return rr.Code, problem
+	}
+
+	code, problem := send(`{"orderId":"o-2","flightId":"FL-1","assignSeats":5}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	require.Equal(t, domain.CodeTooManySeats, problem.Code)
+
+	code, problem = send(`{"orderId":"o-3","flightId":"FL-1","assignSeats":-1}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, domain.CodeInvalidRequest, problem.Code)
+
+	code, _ = send(`{"orderId":"o-1","flightId":"FL-1","assignSeats":4}`)
+	require.Equal(t, http.StatusCreated, code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_RejectsNonOwners(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	router := newTestRouter(config.Load(), mockTemporal, auth.APIKeys{"key-a": "partner-a", "key-b": "partner-b"})
+
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1, Owner: "apikey:partner-a"}}, nil)
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.UpdateSeatsSignal, []string{"1A"}).
+		Return(nil).
+		Once()
+
+	send := func(method, path, body, key string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(&flusherResponseRecorder{rr}, req)
+		return rr
+	}
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodPost, "/orders/o-1/seats", `{"seats":["1A"]}`},
+		{http.MethodPost, "/v1/orders/o-1/payment", `{"code":"12345"}`},
+		{http.MethodGet, "/orders/o-1/status", ""},
+		{http.MethodGet, "/v1/orders/o-1/events", ""},
+	} {
+		rr := send(tc.method, tc.path, tc.body, "key-b")
+		require.Equal(t, http.StatusForbidden, rr.Code, tc.path)
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, domain.CodeForbidden, problem.Code)
+	}
+	mockTemporal.AssertNotCalled(t, "SignalWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// The owner is let through.
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/orders/o-1/seats", `{"seats":["1A"]}`, "key-a").Code)
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/v1/orders/o-1/status", "", "key-a").Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_EnforcesHoldLimits(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Limits = config.LimitsConfig{MaxSeatsPerOrder: 3, MaxHeldSeatsPerCustomer: 4}
+	router := newTestRouter(cfg, mockTemporal, nil)
+
+	customer := "ip:192.0.2.1"
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "SEATS_SELECTED", Seats: []string{"1C"}, Version: 1, FlightID: "F-100", Customer: customer}}, nil)
+	// The customer holds 1A and 1B through another order; 1C is this order's and 1D another customer's.
+	held := map[string]seat.SeatState{
+		"seat::F-100::1A": {IsHeld: true, HeldBy: "o-2", HeldFor: customer},
+		"seat::F-100::1B": {IsHeld: true, HeldBy: "o-2", HeldFor: customer},
+		"seat::F-100::1C": {IsHeld: true, HeldBy: "o-1", HeldFor: customer},
+		"seat::F-100::1D": {IsHeld: true, HeldBy: "o-3", HeldFor: "ip:192.0.2.2"},
+	}
+	for _, seatID := range seat.AllSeatIDs() {
+		id := seat.WorkflowID("F-100", seatID)
+		mockTemporal.On("QueryWorkflow", mock.Anything, id, "", "GetState").Return(mockEncodedValue{value: held[id]}, nil)
+	}
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.UpdateSeatsSignal, []string{"1C", "2A"}).
+		Return(nil).
+		Once()
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.UpdateSeatsSignal, []string{"1C"}).
+		Return(nil).
+		Once()
+
+	send := func(body string) (int, domain.Problem) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/orders/o-1/seats", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "192.0.2.1:40000"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var problem domain.Problem
+		if rr.Code != http.StatusOK {
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		}
+		return rr.Code, problem
+	}
+
+	code, problem := send(`{"seats":["2A","2B","2C","2D"]}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	require.Equal(t, domain.CodeTooManySeats, problem.Code)
+	require.Equal(t, "An order can hold at most 3 seats", problem.Detail)
+
+	// Two seats held elsewhere plus three new ones exceed the limit of four.
+	code, problem = send(`{"seats":["1C","2A","2B"]}`)
+	require.Equal(t, http.StatusConflict, code)
+	require.Equal(t, domain.CodeHoldLimitExceeded, problem.Code)
+
+	code, _ = send(`{"seats":["1C","2A"]}`)
+	require.Equal(t, http.StatusOK, code)
+
+	// Keeping only seats the order holds cannot raise the customer's holds:
+	// the order status is queried, but none of the seats.
+	calls := len(mockTemporal.Calls)
+	code, _ = send(`{"seats":["1C"]}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, mockTemporal.Calls, calls+2)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_RateLimitsPerCaller(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.RateLimit = config.RateLimitConfig{MutationsPerMinute: 1, MutationBurst: 2, AvailabilityPerMinute: 6, AvailabilityBurst: 1}
+	router := newTestRouter(cfg, mockTemporal, nil)
+
+	mockTemporal.
+		On("SignalWorkflow", mock.Anything, "order::o-1", "", workflows.SubmitPaymentSignal, "12345").
+		Return(nil).
+		Times(3)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, mock.MatchedBy(func(id string) bool { return strings.HasPrefix(id, "seat::") }), "", "GetState").
+		Return(nil, serviceerror.NewNotFound("workflow not found"))
+
+	send := func(method, path, body, remoteAddr string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	requireRateLimited := func(rr *httptest.ResponseRecorder, retryAfter string) {
+		t.Helper()
+		require.Equal(t, http.StatusTooManyRequests, rr.Code)
+		require.Equal(t, retryAfter, rr.Header().Get("Retry-After"))
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, domain.CodeRateLimited, problem.Code)
+	}
+
+	// Mutations: a burst of two, then one per minute.
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusOK, send(http.MethodPost, "/v1/orders/o-1/payment", `{"code":"12345"}`, "192.0.2.1:1000").Code)
+	}
+	requireRateLimited(send(http.MethodPost, "/orders/o-1/payment", `{"code":"12345"}`, "192.0.2.1:1001"), "60")
+	// Another caller has its own budget.
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/v1/orders/o-1/payment", `{"code":"12345"}`, "192.0.2.2:1000").Code)
+
+	// Availability reads are budgeted separately from mutations.
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/v1/flights/F-100/available-seats", "", "192.0.2.1:1000").Code)
+	requireRateLimited(send(http.MethodGet, "/v1/flights/F-100/available-seats", "", "192.0.2.1:1000"), "10")
+
+	// Status and queue ticket reads share the availability budget.
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", Version: 1}}, nil).
+		Once()
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/v1/orders/o-1/status", "", "192.0.2.3:1000").Code)
+	requireRateLimited(send(http.MethodGet, "/orders/o-1/status?wait=30s", "", "192.0.2.3:1000"), "10")
+	requireRateLimited(send(http.MethodGet, "/v1/flights/F-100/queue/t-1", "", "192.0.2.3:1000"), "10")
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestStreamLimited(t *testing.T) {
+	opened := make(chan struct{})
+	closeStream := make(chan struct{})
+	handler := streamLimited(ratelimit.NewConns(1), func(w http.ResponseWriter, r *http.Request) {
+		opened <- struct{}{}
+		<-closeStream
+	})
+	request := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/v1/orders/o-1/events", nil).
+			WithContext(auth.WithClientIP(context.Background(), "192.0.2.1"))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(httptest.NewRecorder(), request())
+	}()
+	<-opened
+
+	rr := httptest.NewRecorder()
+	handler(rr, request())
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "5", rr.Header().Get("Retry-After"))
+
+	// Once the first stream closes, the caller may open another.
+	close(closeStream)
+	<-done
+	go handler(httptest.NewRecorder(), request())
+	<-opened
+}
+
+func TestLongPollLimited(t *testing.T) {
+	opened := make(chan struct{})
+	closePoll := make(chan struct{})
+	handler := longPollLimited(ratelimit.NewConns(1), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wait") != "" {
+			opened <- struct{}{}
+			<-closePoll
+		}
+	})
+	request := func(query string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/v1/orders/o-1/status"+query, nil).
+			WithContext(auth.WithClientIP(context.Background(), "192.0.2.1"))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(httptest.NewRecorder(), request("?wait=30s"))
+	}()
+	<-opened
+
+	// A long-poll holds a stream slot; a plain status read does not need one.
+	rr := httptest.NewRecorder()
+	handler(rr, request("?wait=30s"))
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	rr = httptest.NewRecorder()
+	handler(rr, request(""))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	close(closePoll)
+	<-done
+}
+
+func TestRouter_WaitingRoom(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.WaitingRoom = config.WaitingRoomConfig{Flights: []string{"F-100"}, AdmitPerMinute: 60, AdmissionTTL: 10 * time.Minute}
+	router := newTestRouter(cfg, mockTemporal, nil)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.RemoteAddr = "192.0.2.1:40000"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	requireProblem := func(rr *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, rr.Code, rr.Body.String())
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, code, problem.Code)
+	}
+
+	// Orders for the flight need a ticket; other flights take orders directly.
+	requireProblem(send(http.MethodPost, "/v1/orders", `{"flightId":"F-100","orderId":"o-1"}`), http.StatusForbidden, domain.CodeTicketRequired)
+	requireProblem(send(http.MethodPost, "/v1/flights/F-200/queue", ""), http.StatusBadRequest, domain.CodeInvalidRequest)
+
+	// Joining starts the room with the configured rate and queues the caller.
+	var ticketID string
+	mockTemporal.
+		On("SignalWithStartWorkflow", mock.Anything, "waitingroom::F-100", waitingroom.JoinSignal, mock.Anything,
+			mock.MatchedBy(func(opts client.StartWorkflowOptions) bool { return opts.TaskQueue == "seat-tq" }),
+			mock.Anything, []interface{}{waitingroom.Input{FlightID: "F-100", AdmitPerMinute: 60, AdmissionTTL: 10 * time.Minute}}).
+		Run(func(args mock.Arguments) {
+			join := args.Get(3).(waitingroom.JoinRequest)
+			require.Equal(t, "ip:192.0.2.1", join.Customer)
+			ticketID = join.TicketID
+		}).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "waitingroom::F-100", "", waitingroom.GetTicketQuery, mock.Anything).
+		Return(mockEncodedValue{value: waitingroom.TicketStatus{
+			Ticket:        waitingroom.Ticket{ID: "t-1", State: waitingroom.TicketWaiting},
+			FlightID:      "F-100",
+			Position:      3,
+			EstimatedWait: 3 * time.Second,
+		}}, nil).
+		Once()
+
+	rr := send(http.MethodPost, "/v1/flights/F-100/queue", "")
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var ticket domain.QueueTicket
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&ticket))
+	require.Equal(t, domain.QueueTicket{TicketID: "t-1", FlightID: "F-100", State: "waiting", Position: 3, EstimatedWaitSeconds: 3}, ticket)
+	require.NotEmpty(t, ticketID)
+
+	redeem := mock.MatchedBy(func(opts client.UpdateWorkflowOptions) bool {
+		return opts.WorkflowID == "waitingroom::F-100" && opts.UpdateName == waitingroom.RedeemUpdate
+	})
+
+	// A waiting ticket cannot create an order.
+	mockTemporal.
+		On("UpdateWorkflow", mock.Anything, redeem).
+		Return(mockUpdateHandle{err: temporal.NewApplicationError("ticket has not been admitted yet", waitingroom.ErrTypeTicketNotAdmitted)}, nil).
+		Once()
+	requireProblem(send(http.MethodPost, "/v1/orders", `{"flightId":"F-100","orderId":"o-1","ticketId":"t-1"}`), http.StatusConflict, domain.CodeTicketNotAdmitted)
+
+	// An admitted ticket does, once.
+	mockTemporal.
+		On("UpdateWorkflow", mock.Anything, redeem).
+		Return(mockUpdateHandle{}, nil).
+		Once()
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/v1/orders", `{"flightId":"F-100","orderId":"o-1","ticketId":"t-1"}`).Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_WaitingRoomReturnsTicketWhenOrderFails(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.WaitingRoom = config.WaitingRoomConfig{Flights: []string{"*"}, AdmitPerMinute: 60, AdmissionTTL: 10 * time.Minute}
+	router := newTestRouter(cfg, mockTemporal, nil)
+
+	update := func(name string) interface{} {
+		return mock.MatchedBy(func(opts client.UpdateWorkflowOptions) bool {
+			req := opts.Args[0].(waitingroom.RedeemRequest)
+			return opts.UpdateName == name && req.TicketID == "t-1" && req.OrderID == "o-1"
+		})
+	}
+	mockTemporal.On("UpdateWorkflow", mock.Anything, update(waitingroom.RedeemUpdate)).Return(mockUpdateHandle{}, nil).Once()
+	mockTemporal.
+		On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, serviceerror.NewUnavailable("temporal down")).
+		Once()
+	mockTemporal.On("UpdateWorkflow", mock.Anything, update(waitingroom.ReturnTicketUpdate)).Return(mockUpdateHandle{}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/orders", bytes.NewBufferString(`{"flightId":"F-100","orderId":"o-1","ticketId":"t-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestOrderHandler_TicketEventsEndWhenRedeemed(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.SSETick = 10 * time.Millisecond
+	handler := newTestHandler(cfg, mockTemporal)
+
+	waiting := waitingroom.TicketStatus{Ticket: waitingroom.Ticket{ID: "t-1", State: waitingroom.TicketWaiting}, FlightID: "F-100", Position: 1}
+	redeemed := waitingroom.TicketStatus{Ticket: waitingroom.Ticket{ID: "t-1", State: waitingroom.TicketRedeemed, OrderID: "o-1"}, FlightID: "F-100"}
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "waitingroom::F-100", "", waitingroom.GetTicketQuery, "t-1").
+		Return(mockEncodedValue{value: waiting}, nil).
+		Once()
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "waitingroom::F-100", "", waitingroom.GetTicketQuery, "t-1").
+		Return(mockEncodedValue{value: redeemed}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/flights/F-100/queue/t-1/events", nil)
+	req.SetPathValue("flightID", "F-100")
+	req.SetPathValue("ticketID", "t-1")
+	rr := &flusherResponseRecorder{httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ticketEventsHandler(rr, req)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ticket stream did not end after the ticket was redeemed")
+	}
+
+	require.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	body := rr.Body.String()
+	require.Contains(t, body, `"state":"waiting","position":1`)
+	require.Contains(t, body, `"state":"redeemed","orderId":"o-1"`)
+	names, _ := readSSE(t, strings.NewReader(body), 2)
+	require.Equal(t, []string{eventTicket, eventTicket}, names)
+}
+
+func TestRouter_AdminSeatBlocks(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Auth.Admins = []string{"apikey:ops"}
+	router := newTestRouter(cfg, mockTemporal, auth.APIKeys{"key-ops": "ops", "key-a": "partner-a"})
+
+	send := func(method, path, body, key string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	requireProblem := func(rr *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, rr.Code, rr.Body.String())
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, code, problem.Code)
+	}
+
+	blockedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::1A", "", "GetState").
+		Return(nil, serviceerror.NewNotFound("seat not started"))
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::2A", "", "GetState").
+		Return(mockEncodedValue{value: seat.SeatState{IsHeld: true, HeldBy: "o-1"}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::3C", "", "GetState").
+		Return(mockEncodedValue{value: seat.SeatState{Block: &seat.Block{Reason: "crew rest", Actor: "apikey:ops", BlockedAt: blockedAt}}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, mock.MatchedBy(func(id string) bool { return strings.HasPrefix(id, "seat::FL-1::") }), "", "GetState").
+		Return(nil, serviceerror.NewNotFound("seat not started"))
+	mockTemporal.
+		On("SignalWithStartWorkflow", mock.Anything, "seat::FL-1::1A", "cmd",
+			seat.Command{Type: seat.CmdBlock, Reason: "broken recline", Actor: "apikey:ops"},
+			mock.MatchedBy(func(opts client.StartWorkflowOptions) bool { return opts.TaskQueue == "seat-tq" }),
+			mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+	mockTemporal.
+		On("SignalWithStartWorkflow", mock.Anything, "seat::FL-1::1A", "cmd",
+			seat.Command{Type: seat.CmdUnblock, Actor: "apikey:ops"}, mock.Anything, mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	const block = `{"reason":"broken recline"}`
+	requireProblem(send(http.MethodPut, "/v1/admin/flights/FL-1/seats/1A/block", block, "key-a"), http.StatusForbidden, domain.CodeForbidden)
+	requireProblem(send(http.MethodPut, "/v1/admin/flights/FL-1/seats/1A/block", `{"reason":""}`, "key-ops"), http.StatusBadRequest, domain.CodeInvalidRequest)
+	requireProblem(send(http.MethodPut, "/v1/admin/flights/FL-1/seats/9F/block", block, "key-ops"), http.StatusNotFound, domain.CodeSeatNotFound)
+	requireProblem(send(http.MethodPut, "/v1/admin/flights/FL-1/seats/2A/block", block, "key-ops"), http.StatusConflict, domain.CodeConflict)
+
+	require.Equal(t, http.StatusOK, send(http.MethodPut, "/v1/admin/flights/FL-1/seats/1A/block", block, "key-ops").Code)
+	require.Equal(t, http.StatusOK, send(http.MethodDelete, "/v1/admin/flights/FL-1/seats/1A/block", "", "key-ops").Code)
+
+	rr := send(http.MethodGet, "/v1/admin/flights/FL-1/blocked-seats", "", "key-ops")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var blocks []domain.SeatBlock
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&blocks))
+	require.Equal(t, []domain.SeatBlock{{SeatID: "3C", Reason: "crew rest", BlockedBy: "apikey:ops", BlockedAt: blockedAt}}, blocks)
+
+	// Customers see blocked seats as a category of their own.
+	rr = send(http.MethodGet, "/v1/flights/FL-1/available-seats", "", "key-a")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var avail domain.FlightAvailability
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&avail))
+	require.Equal(t, []string{"3C"}, avail.Blocked)
+	require.Equal(t, []string{"2A"}, avail.Held)
+	require.NotContains(t, avail.Available, "3C")
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_AdminRoutesNeedAuthentication(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Auth.Admins = []string{"apikey:ops"}
+	// Without an authenticator nobody is an admin, whatever AUTH_ADMINS says.
+	router := newTestRouter(cfg, mockTemporal, nil)
+
+	for _, tc := range []struct{ method, path, body string }{
+		{http.MethodGet, "/v1/admin/flights/FL-1/blocked-seats", ""},
+		{http.MethodPut, "/v1/admin/flights/FL-1/seats/1A/block", `{"reason":"broken recline"}`},
+		{http.MethodDelete, "/admin/flights/FL-1/seats/1A/block", ""},
+		{http.MethodGet, "/v1/admin/flights/FL-1/seats/1A", ""},
+		{http.MethodPost, "/v1/admin/flights/FL-1/seats/1A/force-release", `{"reason":"stuck hold"}`},
+		{http.MethodPost, "/v1/admin/flights/FL-1/seats/1A/force-unconfirm", `{"reason":"refund"}`},
+		{http.MethodPost, "/admin/flights/FL-1/seats/1A/reassign", `{"reason":"rebooked","orderId":"o-2"}`},
+	} {
+		var body io.Reader
+		if tc.body != "" {
+			body = bytes.NewBufferString(tc.body)
+		}
+		req := httptest.NewRequest(tc.method, tc.path, body)
+		if tc.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusForbidden, rr.Code, tc.path)
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, domain.CodeForbidden, problem.Code)
+	}
+	require.Empty(t, mockTemporal.Calls)
+}
+
+func TestRouter_AdminSeatOverrides(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Auth.Admins = []string{"apikey:ops"}
+	router := newTestRouter(cfg, mockTemporal, auth.APIKeys{"key-ops": "ops", "key-a": "partner-a"})
+
+	send := func(method, path, body, key string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = bytes.NewBufferString(body)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	requireProblem := func(rr *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, rr.Code, rr.Body.String())
+		var problem domain.Problem
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+		require.Equal(t, code, problem.Code)
+	}
+
+	overriddenAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::1A", "", "GetState").
+		Return(mockEncodedValue{value: seat.SeatState{
+			IsHeld: true, HeldBy: "o-1", HeldFor: "apikey:partner-a", ExpiresAt: "2025-03-01T12:15:00Z",
+			Waitlist:  []string{"o-9"},
+			Overrides: []seat.Override{{Command: seat.CmdForceUnconfirm, Actor: "apikey:ops", Reason: "refund", At: overriddenAt, PreviousOrderID: "o-0"}},
+		}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::2A", "", "GetState").
+		Return(mockEncodedValue{value: seat.SeatState{IsConfirmed: true, ConfirmedBy: "o-1"}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-2", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "CONFIRMED", FlightID: "FL-1", Customer: "apikey:partner-b"}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-3", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", FlightID: "FL-2"}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-4", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "PENDING", FlightID: "FL-1"}}, nil)
+	mockTemporal.
+		On("SignalWithStartWorkflow", mock.Anything, "seat::FL-1::1A", "cmd",
+			seat.Command{Type: seat.CmdForceRelease, Reason: "stuck hold", Actor: "apikey:ops"}, mock.Anything, mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+	mockTemporal.
+		On("SignalWithStartWorkflow", mock.Anything, "seat::FL-1::2A", "cmd",
+			seat.Command{Type: seat.CmdReassign, OrderID: "o-2", Customer: "apikey:partner-b", Reason: "rebooked", Actor: "apikey:ops"}, mock.Anything, mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, nil).
+		Once()
+
+	requireProblem(send(http.MethodGet, "/v1/admin/flights/FL-1/seats/1A", "", "key-a"), http.StatusForbidden, domain.CodeForbidden)
+
+	rr := send(http.MethodGet, "/v1/admin/flights/FL-1/seats/1A", "", "key-ops")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var details domain.SeatDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&details))
+	holdExpiresAt := time.Date(2025, 3, 1, 12, 15, 0, 0, time.UTC)
+	require.Equal(t, domain.SeatDetails{
+		FlightID: "FL-1", SeatID: "1A", Status: "held", HeldBy: "o-1", HeldFor: "apikey:partner-a", HoldExpiresAt: &holdExpiresAt,
+		Waitlist:  []string{"o-9"},
+		Overrides: []domain.SeatOverride{{Command: "FORCE_UNCONFIRM", Reason: "refund", Actor: "apikey:ops", At: overriddenAt, PreviousOrderID: "o-0"}},
+	}, details)
+
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/v1/admin/flights/FL-1/seats/1A/force-release", `{"reason":"stuck hold"}`, "key-ops").Code)
+	requireProblem(send(http.MethodPost, "/v1/admin/flights/FL-1/seats/1A/force-unconfirm", `{"reason":"refund"}`, "key-ops"), http.StatusConflict, domain.CodeConflict)
+	requireProblem(send(http.MethodPost, "/v1/admin/flights/FL-1/seats/2A/reassign", `{"reason":"rebooked"}`, "key-ops"), http.StatusBadRequest, domain.CodeInvalidRequest)
+	requireProblem(send(http.MethodPost, "/v1/admin/flights/FL-1/seats/2A/reassign", `{"reason":"rebooked","orderId":"o-3"}`, "key-ops"), http.StatusConflict, domain.CodeConflict)
+	// A confirmed seat goes to confirmed orders only, a hold to open orders only.
+	requireProblem(send(http.MethodPost, "/v1/admin/flights/FL-1/seats/2A/reassign", `{"reason":"rebooked","orderId":"o-4"}`, "key-ops"), http.StatusConflict, domain.CodeConflict)
+	requireProblem(send(http.MethodPost, "/v1/admin/flights/FL-1/seats/1A/reassign", `{"reason":"rebooked","orderId":"o-2"}`, "key-ops"), http.StatusConflict, domain.CodeConflict)
+	require.Equal(t, http.StatusOK, send(http.MethodPost, "/v1/admin/flights/FL-1/seats/2A/reassign", `{"reason":"rebooked","orderId":"o-2"}`, "key-ops").Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_SeatHistory(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	router := newTestRouter(config.Load(), mockTemporal, nil)
+
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::1A", "", seat.GetHistoryQuery).
+		Return(mockEncodedValue{value: []seat.HistoryEntry{
+			{At: at, Command: seat.CmdHold, OrderID: "o-1", Outcome: seat.OutcomeApplied, HeldBy: "o-1"},
+			{At: at, Command: seat.CmdHold, OrderID: "o-2", Outcome: seat.OutcomeIgnored, Reason: "seat is held by o-1", HeldBy: "o-1"},
+		}}, nil)
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "seat::FL-1::2A", "", seat.GetHistoryQuery).
+		Return(nil, serviceerror.NewNotFound("workflow not found"))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get("/v1/flights/FL-1/seats/1A/history")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var history domain.SeatHistory
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&history))
+	require.Equal(t, domain.SeatHistory{FlightID: "FL-1", SeatID: "1A", Entries: []domain.SeatHistoryEntry{
+		{At: at, Command: "HOLD", OrderID: "o-1", Outcome: "applied", HeldBy: "o-1"},
+		{At: at, Command: "HOLD", OrderID: "o-2", Outcome: "ignored", Reason: "seat is held by o-1", HeldBy: "o-1"},
+	}}, history)
+
+	// A seat that was never used has no history yet.
+	rr = get("/flights/FL-1/seats/2A/history")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.JSONEq(t, `{"flightId":"FL-1","seatId":"2A","entries":[]}`, rr.Body.String())
+
+	require.Equal(t, http.StatusNotFound, get("/v1/flights/FL-1/seats/9F/history").Code)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestRouter_OrderTimeline(t *testing.T) {
+	mockTemporal := new(MockTemporalClient)
+	cfg := config.Load()
+	cfg.Auth.Admins = []string{"apikey:ops"}
+	router := newTestRouter(cfg, mockTemporal, auth.APIKeys{"key-a": "partner-a", "key-b": "partner-b", "key-ops": "ops"})
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	payloads := func(values ...interface{}) *commonpb.Payloads {
+		p, err := converter.GetDefaultDataConverter().ToPayloads(values...)
+		require.NoError(t, err)
+		return p
+	}
+	event := func(id int64, eventType enumspb.EventType, attrs interface{}) *historypb.HistoryEvent {
+		e := &historypb.HistoryEvent{EventId: id, EventType: eventType, EventTime: timestamppb.New(start.Add(time.Duration(id) * time.Second))}
+		switch a := attrs.(type) {
+		case *historypb.WorkflowExecutionStartedEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{WorkflowExecutionStartedEventAttributes: a}
+		case *historypb.WorkflowExecutionSignaledEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_WorkflowExecutionSignaledEventAttributes{WorkflowExecutionSignaledEventAttributes: a}
+		case *historypb.ActivityTaskScheduledEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_ActivityTaskScheduledEventAttributes{ActivityTaskScheduledEventAttributes: a}
+		case *historypb.ActivityTaskCompletedEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_ActivityTaskCompletedEventAttributes{ActivityTaskCompletedEventAttributes: a}
+		case *historypb.ActivityTaskFailedEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_ActivityTaskFailedEventAttributes{ActivityTaskFailedEventAttributes: a}
+		case *historypb.TimerStartedEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_TimerStartedEventAttributes{TimerStartedEventAttributes: a}
+		case *historypb.TimerFiredEventAttributes:
+			e.Attributes = &historypb.HistoryEvent_TimerFiredEventAttributes{TimerFiredEventAttributes: a}
+		}
+		return e
+	}
+	history := func() client.HistoryEventIterator {
+		return &mockHistoryIterator{events: []*historypb.HistoryEvent{
+			event(1, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, &historypb.WorkflowExecutionStartedEventAttributes{
+				Input: payloads(workflows.OrderInput{OrderID: "o-1", FlightID: "FL-1"}),
+			}),
+			event(5, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, &historypb.WorkflowExecutionSignaledEventAttributes{
+				SignalName: workflows.UpdateSeatsSignal, Input: payloads([]string{"1A", "1B"}),
+			}),
+			event(7, enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED, &historypb.ActivityTaskScheduledEventAttributes{
+				ActivityType: &commonpb.ActivityType{Name: "SeatSignalActivity"},
+				Input:        payloads(map[string]interface{}{"FlightID": "FL-1", "SeatID": "1A", "Cmd": map[string]interface{}{"Type": "HOLD", "OrderID": "o-1"}}),
+			}),
+			event(9, enumspb.EVENT_TYPE_ACTIVITY_TASK_COMPLETED, &historypb.ActivityTaskCompletedEventAttributes{ScheduledEventId: 7}),
+			event(11, enumspb.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, &historypb.WorkflowExecutionSignaledEventAttributes{
+				SignalName: workflows.SubmitPaymentSignal, Input: payloads("54321"),
+			}),
+			event(13, enumspb.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED, &historypb.ActivityTaskScheduledEventAttributes{
+				ActivityType: &commonpb.ActivityType{Name: "ValidatePaymentActivity"},
+				Input:        payloads("o-1", "54321"),
+			}),
+			event(15, enumspb.EVENT_TYPE_ACTIVITY_TASK_FAILED, &historypb.ActivityTaskFailedEventAttributes{
+				ScheduledEventId: 13, Failure: temporal.GetDefaultFailureConverter().ErrorToFailure(errors.New("payment gateway failed")),
+			}),
+			event(17, enumspb.EVENT_TYPE_TIMER_STARTED, &historypb.TimerStartedEventAttributes{StartToFireTimeout: durationpb.New(15 * time.Minute)}),
+			event(19, enumspb.EVENT_TYPE_TIMER_FIRED, &historypb.TimerFiredEventAttributes{StartedEventId: 17}),
+		}}
+	}
+	mockTemporal.
+		On("QueryWorkflow", mock.Anything, "order::o-1", "", workflows.GetStatusQuery).
+		Return(mockEncodedValue{value: workflows.OrderState{State: "EXPIRED", Owner: "apikey:partner-a", FlightID: "FL-1"}}, nil)
+	mockTemporal.
+		On("GetWorkflowHistory", mock.Anything, "order::o-1", "", false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+		Return(history()).
+		Once()
+	mockTemporal.
+		On("GetWorkflowHistory", mock.Anything, "order::o-1", "", false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+		Return(history()).
+		Once()
+
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-API-Key", key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/v1/orders/o-1/timeline", "key-a")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotContains(t, rr.Body.String(), "54321")
+	var timeline domain.OrderTimeline
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&timeline))
+	require.Equal(t, "o-1", timeline.OrderID)
+	require.Equal(t, "EXPIRED", timeline.State)
+
+	var summaries []string
+	for _, e := range timeline.Events {
+		summaries = append(summaries, e.Kind+": "+e.Summary)
+	}
+	require.Equal(t, []string{
+		"workflow: Order created for flight FL-1",
+		"signal: Seats selected: 1A, 1B",
+		"activity: HOLD of seat 1A completed",
+		"signal: Payment submitted with code *****",
+		"activity: Payment validation with code ***** failed",
+		"timer: Timer fired after 15m0s",
+	}, summaries)
+	require.Equal(t, "payment gateway failed", timeline.Events[4].Error)
+	require.Equal(t, start.Add(15*time.Second), timeline.Events[4].At)
+
+	// Support agents read any order; other customers read none.
+	require.Equal(t, http.StatusOK, get("/orders/o-1/timeline", "key-ops").Code)
+	require.Equal(t, http.StatusForbidden, get("/v1/orders/o-1/timeline", "key-b").Code)
+	mockTemporal.AssertExpectations(t)
+}
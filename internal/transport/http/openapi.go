@@ -0,0 +1,126 @@
+package http
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+//go:embed openapi.yaml
+var openAPIDocument []byte
+
+// apiSpec is the parsed OpenAPI document describing every order route.
+type apiSpec struct {
+	doc    *openapi3.T
+	router routers.Router
+	json   []byte
+}
+
+// loadAPISpec parses and validates the embedded OpenAPI document.
+func loadAPISpec() (*apiSpec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPIDocument)
+	if err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build OpenAPI router: %w", err)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("marshal OpenAPI document: %w", err)
+	}
+	return &apiSpec{doc: doc, router: router, json: data}, nil
+}
+
+// mustLoadAPISpec is loadAPISpec for the embedded document, which is known to be valid.
+func mustLoadAPISpec() *apiSpec {
+	spec, err := loadAPISpec()
+	if err != nil {
+		panic(err)
+	}
+	return spec
+}
+
+// serveJSON serves the OpenAPI document at /openapi.json.
+func (s *apiSpec) serveJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.json)
+}
+
+// validate rejects requests that do not match the OpenAPI document with a 400
+// problem, before they reach a handler and Temporal. Requests for routes the
+// document does not describe (health, the document itself) pass through.
+func (s *apiSpec) validate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := s.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			problem := newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, validationDetail(err))
+			problem.OrderID = pathParams["id"]
+			problem.FlightID = pathParams["flightID"]
+			writeProblem(w, r, problem)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validateWSMessage checks a WebSocket client message against the WSClientMessage schema.
+func (s *apiSpec) validateWSMessage(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.New("Invalid message")
+	}
+	schema := s.doc.Components.Schemas["WSClientMessage"].Value
+	if err := schema.VisitJSON(value); err != nil {
+		return errors.New(validationDetail(err))
+	}
+	return nil
+}
+
+// validationDetail turns a kin-openapi validation error into a short client-facing message.
+func validationDetail(err error) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		field := strings.Join(schemaErr.JSONPointer(), ".")
+		if field == "" {
+			return "Invalid request: " + schemaErr.Reason
+		}
+		return fmt.Sprintf("Invalid %s: %s", field, schemaErr.Reason)
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if reqErr.Parameter != nil {
+			return fmt.Sprintf("Invalid %s parameter %q", reqErr.Parameter.In, reqErr.Parameter.Name)
+		}
+		if reqErr.Reason != "" {
+			return "Invalid request body: " + reqErr.Reason
+		}
+	}
+	return "Invalid request"
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/realtime/sse"
+)
+
+// eventTicket is the SSE event emitted on /flights/{flightID}/queue/{ticketID}/events.
+const eventTicket = "ticket"
+
+// ticketProblem maps an error of the waiting room to a problem about the flight.
+func ticketProblem(err error, flightID, detail string) domain.Problem {
+	problem := temporalProblem(err, "", detail)
+	problem.FlightID = flightID
+	return problem
+}
+
+// joinQueueHandler hands the caller a ticket in the waiting room of a flight.
+func (h *OrderHandler) joinQueueHandler(w http.ResponseWriter, r *http.Request) {
+	flightID := r.PathValue("flightID")
+	log.Println("Handler called: joinQueueHandler for flight", flightID)
+
+	ticket, err := h.orders.JoinQueue(r.Context(), flightID)
+	if err != nil {
+		problem := ticketProblem(err, flightID, "Failed to join the waiting room")
+		if problem.Status >= http.StatusInternalServerError {
+			log.Printf("Failed to join waiting room: %v", err)
+		}
+		writeProblem(w, r, problem)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// getTicketHandler returns the state of a waiting room ticket and its place in the queue.
+func (h *OrderHandler) getTicketHandler(w http.ResponseWriter, r *http.Request) {
+	flightID, ticketID := r.PathValue("flightID"), r.PathValue("ticketID")
+
+	ticket, err := h.orders.Ticket(r.Context(), flightID, ticketID)
+	if err != nil {
+		writeProblem(w, r, ticketProblem(err, flightID, "Failed to get ticket"))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// ticketEventsHandler streams a ticket as "ticket" SSE events whenever its
+// state or queue position changes. The stream ends once the ticket has been
+// redeemed or has expired.
+func (h *OrderHandler) ticketEventsHandler(w http.ResponseWriter, r *http.Request) {
+	flightID, ticketID := r.PathValue("flightID"), r.PathValue("ticketID")
+	log.Printf("Handler called: ticketEventsHandler for ticket %s of flight %s\n", ticketID, flightID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, newProblem(http.StatusInternalServerError, domain.CodeStreamingUnsupported, "Streaming unsupported"))
+		return
+	}
+
+	sub := h.orders.WatchTicket(flightID, ticketID)
+	defer sub.Close()
+
+	headersWritten := false
+	keepAlive := time.NewTicker(h.cfg.SSEKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case u, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if u.Err != nil {
+				if !headersWritten {
+					writeProblem(w, r, ticketProblem(u.Err, flightID, "Failed to get ticket"))
+				}
+				return
+			}
+
+			ticket := u.Value.(domain.QueueTicket)
+			if !headersWritten {
+				sse.WriteHeaders(w)
+				w.WriteHeader(http.StatusOK)
+				headersWritten = true
+			}
+			if err := sse.Write(w, sse.Event{Name: eventTicket, Data: ticket}); err != nil {
+				log.Printf("Failed to write SSE event: %v", err)
+				return
+			}
+			flusher.Flush()
+			if ticket.State == "redeemed" || ticket.State == "expired" {
+				return
+			}
+
+		case <-keepAlive.C:
+			if !headersWritten {
+				continue
+			}
+			if err := sse.WriteComment(w, "keep-alive"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
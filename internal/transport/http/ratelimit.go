@@ -0,0 +1,70 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
+)
+
+// streamRetryAfter is suggested to clients over their streaming connection limit,
+// which frees up when one of their streams closes rather than at a known time.
+const streamRetryAfter = 5 * time.Second
+
+// rateLimited rejects requests with 429 once the caller has used up budget.
+func rateLimited(budget *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	if budget == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := budget.Allow(auth.Caller(r.Context())); !ok {
+			writeRateLimited(w, r, retryAfter, "Too many requests, slow down")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// streamLimited rejects SSE and WebSocket connections with 429 while the
+// caller already has the maximum number of streams open.
+func streamLimited(streams *ratelimit.Conns, next http.HandlerFunc) http.HandlerFunc {
+	if streams == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, ok := streams.Acquire(auth.Caller(r.Context()))
+		if !ok {
+			writeRateLimited(w, r, streamRetryAfter, "Too many open streams, close one first")
+			return
+		}
+		defer release()
+		next(w, r)
+	}
+}
+
+// longPollLimited is streamLimited for status requests that long-poll with
+// ?wait=, which hold a watcher open like a stream; other requests pass.
+func longPollLimited(streams *ratelimit.Conns, next http.HandlerFunc) http.HandlerFunc {
+	limited := streamLimited(streams, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("wait") == "" {
+			next(w, r)
+			return
+		}
+		limited(w, r)
+	}
+}
+
+func writeRateLimited(w http.ResponseWriter, r *http.Request, retryAfter time.Duration, detail string) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(retryAfter)))
+	writeProblem(w, r, newProblem(http.StatusTooManyRequests, domain.CodeRateLimited, detail))
+}
+
+// retryAfterSeconds rounds a delay up to whole seconds, as Retry-After requires.
+func retryAfterSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
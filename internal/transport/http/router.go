@@ -3,12 +3,15 @@ package http
 import (
 	"net/http"
 
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
 	"github.com/EyalShahaf/temporal-seats/internal/config"
-	"go.temporal.io/sdk/client"
+	"github.com/EyalShahaf/temporal-seats/internal/orders"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
 )
 
 // NewRouter creates and configures the main HTTP router for the service.
-func NewRouter(cfg config.Config, temporal client.Client) http.Handler {
+// authn authenticates API requests; nil disables authentication.
+func NewRouter(cfg config.Config, svc *orders.Service, limits ratelimit.Limits, authn auth.Authenticator) http.Handler {
 	mux := http.NewServeMux()
 
 	// Health check endpoint
@@ -18,17 +21,24 @@ func NewRouter(cfg config.Config, temporal client.Client) http.Handler {
 		w.Write([]byte(`{"status":"healthy","service":"temporal-seats-api"}`))
 	})
 
-	orderHandler := NewOrderHandler(temporal)
+	orderHandler := NewOrderHandler(cfg, svc, limits)
 	orderHandler.attachOrderRoutes(mux)
+	mux.HandleFunc("GET /openapi.json", orderHandler.spec.serveJSON)
+
+	// Reject requests that do not match the OpenAPI document before they reach Temporal.
+	handler := orderHandler.spec.validate(mux)
+	handler = authenticate(authn, handler)
+	handler = withClientIP(handler)
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Last-Event-ID, If-None-Match, Authorization, X-API-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Retry-After")
+		w.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(204)
 			return
 		}
-		mux.ServeHTTP(w, r)
+		handler.ServeHTTP(w, r)
 	})
 }
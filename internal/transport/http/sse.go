@@ -0,0 +1,169 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/realtime/sse"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+)
+
+// SSE event names emitted on /orders/{id}/events.
+const (
+	eventState         = "state"
+	eventPayment       = "payment"
+	eventExpiryWarning = "expiry-warning"
+)
+
+// sseHandler streams order changes as named SSE events. State is pushed only
+// when the order version changes; event IDs carry that version so a reconnecting
+// client sending Last-Event-ID is not sent a state it already has.
+func (h *OrderHandler) sseHandler(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+	log.Printf("Handler called: sseHandler for order %s\n", orderID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, orderProblem(http.StatusInternalServerError, domain.CodeStreamingUnsupported, "Streaming unsupported", orderID))
+		return
+	}
+
+	if err := h.orders.Authorize(r.Context(), orderID); err != nil {
+		writeProblem(w, r, temporalProblem(err, orderID, "Failed to get order status"))
+		return
+	}
+
+	sub := h.orders.WatchOrder(orderID)
+	defer sub.Close()
+
+	api := apiVersionOf(r)
+	lastEventID := r.Header.Get("Last-Event-ID")
+	headersWritten := false
+
+	keepAlive := time.NewTicker(h.cfg.SSEKeepAlive)
+	defer keepAlive.Stop()
+
+	expiryTimer := time.NewTimer(time.Hour)
+	expiryTimer.Stop()
+	defer expiryTimer.Stop()
+
+	var prev *workflows.OrderState
+	var warnedFor time.Time
+
+	write := func(events ...sse.Event) bool {
+		for _, ev := range events {
+			if err := sse.Write(w, ev); err != nil {
+				log.Printf("Failed to write SSE event: %v", err)
+				return false
+			}
+		}
+		flusher.Flush()
+		return true
+	}
+
+	// scheduleExpiryWarning arms the timer for the current hold, or returns the
+	// warning event right away if the hold is already inside the warning window.
+	scheduleExpiryWarning := func(state workflows.OrderState) []sse.Event {
+		expiryTimer.Stop()
+		if state.State != "SEATS_SELECTED" || state.HoldExpiresAt.IsZero() || state.HoldExpiresAt.Equal(warnedFor) {
+			return nil
+		}
+		untilWarning := time.Until(state.HoldExpiresAt.Add(-h.cfg.HoldExpiryWarning))
+		if untilWarning > 0 {
+			expiryTimer.Reset(untilWarning)
+			return nil
+		}
+		warnedFor = state.HoldExpiresAt
+		return []sse.Event{expiryWarningEvent(state.HoldExpiresAt)}
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Println("Client disconnected from SSE stream")
+			return
+
+		case u, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if u.Err != nil {
+				// Once streaming has started the error can only end the stream.
+				if !headersWritten {
+					writeProblem(w, r, temporalProblem(u.Err, orderID, "Failed to get order status"))
+				}
+				return
+			}
+
+			state := u.Value.(workflows.OrderState)
+			if !headersWritten {
+				sse.WriteHeaders(w)
+				w.WriteHeader(http.StatusOK)
+				headersWritten = true
+			}
+
+			// A resumed client that already has this version only needs later changes.
+			var events []sse.Event
+			if resumed := prev == nil && lastEventID == strconv.FormatInt(state.Version, 10); !resumed {
+				events = orderEvents(api, prev, state)
+			}
+			events = append(events, scheduleExpiryWarning(state)...)
+			prev = &state
+
+			if !write(events...) {
+				return
+			}
+
+		case <-expiryTimer.C:
+			if prev == nil {
+				continue
+			}
+			warnedFor = prev.HoldExpiresAt
+			if !write(expiryWarningEvent(prev.HoldExpiresAt)) {
+				return
+			}
+
+		case <-keepAlive.C:
+			if !headersWritten {
+				continue
+			}
+			if err := sse.WriteComment(w, "keep-alive"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// orderEvents builds the events describing the change from prev to state.
+func orderEvents(api apiVersion, prev *workflows.OrderState, state workflows.OrderState) []sse.Event {
+	id := strconv.FormatInt(state.Version, 10)
+	events := []sse.Event{{ID: id, Name: eventState, Data: api.orderState(state)}}
+
+	prevPayment := ""
+	if prev != nil {
+		prevPayment = prev.PaymentStatus
+	}
+	if state.PaymentStatus != "" && state.PaymentStatus != prevPayment {
+		events = append(events, sse.Event{ID: id, Name: eventPayment, Data: domain.PaymentEvent{
+			PaymentStatus: state.PaymentStatus,
+			AttemptsLeft:  state.AttemptsLeft,
+			LastError:     state.LastPaymentErr,
+		}})
+	}
+	return events
+}
+
+func expiryWarningEvent(expiresAt time.Time) sse.Event {
+	secondsLeft := int(time.Until(expiresAt).Seconds())
+	if secondsLeft < 0 {
+		secondsLeft = 0
+	}
+	return sse.Event{Name: eventExpiryWarning, Data: domain.ExpiryWarningEvent{
+		HoldExpiresAt: expiresAt,
+		SecondsLeft:   secondsLeft,
+	}}
+}
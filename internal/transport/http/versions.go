@@ -0,0 +1,107 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+)
+
+// apiVersion selects the payload shapes a route responds with.
+type apiVersion int
+
+const (
+	// apiLegacy is the original unversioned API, which exposes the raw workflow structs.
+	apiLegacy apiVersion = iota
+	// apiV1 maps every payload to the camelCase domain DTOs.
+	apiV1
+)
+
+type apiVersionKey struct{}
+
+// withAPIVersion tags requests reaching next with the API version of their route.
+func withAPIVersion(version apiVersion, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+	}
+}
+
+// apiVersionOf returns the API version of the route that matched r.
+func apiVersionOf(r *http.Request) apiVersion {
+	if v, ok := r.Context().Value(apiVersionKey{}).(apiVersion); ok {
+		return v
+	}
+	return apiLegacy
+}
+
+// orderState returns the order state payload for this API version. The legacy
+// payload is the workflow state without the caller identities, which only the
+// server uses for ownership and hold limit checks, and without the hold
+// tokens, which only the order workflow uses.
+func (v apiVersion) orderState(state workflows.OrderState) any {
+	if v == apiV1 {
+		return toStatusResponse(state)
+	}
+	state.Owner, state.Customer = "", ""
+	state.HoldTokens = nil
+	return state
+}
+
+// orderETag returns the ETag of the order state payload at version. The /v1
+// tag names its representation, so that a cache never answers one shape with
+// the other; the legacy tag is the bare version it always was.
+func (v apiVersion) orderETag(version int64) string {
+	if v == apiV1 {
+		return `"v1-` + strconv.FormatInt(version, 10) + `"`
+	}
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// flightAvailability returns the seat availability payload for this API version.
+func (v apiVersion) flightAvailability(avail domain.FlightAvailability) any {
+	if v == apiV1 {
+		return avail
+	}
+	return legacyFlightAvailability{
+		FlightID:  avail.FlightID,
+		Available: avail.Available,
+		Held:      avail.Held,
+		Confirmed: avail.Confirmed,
+		Blocked:   avail.Blocked,
+		Total:     avail.Total,
+	}
+}
+
+// legacyFlightAvailability is the unversioned availability payload.
+type legacyFlightAvailability struct {
+	FlightID  string   `json:"flightID"`
+	Available []string `json:"available"`
+	Held      []string `json:"held"`
+	Confirmed []string `json:"confirmed"`
+	Blocked   []string `json:"blocked"`
+	Total     int      `json:"total"`
+}
+
+// toStatusResponse maps the workflow state to the public status DTO.
+func toStatusResponse(state workflows.OrderState) domain.GetStatusResponse {
+	resp := domain.GetStatusResponse{
+		State:         state.State,
+		Seats:         state.Seats,
+		AttemptsLeft:  state.AttemptsLeft,
+		LastError:     state.LastPaymentErr,
+		PaymentStatus: state.PaymentStatus,
+		Version:       state.Version,
+		SeatsError:    state.SeatsError,
+		Waitlisted:    state.Waitlisted,
+	}
+	if resp.Seats == nil {
+		resp.Seats = []string{}
+	}
+	if !state.HoldExpiresAt.IsZero() {
+		expiresAt := state.HoldExpiresAt
+		resp.HoldExpiresAt = &expiresAt
+	}
+	return resp
+}
@@ -0,0 +1,299 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/auth"
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/EyalShahaf/temporal-seats/internal/ratelimit"
+	"github.com/EyalShahaf/temporal-seats/internal/realtime"
+	"github.com/EyalShahaf/temporal-seats/internal/workflows"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteTimeout = 10 * time.Second
+	wsMaxMessage   = 64 << 10
+	// wsMaxSubscriptions caps the orders and flights one connection watches;
+	// every subscription polls Temporal until it is closed.
+	wsMaxSubscriptions = 32
+)
+
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || origin == "http://localhost:5173" {
+			return true
+		}
+		u, err := url.Parse(origin)
+		return err == nil && u.Host == r.Host
+	},
+}
+
+// wsSession is a single WebSocket connection with its order and flight subscriptions.
+type wsSession struct {
+	h    *OrderHandler
+	api  apiVersion
+	conn *websocket.Conn
+	ctx  context.Context
+	out  chan domain.WSServerMessage
+
+	mu   sync.Mutex
+	subs map[string]*realtime.Subscription
+}
+
+// wsHandler upgrades the connection to a WebSocket over which a client can
+// subscribe to any number of orders and flights and send seat/payment commands.
+func (h *OrderHandler) wsHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with an HTTP error.
+		log.Printf("Failed to upgrade WebSocket: %v", err)
+		return
+	}
+	log.Println("Handler called: wsHandler, client connected")
+
+	ctx, cancel := context.WithCancel(r.Context())
+	s := &wsSession{
+		h:    h,
+		api:  apiVersionOf(r),
+		conn: conn,
+		ctx:  ctx,
+		out:  make(chan domain.WSServerMessage, 16),
+		subs: make(map[string]*realtime.Subscription),
+	}
+
+	go s.writeLoop(cancel)
+	s.readLoop()
+
+	cancel()
+	s.closeAll()
+	conn.Close()
+	log.Println("WebSocket client disconnected")
+}
+
+func (s *wsSession) readLoop() {
+	pingInterval := s.h.cfg.WSPingInterval
+	s.conn.SetReadLimit(wsMaxMessage)
+	s.conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+	})
+
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg domain.WSClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.sendProblem("", "", "", *invalidWSMessage("Invalid message"))
+			continue
+		}
+		// Commands are validated against the OpenAPI schema, like REST request bodies.
+		if err := s.h.spec.validateWSMessage(data); err != nil {
+			s.sendProblem(msg.ID, msg.OrderID, msg.FlightID, *invalidWSMessage(err.Error()))
+			continue
+		}
+		s.handle(msg)
+	}
+}
+
+// writeLoop is the only goroutine writing to the connection.
+func (s *wsSession) writeLoop(cancel context.CancelFunc) {
+	ping := time.NewTicker(s.h.cfg.WSPingInterval)
+	defer ping.Stop()
+	defer cancel()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			return
+		case msg := <-s.out:
+			s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := s.conn.WriteJSON(msg); err != nil {
+				s.conn.Close()
+				return
+			}
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				s.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) send(msg domain.WSServerMessage) {
+	select {
+	case s.out <- msg:
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *wsSession) handle(msg domain.WSClientMessage) {
+	var problem *domain.Problem
+	switch msg.Type {
+	case domain.WSSubscribe:
+		problem = s.subscribe(msg)
+	case domain.WSUnsubscribe:
+		problem = s.unsubscribe(msg)
+	case domain.WSUpdateSeats:
+		if problem = s.rateLimit(s.h.limits.Mutations); problem != nil {
+			break
+		}
+		if msg.OrderID == "" {
+			problem = invalidWSMessage("orderId is required")
+		} else if err := s.h.orders.UpdateSeats(s.ctx, msg.OrderID, msg.Seats); err != nil {
+			log.Printf("Failed to signal workflow: %v", err)
+			p := temporalProblem(err, msg.OrderID, "Failed to update seats")
+			problem = &p
+		}
+	case domain.WSSubmitPayment:
+		if problem = s.rateLimit(s.h.limits.Mutations); problem != nil {
+			break
+		}
+		if msg.OrderID == "" {
+			problem = invalidWSMessage("orderId is required")
+		} else if err := s.h.orders.SubmitPayment(s.ctx, msg.OrderID, msg.Code); err != nil {
+			log.Printf("Failed to signal workflow: %v", err)
+			p := temporalProblem(err, msg.OrderID, "Failed to submit payment")
+			problem = &p
+		}
+	default:
+		problem = invalidWSMessage("Unknown message type")
+	}
+
+	if problem != nil {
+		s.sendProblem(msg.ID, msg.OrderID, msg.FlightID, *problem)
+		return
+	}
+	s.send(domain.WSServerMessage{Type: domain.WSAck, ID: msg.ID, OrderID: msg.OrderID, FlightID: msg.FlightID})
+}
+
+// sendProblem reports p to the client as an error message, using the same codes as the REST API.
+func (s *wsSession) sendProblem(id, orderID, flightID string, p domain.Problem) {
+	s.send(domain.WSServerMessage{Type: domain.WSError, ID: id, OrderID: orderID, FlightID: flightID, Error: p.Detail, Code: p.Code})
+}
+
+// rateLimit charges a message to the same budget as the matching REST
+// requests, so a WebSocket cannot be used to get around it: seat and payment
+// commands to the mutations, subscriptions to the availability reads.
+func (s *wsSession) rateLimit(l *ratelimit.Limiter) *domain.Problem {
+	if ok, _ := l.Allow(auth.Caller(s.ctx)); ok {
+		return nil
+	}
+	p := newProblem(http.StatusTooManyRequests, domain.CodeRateLimited, "Too many requests, slow down")
+	return &p
+}
+
+func invalidWSMessage(detail string) *domain.Problem {
+	p := newProblem(http.StatusBadRequest, domain.CodeInvalidRequest, detail)
+	return &p
+}
+
+func (s *wsSession) subscribe(msg domain.WSClientMessage) *domain.Problem {
+	key, problem := topicKey(msg)
+	if problem != nil {
+		return problem
+	}
+	if problem := s.rateLimit(s.h.limits.Availability); problem != nil {
+		return problem
+	}
+	if msg.OrderID != "" {
+		if err := s.h.orders.Authorize(s.ctx, msg.OrderID); err != nil {
+			p := temporalProblem(err, msg.OrderID, "Failed to subscribe")
+			return &p
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[key]; ok {
+		return nil
+	}
+	if len(s.subs) >= wsMaxSubscriptions {
+		p := newProblem(http.StatusTooManyRequests, domain.CodeRateLimited, "Too many subscriptions, unsubscribe first")
+		return &p
+	}
+	var sub *realtime.Subscription
+	if msg.OrderID != "" {
+		sub = s.h.orders.WatchOrder(msg.OrderID)
+	} else {
+		sub = s.h.orders.WatchFlight(msg.FlightID)
+	}
+	s.subs[key] = sub
+	go s.forward(key, sub, msg.OrderID, msg.FlightID)
+	return nil
+}
+
+func (s *wsSession) unsubscribe(msg domain.WSClientMessage) *domain.Problem {
+	key, problem := topicKey(msg)
+	if problem != nil {
+		return problem
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.subs[key]; ok {
+		sub.Close()
+		delete(s.subs, key)
+	}
+	return nil
+}
+
+// topicKey identifies the order or flight a subscription message refers to.
+func topicKey(msg domain.WSClientMessage) (string, *domain.Problem) {
+	switch {
+	case msg.OrderID != "" && msg.FlightID != "":
+		return "", invalidWSMessage("Specify either orderId or flightId")
+	case msg.OrderID != "":
+		return "order::" + msg.OrderID, nil
+	case msg.FlightID != "":
+		return "flight::" + msg.FlightID, nil
+	default:
+		return "", invalidWSMessage("orderId or flightId is required")
+	}
+}
+
+// forward relays hub updates of one subscription to the client.
+func (s *wsSession) forward(key string, sub *realtime.Subscription, orderID, flightID string) {
+	for u := range sub.C {
+		if u.Err != nil {
+			s.sendProblem("", orderID, flightID, temporalProblem(u.Err, orderID, "Subscription failed"))
+			break
+		}
+
+		var msg domain.WSServerMessage
+		switch v := u.Value.(type) {
+		case workflows.OrderState:
+			msg = domain.WSServerMessage{Type: domain.WSState, OrderID: orderID, Version: u.Version, Data: s.api.orderState(v)}
+		case domain.FlightAvailability:
+			msg = domain.WSServerMessage{Type: domain.WSAvailability, FlightID: flightID, Data: s.api.flightAvailability(v)}
+		}
+		s.send(msg)
+	}
+
+	s.mu.Lock()
+	if s.subs[key] == sub {
+		delete(s.subs, key)
+	}
+	s.mu.Unlock()
+}
+
+func (s *wsSession) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, sub := range s.subs {
+		sub.Close()
+		delete(s.subs, key)
+	}
+}
@@ -1,6 +1,8 @@
 package workflows
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/EyalShahaf/temporal-seats/internal/activities"
@@ -15,10 +17,43 @@ const (
 	GetStatusQuery      = "GetStatus"
 )
 
+// customerHoldLimitVersion is the change ID of orders counting the seats held
+// by their customer's other orders (CountHeldSeatsActivity) before taking a
+// selection. Orders started before it replay without the count.
+const customerHoldLimitVersion = "customer-hold-limit"
+
+// waitlistVersion is the change ID of orders taking the waitlist notices of
+// their seats while they are open. Orders started before it replay leaving
+// the notices unread until they are finished.
+const waitlistVersion = "seat-waitlist"
+
+// parallelSeatCommandsVersion is the change ID of orders sending their seat
+// commands maxSeatCommandsInFlight at a time (seatCommands). Orders started
+// before it replay sending them one by one.
+const parallelSeatCommandsVersion = "parallel-seat-commands"
+
 // OrderInput defines the required inputs to start the order workflow.
 type OrderInput struct {
 	OrderID  string
 	FlightID string
+	// Owner is the authenticated principal that created the order; empty when authentication is disabled.
+	Owner string
+	// Customer identifies the caller for hold limits: Owner, or the client IP when authentication is disabled.
+	Customer string
+	// Limits are the hold limits in force when the order was created.
+	Limits HoldLimits
+	// Waitlist queues the order for seats held by other orders instead of
+	// giving up on them; see seat.Command.Waitlist.
+	Waitlist bool
+	// AssignSeats, when positive, has the order pick and hold that many
+	// adjacent seats itself instead of waiting for a selection.
+	AssignSeats int
+}
+
+// HoldLimits caps the seats an order and its customer may hold. Zero disables a limit.
+type HoldLimits struct {
+	MaxSeatsPerOrder        int
+	MaxHeldSeatsPerCustomer int
 }
 
 // OrderState represents the current state of the order process.
@@ -29,6 +64,24 @@ type OrderState struct {
 	AttemptsLeft   int       `json:"AttemptsLeft"`
 	LastPaymentErr string    `json:"LastPaymentErr,omitempty"`
 	PaymentStatus  string    `json:"PaymentStatus,omitempty"` // NEW: trying, retrying, failed, success
+	// Version is incremented on every state change so clients can detect updates.
+	Version int64 `json:"Version"`
+	// Owner is the principal allowed to act on the order (see OrderInput.Owner).
+	Owner string `json:"Owner,omitempty"`
+	// FlightID and Customer are copied from the OrderInput so the API can check hold limits.
+	FlightID string `json:"FlightID,omitempty"`
+	Customer string `json:"Customer,omitempty"`
+	// SeatsError explains why the last seat selection was rejected; cleared once a selection is accepted.
+	SeatsError string `json:"SeatsError,omitempty"`
+	// Waitlisted lists the selected seats the order is still waiting for. Payment
+	// is refused until every waitlisted seat has been granted.
+	Waitlisted []string `json:"Waitlisted,omitempty"`
+	// HoldTokens maps the seats held by the order to the token of their hold,
+	// which the seat requires to release or confirm them (see seat.Command.HoldToken).
+	// Orders started before tokens replay without any: their seat commands
+	// recorded no result, and seats still on the legacy entity ignore tokens
+	// while holds carried over from it have token zero.
+	HoldTokens map[string]int64 `json:"HoldTokens,omitempty"`
 }
 
 // OrderOrchestrationWorkflow is the main Temporal workflow for an entire seat reservation and payment process.
@@ -38,8 +91,13 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 
 	// Set up initial state
 	state := OrderState{
-		State: "PENDING",
-		Seats: []string{},
+		State:      "PENDING",
+		Seats:      []string{},
+		Version:    1,
+		Owner:      input.Owner,
+		FlightID:   input.FlightID,
+		Customer:   input.Customer,
+		HoldTokens: map[string]int64{},
 	}
 
 	// Register query handler
@@ -51,15 +109,6 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 		return err
 	}
 
-	// Wait for seat selection
-	updateSeatsChan := workflow.GetSignalChannel(ctx, UpdateSeatsSignal)
-
-	// Block until seats are selected for the first time
-	// For subsequent updates, we'll use a selector inside the main loop
-	var seats []string
-	updateSeatsChan.Receive(ctx, &seats)
-
-	// Hold the selected seats using activity
 	ao := workflow.ActivityOptions{
 		StartToCloseTimeout:    10 * time.Second,
 		ScheduleToCloseTimeout: 1 * time.Minute,
@@ -67,25 +116,69 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 	}
 	ctxA := workflow.WithActivityOptions(ctx, ao)
 
-	for _, seatID := range seats {
-		cmd := seat.Command{Type: seat.CmdHold, OrderID: input.OrderID, TTL: 15 * time.Minute}
-		err := workflow.ExecuteActivity(ctxA, "SeatSignalActivity", activities.SeatSignalInput{
-			FlightID: input.FlightID, SeatID: seatID, Cmd: cmd,
-		}).Get(ctx, nil)
-		if err != nil {
-			logger.Error("Failed to hold seat", "SeatID", seatID, "Error", err)
+	// Wait for seat selection
+	updateSeatsChan := workflow.GetSignalChannel(ctx, UpdateSeatsSignal)
+
+	hold := func(string) seat.Command {
+		return seat.Command{Type: seat.CmdHold, OrderID: input.OrderID, TTL: 15 * time.Minute, Customer: input.Customer, Waitlist: input.Waitlist}
+	}
+	release := func(seatID string) seat.Command {
+		return seat.Command{Type: seat.CmdRelease, OrderID: input.OrderID, HoldToken: state.HoldTokens[seatID]}
+	}
+
+	// Orders asking for adjacent seats get them assigned; if no block can be
+	// held they fall back to a selection like any other order.
+	var seats []string
+	assigned := false
+	if input.AssignSeats > 0 {
+		var reason string
+		seats, reason = assignAdjacentSeats(ctxA, input, &state)
+		if reason == "" {
+			assigned = true
+			logger.Info("Adjacent seats assigned", "Seats", seats)
 		} else {
-			logger.Info("Successfully held seat", "SeatID", seatID)
+			logger.Warn("Adjacent seats not assigned", "Count", input.AssignSeats, "Reason", reason)
+			state.SeatsError = reason
+			state.Version++
+		}
+	}
+
+	if !assigned {
+		// Block until seats are selected for the first time, skipping selections over the hold limits.
+		// For subsequent updates, we'll use a selector inside the main loop
+		for {
+			updateSeatsChan.Receive(ctx, &seats)
+			reason := checkHoldLimits(ctxA, input, nil, seats)
+			if reason == "" {
+				break
+			}
+			logger.Warn("Seat selection rejected", "Seats", seats, "Reason", reason)
+			state.SeatsError = reason
+			state.Version++
+		}
+
+		// Hold the selected seats using activity
+		for _, r := range seatCommands(ctxA, input, seats, hold) {
+			if r.Err != nil {
+				logger.Error("Failed to hold seat", "SeatID", r.SeatID, "Error", r.Err)
+				continue
+			}
+			logger.Info("Successfully held seat", "SeatID", r.SeatID, "Outcome", r.Result.Outcome, "Reason", r.Result.Reason)
+			setHoldToken(&state, r.SeatID, r.Result.HoldToken)
 		}
 	}
 
 	state.Seats = seats
+	state.SeatsError = ""
 	state.State = "SEATS_SELECTED"
 	state.HoldExpiresAt = workflow.Now(ctx).Add(15 * time.Minute)
+	state.Version++
 	logger.Info("Seats selected, hold timer started.", "Seats", state.Seats, "ExpiresAt", state.HoldExpiresAt)
 
-	// Set up payment signal channel
+	// Set up payment and waitlist signal channels
 	paymentChan := workflow.GetSignalChannel(ctx, SubmitPaymentSignal)
+	waitlistChan := workflow.GetSignalChannel(ctx, seat.WaitlistSignal)
+	waitlisting := workflow.GetVersion(ctx, waitlistVersion, workflow.DefaultVersion, 1) != workflow.DefaultVersion
 	state.AttemptsLeft = 3
 
 	// Main loop for handling signals or timer expiry
@@ -96,6 +189,7 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 		selector := workflow.NewSelector(ctx)
 		selector.AddFuture(holdTimer, func(f workflow.Future) {
 			state.State = "EXPIRED"
+			state.Version++
 			logger.Warn("Hold timer expired.")
 		})
 
@@ -103,38 +197,43 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 			var newSeats []string
 			c.Receive(ctx, &newSeats)
 
+			// A rejected selection keeps the current seats and hold timer.
+			if reason := checkHoldLimits(ctxA, input, state.Seats, newSeats); reason != "" {
+				logger.Warn("Seat update rejected", "Seats", newSeats, "Reason", reason)
+				state.SeatsError = reason
+				state.Version++
+				return
+			}
+
 			// Determine which seats to release and which to hold
 			toRelease, toHold := diffSeats(state.Seats, newSeats)
 			logger.Info("Updating seats", "ToRelease", toRelease, "ToHold", toHold)
 
 			// Release old seats
-			for _, seatID := range toRelease {
-				cmd := seat.Command{Type: seat.CmdRelease, OrderID: input.OrderID}
-				err := workflow.ExecuteActivity(ctxA, "SeatSignalActivity", activities.SeatSignalInput{
-					FlightID: input.FlightID, SeatID: seatID, Cmd: cmd,
-				}).Get(ctx, nil)
-				if err != nil {
-					logger.Error("Failed to release seat", "SeatID", seatID, "Error", err)
+			for _, r := range seatCommands(ctxA, input, toRelease, release) {
+				if r.Err != nil {
+					logger.Error("Failed to release seat", "SeatID", r.SeatID, "Error", r.Err)
 				} else {
-					logger.Info("Successfully released seat", "SeatID", seatID)
+					logger.Info("Successfully released seat", "SeatID", r.SeatID)
 				}
+				setHoldToken(&state, r.SeatID, 0)
 			}
 
 			// Hold new seats
-			for _, seatID := range toHold {
-				cmd := seat.Command{Type: seat.CmdHold, OrderID: input.OrderID, TTL: 15 * time.Minute}
-				err := workflow.ExecuteActivity(ctxA, "SeatSignalActivity", activities.SeatSignalInput{
-					FlightID: input.FlightID, SeatID: seatID, Cmd: cmd,
-				}).Get(ctx, nil)
-				if err != nil {
-					logger.Error("Failed to hold seat", "SeatID", seatID, "Error", err)
-				} else {
-					logger.Info("Successfully held seat", "SeatID", seatID)
+			for _, r := range seatCommands(ctxA, input, toHold, hold) {
+				if r.Err != nil {
+					logger.Error("Failed to hold seat", "SeatID", r.SeatID, "Error", r.Err)
+					continue
 				}
+				logger.Info("Successfully held seat", "SeatID", r.SeatID, "Outcome", r.Result.Outcome, "Reason", r.Result.Reason)
+				setHoldToken(&state, r.SeatID, r.Result.HoldToken)
 			}
 
 			state.Seats = newSeats
+			state.Waitlisted = intersectSeats(state.Waitlisted, newSeats)
+			state.SeatsError = ""
 			state.HoldExpiresAt = workflow.Now(ctx).Add(15 * time.Minute)
+			state.Version++
 			logger.Info("Hold timer has been refreshed.", "ExpiresAt", state.HoldExpiresAt)
 		})
 
@@ -144,15 +243,25 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 
 			logger.Info("Received payment signal", "PaymentCode", paymentCode, "AttemptsLeft", state.AttemptsLeft)
 
+			// Paying now would confirm only part of the selection.
+			if len(state.Waitlisted) > 0 {
+				logger.Warn("Payment refused while seats are waitlisted", "Waitlisted", state.Waitlisted)
+				state.LastPaymentErr = fmt.Sprintf("seats %v are still waitlisted", state.Waitlisted)
+				state.Version++
+				return
+			}
+
 			if state.AttemptsLeft <= 0 {
 				logger.Warn("No payment attempts left.")
 				state.PaymentStatus = "failed"
+				state.Version++
 				return
 			}
 			state.AttemptsLeft--
 
 			// Emit payment trying signal
 			state.PaymentStatus = "trying"
+			state.Version++
 			logger.Info("Payment attempt started", "AttemptsLeft", state.AttemptsLeft, "PaymentCode", paymentCode)
 
 			// Set up activity options with built-in retry policy
@@ -183,29 +292,42 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 					state.State = "FAILED"
 					logger.Error("Order failed after maximum payment attempts.", "AttemptsLeft", state.AttemptsLeft)
 				}
+				state.Version++
 			} else {
 				logger.Info("Payment successful")
 				state.PaymentStatus = "success"
+				state.Version++
 
 				// Send CONFIRM signals to all seats to permanently lock them
 				logger.Info("Payment confirmed, permanently locking seats", "Seats", state.Seats)
-				for _, seatID := range state.Seats {
-					cmd := seat.Command{Type: seat.CmdConfirm, OrderID: input.OrderID}
-					err := workflow.ExecuteActivity(ctxA, "SeatSignalActivity", activities.SeatSignalInput{
-						FlightID: input.FlightID, SeatID: seatID, Cmd: cmd,
-					}).Get(ctx, nil)
-					if err != nil {
-						logger.Error("Failed to confirm seat", "SeatID", seatID, "Error", err)
+				confirm := func(seatID string) seat.Command {
+					return seat.Command{Type: seat.CmdConfirm, OrderID: input.OrderID, HoldToken: state.HoldTokens[seatID]}
+				}
+				for _, r := range seatCommands(ctxA, input, state.Seats, confirm) {
+					if r.Err != nil {
+						logger.Error("Failed to confirm seat", "SeatID", r.SeatID, "Error", r.Err)
 					} else {
-						logger.Info("Successfully confirmed seat", "SeatID", seatID)
+						logger.Info("Successfully confirmed seat", "SeatID", r.SeatID, "Outcome", r.Result.Outcome, "Reason", r.Result.Reason)
 					}
 				}
 
 				// Set state to CONFIRMED AFTER sending confirm signals
 				state.State = "CONFIRMED"
+				state.Version++
 			}
 		})
 
+		if waitlisting {
+			selector.AddReceive(waitlistChan, func(c workflow.ReceiveChannel, more bool) {
+				var notice seat.WaitlistNotice
+				c.Receive(ctx, &notice)
+				logger.Info("Received waitlist notice", "SeatID", notice.SeatID, "Event", notice.Event, "Position", notice.Position)
+				if applyWaitlistNotice(&state, notice) {
+					state.Version++
+				}
+			})
+		}
+
 		selector.Select(ctx)
 		cancelTimer() // clean up the timer
 	}
@@ -232,15 +354,11 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 		// Best-effort attempt to release seats
 		dCtx, cancel := workflow.NewDisconnectedContext(ctx)
 		defer cancel()
-		for _, seatID := range state.Seats {
-			cmd := seat.Command{Type: seat.CmdRelease, OrderID: input.OrderID}
-			err := workflow.ExecuteActivity(dCtx, "SeatSignalActivity", activities.SeatSignalInput{
-				FlightID: input.FlightID, SeatID: seatID, Cmd: cmd,
-			}).Get(dCtx, nil)
-			if err != nil {
-				logger.Error("Failed to release seat", "SeatID", seatID, "Error", err)
+		for _, r := range seatCommands(dCtx, input, state.Seats, release) {
+			if r.Err != nil {
+				logger.Error("Failed to release seat", "SeatID", r.SeatID, "Error", r.Err)
 			} else {
-				logger.Info("Successfully released seat", "SeatID", seatID)
+				logger.Info("Successfully released seat", "SeatID", r.SeatID)
 			}
 		}
 		_ = workflow.ExecuteActivity(ctx, activities.FailOrderActivity, input.OrderID).Get(ctx, nil)
@@ -253,8 +371,308 @@ func OrderOrchestrationWorkflow(ctx workflow.Context, input OrderInput) error {
 	for {
 		// Wait for any additional signals or just keep running
 		// The workflow will be terminated by Temporal when appropriate
-		workflow.Sleep(ctx, time.Hour)
+		if err := awaitRevokedSeats(ctx, waitlistChan, &state, time.Hour); err != nil {
+			// Canceled: stop instead of spinning on a sleep that returns immediately.
+			return err
+		}
+	}
+}
+
+// awaitRevokedSeats sleeps for d, meanwhile keeping the seats of a finished
+// order in step with its administrators: seats taken back (force unconfirm or
+// reassignment) are dropped, and confirmed seats reassigned to a confirmed
+// order are added, so that its status lists what it owns. Other waitlist
+// notices came too late to matter.
+func awaitRevokedSeats(ctx workflow.Context, waitlistChan workflow.ReceiveChannel, state *OrderState, d time.Duration) error {
+	logger := workflow.GetLogger(ctx)
+	timer := workflow.NewTimer(ctx, d)
+	for fired := false; !fired; {
+		sel := workflow.NewSelector(ctx)
+		sel.AddFuture(timer, func(workflow.Future) { fired = true })
+		sel.AddReceive(waitlistChan, func(c workflow.ReceiveChannel, more bool) {
+			var notice seat.WaitlistNotice
+			c.Receive(ctx, &notice)
+			switch {
+			case notice.Event == seat.WaitlistRevoked:
+				if applyWaitlistNotice(state, notice) {
+					logger.Warn("Seat taken back by an administrator", "SeatID", notice.SeatID, "State", state.State)
+					state.Version++
+				}
+			case notice.Event == seat.WaitlistReassigned && state.State == "CONFIRMED":
+				if applyWaitlistNotice(state, notice) {
+					logger.Info("Seat reassigned by an administrator", "SeatID", notice.SeatID)
+					state.Version++
+				}
+			case notice.Event == seat.WaitlistGranted && notice.Reassigned:
+				// The order can neither confirm nor release the hold any more;
+				// it lapses on the seat. ReassignSeat refuses finished orders.
+				logger.Warn("Ignoring seat reassigned to a finished order", "SeatID", notice.SeatID, "State", state.State)
+			}
+		})
+		sel.Select(ctx)
+	}
+	return timer.Get(ctx, nil)
+}
+
+// maxSeatCommandsInFlight bounds the seat commands an order runs at once, so
+// that a group booking does not flood the seat task queue.
+const maxSeatCommandsInFlight = 5
+
+// seatCommandResult is what came of the command sent to one seat.
+type seatCommandResult struct {
+	SeatID string
+	Result seat.CommandResult
+	Err    error
+}
+
+// seatCommands sends command(seatID) to the entity of every seat of seatIDs,
+// maxSeatCommandsInFlight at a time, and returns what came of each in the
+// order of seatIDs once all are done.
+func seatCommands(ctx workflow.Context, input OrderInput, seatIDs []string, command func(seatID string) seat.Command) []seatCommandResult {
+	inFlight := maxSeatCommandsInFlight
+	if workflow.GetVersion(ctx, parallelSeatCommandsVersion, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		inFlight = 1
+	}
+	results := make([]seatCommandResult, len(seatIDs))
+	sel := workflow.NewSelector(ctx)
+	started := 0
+	start := func() {
+		i := started
+		started++
+		results[i].SeatID = seatIDs[i]
+		f := workflow.ExecuteActivity(ctx, "SeatSignalActivity", activities.SeatSignalInput{
+			FlightID: input.FlightID, SeatID: seatIDs[i], Cmd: command(seatIDs[i]),
+		})
+		sel.AddFuture(f, func(f workflow.Future) {
+			results[i].Err = f.Get(ctx, &results[i].Result)
+		})
+	}
+	for started < len(seatIDs) && started < inFlight {
+		start()
+	}
+	for range seatIDs {
+		sel.Select(ctx)
+		if started < len(seatIDs) {
+			start()
+		}
+	}
+	return results
+}
+
+// seatBatchVersion is the change ID of orders holding and releasing their
+// block of adjacent seats with one SeatBatchActivity (seatBatch). Orders
+// started before it replay sending the commands with seatCommands.
+const seatBatchVersion = "seat-batch"
+
+// seatBatchOptions schedule SeatBatchActivity. It heartbeats after every
+// seat that acknowledged its command, so a worker lost mid-batch is noticed
+// within the heartbeat timeout and the retry resumes after those seats.
+var seatBatchOptions = workflow.ActivityOptions{
+	StartToCloseTimeout:    time.Minute,
+	ScheduleToCloseTimeout: 3 * time.Minute,
+	HeartbeatTimeout:       15 * time.Second,
+	RetryPolicy:            &temporal.RetryPolicy{MaximumAttempts: 3},
+}
+
+// seatBatch sends cmd to every seat of seatIDs with one SeatBatchActivity,
+// each seat with its token from tokens if it has one, and returns what came
+// of each in the order of seatIDs. If the batch still fails after its retries,
+// the seats are sent their commands one by one with seatCommands: a seat that
+// already took the command again holds for a HOLD, keeping its token, and
+// ignores a RELEASE.
+func seatBatch(ctx workflow.Context, input OrderInput, seatIDs []string, cmd seat.Command, tokens map[string]int64) []seatCommandResult {
+	command := func(seatID string) seat.Command {
+		c := cmd
+		if token, ok := tokens[seatID]; ok {
+			c.HoldToken = token
+		}
+		return c
+	}
+	if workflow.GetVersion(ctx, seatBatchVersion, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return seatCommands(ctx, input, seatIDs, command)
+	}
+
+	var batch activities.SeatBatchResult
+	err := workflow.ExecuteActivity(workflow.WithActivityOptions(ctx, seatBatchOptions), "SeatBatchActivity", activities.SeatBatchInput{
+		FlightID: input.FlightID, SeatIDs: seatIDs, Cmd: cmd, HoldTokens: tokens,
+	}).Get(ctx, &batch)
+	if err != nil {
+		workflow.GetLogger(ctx).Warn("Seat batch failed, sending the commands one by one", "Seats", seatIDs, "Command", cmd.Type, "Error", err)
+		return seatCommands(ctx, input, seatIDs, command)
+	}
+
+	results := make([]seatCommandResult, len(seatIDs))
+	for i, seatID := range seatIDs {
+		results[i] = seatCommandResult{SeatID: seatID, Result: batch.Results[seatID]}
+	}
+	return results
+}
+
+// maxAssignAttempts bounds how often an order looks for another block of
+// adjacent seats after other orders took seats of the one it picked.
+const maxAssignAttempts = 3
+
+// assignAdjacentSeats picks the best block of input.AssignSeats free seats
+// (see seat.FindAdjacentSeats) and holds it, recording the hold tokens in
+// state. A block is held whole or not at all: if another order takes one of
+// its seats first, the seats already held are released and the next best block
+// is tried. It returns the held seats, or why no block could be assigned.
+func assignAdjacentSeats(ctx workflow.Context, input OrderInput, state *OrderState) ([]string, string) {
+	logger := workflow.GetLogger(ctx)
+	n := input.AssignSeats
+	// Only the number of seats counts against the hold limits.
+	if reason := checkHoldLimits(ctx, input, nil, make([]string, n)); reason != "" {
+		return nil, reason
+	}
+
+	// The block is held and released as a batch. Waitlisting a seat of the
+	// block would leave the group split.
+	hold := seat.Command{Type: seat.CmdHold, OrderID: input.OrderID, TTL: 15 * time.Minute, Customer: input.Customer}
+	release := seat.Command{Type: seat.CmdRelease, OrderID: input.OrderID}
+	for attempt := 1; attempt <= maxAssignAttempts; attempt++ {
+		var free []string
+		err := workflow.ExecuteActivity(ctx, "FreeSeatsActivity", activities.FreeSeatsInput{FlightID: input.FlightID}).Get(ctx, &free)
+		if err != nil {
+			logger.Error("Failed to list free seats", "Error", err)
+			return nil, "seat availability could not be read; select seats instead"
+		}
+		block := seat.FindAdjacentSeats(free, n)
+		if block == nil {
+			return nil, fmt.Sprintf("no %d adjacent seats are free; select seats instead", n)
+		}
+
+		var held []string
+		for _, r := range seatBatch(ctx, input, block, hold, nil) {
+			if r.Err != nil || r.Result.HoldToken == 0 {
+				logger.Info("Seat of the block not held", "SeatID", r.SeatID, "Error", r.Err, "Reason", r.Result.Reason)
+				continue
+			}
+			setHoldToken(state, r.SeatID, r.Result.HoldToken)
+			held = append(held, r.SeatID)
+		}
+		if len(held) == len(block) {
+			return block, ""
+		}
+
+		logger.Info("Block taken by other orders, releasing it", "Seats", block, "Attempt", attempt)
+		for _, r := range seatBatch(ctx, input, held, release, state.HoldTokens) {
+			if r.Err != nil {
+				logger.Error("Failed to release seat", "SeatID", r.SeatID, "Error", r.Err)
+			}
+			setHoldToken(state, r.SeatID, 0)
+		}
+	}
+	return nil, fmt.Sprintf("the blocks of %d adjacent seats found were taken by other orders; select seats instead", n)
+}
+
+// setHoldToken records the token of the order's hold on seatID; zero forgets it.
+func setHoldToken(state *OrderState, seatID string, token int64) {
+	if token == 0 {
+		delete(state.HoldTokens, seatID)
+		return
+	}
+	if state.HoldTokens == nil {
+		state.HoldTokens = map[string]int64{}
+	}
+	state.HoldTokens[seatID] = token
+}
+
+// checkHoldLimits returns why a selection of seats replacing the order's
+// current seats exceeds the order's hold limits, or "" if it is within them.
+// The API checks the same limits before signaling; this check covers signals
+// sent directly to the workflow. The seats held by the customer's other orders
+// are only counted when the selection adds seats to current; if they cannot
+// be counted, the selection is allowed.
+func checkHoldLimits(ctx workflow.Context, input OrderInput, current, seats []string) string {
+	limits := input.Limits
+	if limits.MaxSeatsPerOrder > 0 && len(seats) > limits.MaxSeatsPerOrder {
+		return fmt.Sprintf("an order can hold at most %d seats", limits.MaxSeatsPerOrder)
+	}
+	if limits.MaxHeldSeatsPerCustomer <= 0 || input.Customer == "" || len(seats) == 0 {
+		return ""
+	}
+	if !slices.ContainsFunc(seats, func(id string) bool { return !slices.Contains(current, id) }) {
+		return ""
+	}
+
+	if workflow.GetVersion(ctx, customerHoldLimitVersion, workflow.DefaultVersion, 1) == workflow.DefaultVersion {
+		return ""
+	}
+
+	var held int
+	err := workflow.ExecuteActivity(ctx, "CountHeldSeatsActivity", activities.CountHeldSeatsInput{
+		FlightID: input.FlightID, Customer: input.Customer, ExcludeOrderID: input.OrderID,
+	}).Get(ctx, &held)
+	if err != nil {
+		workflow.GetLogger(ctx).Error("Failed to count held seats, skipping customer hold limit", "Error", err)
+		return ""
+	}
+	if held+len(seats) > limits.MaxHeldSeatsPerCustomer {
+		return fmt.Sprintf("a customer can hold at most %d seats on this flight; %d are already held by other orders",
+			limits.MaxHeldSeatsPerCustomer, held)
+	}
+	return ""
+}
+
+// applyWaitlistNotice records a seat's waitlist change in the order state and
+// reports whether the state changed. Notices for seats the order no longer
+// wants are ignored; the seat entity learns of that from the RELEASE. Seats
+// reassigned to the order by an administrator are the exception.
+func applyWaitlistNotice(state *OrderState, notice seat.WaitlistNotice) bool {
+	adopted := false
+	if !slices.Contains(state.Seats, notice.SeatID) {
+		// A handed-over seat joins the selection, so that it is confirmed or
+		// released with the others.
+		if notice.Event != seat.WaitlistReassigned && (notice.Event != seat.WaitlistGranted || !notice.Reassigned) {
+			return false
+		}
+		state.Seats = append(slices.Clone(state.Seats), notice.SeatID)
+		adopted = true
+	}
+	waiting := slices.Contains(state.Waitlisted, notice.SeatID)
+	switch notice.Event {
+	case seat.WaitlistQueued:
+		if waiting {
+			return false
+		}
+		state.Waitlisted = append(state.Waitlisted, notice.SeatID)
+	case seat.WaitlistGranted:
+		// Granted from the waitlist, or handed over by an administrator.
+		if !adopted && !waiting && state.HoldTokens[notice.SeatID] == notice.HoldToken {
+			return false
+		}
+		setHoldToken(state, notice.SeatID, notice.HoldToken)
+		state.Waitlisted = slices.DeleteFunc(state.Waitlisted, func(s string) bool { return s == notice.SeatID })
+	case seat.WaitlistReassigned:
+		// Confirmed for the order by an administrator; there is no hold to fence.
+		if !adopted {
+			return false
+		}
+		setHoldToken(state, notice.SeatID, 0)
+		state.Waitlisted = slices.DeleteFunc(state.Waitlisted, func(s string) bool { return s == notice.SeatID })
+	case seat.WaitlistClosed, seat.WaitlistRevoked:
+		setHoldToken(state, notice.SeatID, 0)
+		state.Waitlisted = slices.DeleteFunc(state.Waitlisted, func(s string) bool { return s == notice.SeatID })
+		state.Seats = slices.DeleteFunc(slices.Clone(state.Seats), func(s string) bool { return s == notice.SeatID })
+		state.SeatsError = fmt.Sprintf("seat %s was booked by another order", notice.SeatID)
+		if notice.Event == seat.WaitlistRevoked {
+			state.SeatsError = fmt.Sprintf("seat %s was taken back by an administrator", notice.SeatID)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// intersectSeats returns the seats of seats that are also in keep.
+func intersectSeats(seats, keep []string) []string {
+	var out []string
+	for _, s := range seats {
+		if slices.Contains(keep, s) {
+			out = append(out, s)
+		}
 	}
+	return out
 }
 
 // diffSeats calculates which seats to release and which to hold.
@@ -281,5 +699,9 @@ func diffSeats(oldSeats, newSeats []string) (toRelease, toHold []string) {
 		}
 	}
 
+	// Map order is random; the seat commands must be scheduled in the same
+	// order on replay.
+	slices.Sort(toRelease)
+	slices.Sort(toHold)
 	return toRelease, toHold
 }
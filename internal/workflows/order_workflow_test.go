@@ -1,7 +1,12 @@
 package workflows_test
 
 import (
+	"context"
 	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,7 +15,12 @@ import (
 	"github.com/EyalShahaf/temporal-seats/internal/workflows"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/mocks"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
 )
 
 type OrderWorkflowTestSuite struct {
@@ -18,6 +28,10 @@ type OrderWorkflowTestSuite struct {
 	testsuite.WorkflowTestSuite
 }
 
+// seatActivities names the methods of activities.SeatActivities, to register
+// and mock them; the mocks never reach its client.
+var seatActivities *activities.SeatActivities
+
 func TestOrderWorkflowSuite(t *testing.T) {
 	suite.Run(t, new(OrderWorkflowTestSuite))
 }
@@ -27,7 +41,7 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatSignalsAndExpire() {
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterActivity(activities.ConfirmOrderActivity)
 	env.RegisterActivity(activities.FailOrderActivity)
-	env.RegisterActivity(activities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
 	env.RegisterActivity(activities.ValidatePaymentActivity)
 
 	orderID := "test-order-expire"
@@ -39,27 +53,27 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatSignalsAndExpire() {
 		// HOLD when seats are updated
 		env.
 			OnActivity(
-				activities.SeatSignalActivity,
+				seatActivities.SeatSignalActivity,
 				mock.Anything, // context
 				mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 					return input.FlightID == flightID && input.SeatID == sid &&
 						input.Cmd.Type == seat.CmdHold && input.Cmd.OrderID == orderID
 				}),
 			).
-			Return(nil).
+			Return(seat.CommandResult{}, nil).
 			Once()
 
 		// RELEASE on hold expiry
 		env.
 			OnActivity(
-				activities.SeatSignalActivity,
+				seatActivities.SeatSignalActivity,
 				mock.Anything, // context
 				mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 					return input.FlightID == flightID && input.SeatID == sid &&
 						input.Cmd.Type == seat.CmdRelease && input.Cmd.OrderID == orderID
 				}),
 			).
-			Return(nil).
+			Return(seat.CommandResult{}, nil).
 			Once()
 	}
 
@@ -97,7 +111,7 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_PaymentSuccess() {
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterActivity(activities.ConfirmOrderActivity)
 	env.RegisterActivity(activities.FailOrderActivity)
-	env.RegisterActivity(activities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
 	env.RegisterActivity(activities.ValidatePaymentActivity)
 
 	orderID := "test-order-success"
@@ -106,9 +120,9 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_PaymentSuccess() {
 
 	// Expect HOLD activity calls
 	for _, sid := range seats {
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdHold
-		})).Return(nil).Once()
+		})).Return(seat.CommandResult{}, nil).Once()
 	}
 
 	// Mock the payment activity to always succeed
@@ -150,7 +164,7 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_PaymentFailureRetries() {
 	env.RegisterActivity(activities.ConfirmOrderActivity)
 	env.RegisterActivity(activities.FailOrderActivity)
 	env.RegisterActivity(activities.ValidatePaymentActivity) // Need to register it to mock it
-	env.RegisterActivity(activities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
 
 	orderID := "test-order-fail"
 	flightID := "test-flight-fail"
@@ -158,12 +172,12 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_PaymentFailureRetries() {
 
 	// Expect HOLD and RELEASE activity calls
 	for _, sid := range seats {
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdHold
-		})).Return(nil).Once()
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		})).Return(seat.CommandResult{}, nil).Once()
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdRelease
-		})).Return(nil).Once()
+		})).Return(seat.CommandResult{}, nil).Once()
 	}
 
 	// Mock the payment activity to always fail (Temporal will retry 3 times internally)
@@ -204,7 +218,7 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatUpdateDuringPayment() {
 	env.RegisterActivity(activities.ConfirmOrderActivity)
 	env.RegisterActivity(activities.FailOrderActivity)
 	env.RegisterActivity(activities.ValidatePaymentActivity)
-	env.RegisterActivity(activities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
 
 	orderID := "test-order-seat-update"
 	flightID := "test-flight-seat-update"
@@ -213,20 +227,20 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatUpdateDuringPayment() {
 
 	// Expect HOLD activity calls for initial seats
 	for _, sid := range initialSeats {
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdHold
-		})).Return(nil).Once()
+		})).Return(seat.CommandResult{}, nil).Once()
 		// Expect RELEASE when seats are updated
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdRelease
-		})).Return(nil).Once()
+		})).Return(seat.CommandResult{}, nil).Once()
 	}
 
 	// Expect HOLD activity calls for updated seats
 	for _, sid := range updatedSeats {
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdHold
-		})).Return(nil).Once()
+		})).Return(seat.CommandResult{}, nil).Once()
 	}
 
 	// Mock payment activity to succeed
@@ -272,7 +286,7 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_ConcurrentSignals() {
 	env.RegisterActivity(activities.ConfirmOrderActivity)
 	env.RegisterActivity(activities.FailOrderActivity)
 	env.RegisterActivity(activities.ValidatePaymentActivity)
-	env.RegisterActivity(activities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
 
 	orderID := "test-order-concurrent"
 	flightID := "test-flight-concurrent"
@@ -280,9 +294,9 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_ConcurrentSignals() {
 
 	// Expect HOLD activity calls
 	for _, sid := range seats {
-		env.OnActivity(activities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(input activities.SeatSignalInput) bool {
 			return input.FlightID == flightID && input.SeatID == sid && input.Cmd.Type == seat.CmdHold
-		})).Return(nil).Once()
+		})).Return(seat.CommandResult{}, nil).Once()
 	}
 
 	// Mock payment activity to succeed
@@ -323,7 +337,7 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_NoSeatsSelected() {
 	env := s.NewTestWorkflowEnvironment()
 	env.RegisterActivity(activities.ConfirmOrderActivity)
 	env.RegisterActivity(activities.FailOrderActivity)
-	env.RegisterActivity(activities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
 	env.RegisterActivity(activities.ValidatePaymentActivity)
 
 	orderID := "test-order-no-seats"
@@ -358,3 +372,827 @@ func (s *OrderWorkflowTestSuite) TestOrderWorkflow_NoSeatsSelected() {
 	s.Equal("CONFIRMED", st.State)
 	s.Empty(st.Seats)
 }
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_RejectsSeatsOverHoldLimits() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.ConfirmOrderActivity)
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.CountHeldSeatsActivity)
+
+	input := workflows.OrderInput{
+		OrderID:  "test-order-limits",
+		FlightID: "test-flight-limits",
+		Customer: "apikey:partner-a",
+		Limits:   workflows.HoldLimits{MaxSeatsPerOrder: 2, MaxHeldSeatsPerCustomer: 3},
+	}
+
+	// Other orders of the same customer already hold two seats on the flight.
+	env.OnActivity(seatActivities.CountHeldSeatsActivity, mock.Anything, activities.CountHeldSeatsInput{
+		FlightID: input.FlightID, Customer: input.Customer, ExcludeOrderID: input.OrderID,
+	}).Return(2, nil).Twice()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+		return in.SeatID == "1A" && in.Cmd.Type == seat.CmdHold && in.Cmd.Customer == input.Customer
+	})).Return(seat.CommandResult{}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).Return(seat.CommandResult{}, nil).Maybe()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	// Too many seats for one order: rejected without counting the customer's holds.
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B", "1C"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("PENDING", st.State)
+		s.Equal("an order can hold at most 2 seats", st.SeatsError)
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, time.Second)
+
+	// Two seats held elsewhere plus two new ones exceed the customer limit of three.
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("PENDING", st.State)
+		s.Contains(st.SeatsError, "at most 3 seats")
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A"})
+	}, 2*time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Equal([]string{"1A"}, st.Seats)
+		s.Empty(st.SeatsError)
+		// Adding no seat cannot raise the customer's holds, so it is not counted.
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A"})
+	}, 3*time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal([]string{"1A"}, st.Seats)
+		s.Empty(st.SeatsError)
+		env.CancelWorkflow()
+	}, 4*time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_HoldLimitsBeforeCustomerCount() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.CountHeldSeatsActivity)
+
+	input := workflows.OrderInput{
+		OrderID:  "test-order-limits-v0",
+		FlightID: "test-flight-limits-v0",
+		Customer: "apikey:partner-a",
+		Limits:   workflows.HoldLimits{MaxHeldSeatsPerCustomer: 1},
+	}
+
+	// An order started before the customer count replays without it.
+	env.OnGetVersion("customer-hold-limit", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	env.OnActivity(seatActivities.CountHeldSeatsActivity, mock.Anything, mock.Anything).Return(5, nil).Maybe()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).Return(seat.CommandResult{}, nil)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Empty(st.SeatsError)
+		env.CancelWorkflow()
+	}, time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertActivityNotCalled(s.T(), "CountHeldSeatsActivity", mock.Anything, mock.Anything)
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_WaitlistedSeats() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.ConfirmOrderActivity)
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(activities.ValidatePaymentActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-waitlist", FlightID: "test-flight-waitlist", Waitlist: true}
+
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+		return in.Cmd.Type == seat.CmdHold && in.Cmd.Waitlist
+	})).Return(seat.CommandResult{}, nil).Twice()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+		return in.Cmd.Type == seat.CmdConfirm
+	})).Return(seat.CommandResult{}, nil).Twice()
+	env.OnActivity(activities.ValidatePaymentActivity, mock.Anything, input.OrderID, "12345").Return("PAYMENT_SUCCESSFUL", nil).Once()
+	env.OnActivity(activities.ConfirmOrderActivity, mock.Anything, input.OrderID).Return(nil).Once()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+	notice := func(event seat.WaitlistEvent, seatID string) seat.WaitlistNotice {
+		return seat.WaitlistNotice{FlightID: input.FlightID, SeatID: seatID, Event: event, Position: 1}
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(seat.WaitlistSignal, notice(seat.WaitlistQueued, "1B"))
+	}, time.Second)
+
+	// Payment is refused, without using up an attempt, while 1B is waitlisted.
+	env.RegisterDelayedCallback(func() {
+		s.Equal([]string{"1B"}, status().Waitlisted)
+		env.SignalWorkflow(workflows.SubmitPaymentSignal, "12345")
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Equal(3, st.AttemptsLeft)
+		s.Contains(st.LastPaymentErr, "waitlisted")
+		env.SignalWorkflow(seat.WaitlistSignal, notice(seat.WaitlistGranted, "1B"))
+	}, 3*time.Second)
+
+	env.RegisterDelayedCallback(func() {
+		s.Empty(status().Waitlisted)
+		env.SignalWorkflow(workflows.SubmitPaymentSignal, "12345")
+	}, 4*time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("CONFIRMED", status().State)
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_WaitlistNoticesUnreadBeforeVersion() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.ConfirmOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(activities.ValidatePaymentActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-waitlist-v0", FlightID: "test-flight-waitlist-v0"}
+
+	// An order started before the waitlist replays without reading notices
+	// while open: a queued seat does not hold up payment, and both seats are
+	// confirmed.
+	env.OnGetVersion("seat-waitlist", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+		return in.Cmd.Type == seat.CmdHold
+	})).Return(seat.CommandResult{}, nil).Twice()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+		return in.Cmd.Type == seat.CmdConfirm
+	})).Return(seat.CommandResult{}, nil).Twice()
+	env.OnActivity(activities.ValidatePaymentActivity, mock.Anything, input.OrderID, "12345").Return("PAYMENT_SUCCESSFUL", nil).Once()
+	env.OnActivity(activities.ConfirmOrderActivity, mock.Anything, input.OrderID).Return(nil).Once()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{FlightID: input.FlightID, SeatID: "1B", Event: seat.WaitlistQueued, Position: 1})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.SubmitPaymentSignal, "12345")
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal("CONFIRMED", st.State)
+		s.Equal([]string{"1A", "1B"}, st.Seats)
+		s.Empty(st.Waitlisted)
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_WaitlistClosedDropsSeat() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-closed", FlightID: "test-flight-closed", Waitlist: true}
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).Return(seat.CommandResult{}, nil).Maybe()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "1B", Event: seat.WaitlistQueued})
+		// Notices about seats the order did not select are ignored.
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "2C", Event: seat.WaitlistQueued})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "1B", Event: seat.WaitlistClosed})
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal([]string{"1A"}, st.Seats)
+		s.Empty(st.Waitlisted)
+		s.Equal("seat 1B was booked by another order", st.SeatsError)
+		env.CancelWorkflow()
+	}, 3*time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_RevokedHoldDropsSeat() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-revoked", FlightID: "test-flight-revoked"}
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).Return(seat.CommandResult{}, nil).Maybe()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "1A", Event: seat.WaitlistRevoked})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal([]string{"1B"}, st.Seats)
+		s.Equal("seat 1A was taken back by an administrator", st.SeatsError)
+		env.CancelWorkflow()
+	}, 2*time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_UsesHoldTokens() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.ConfirmOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(activities.ValidatePaymentActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-tokens", FlightID: "test-flight-tokens", Waitlist: true}
+	command := func(seatID string, t seat.CommandType, token int64) interface{} {
+		return mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+			return in.SeatID == seatID && in.Cmd.Type == t && in.Cmd.HoldToken == token
+		})
+	}
+
+	// 1A and 2A are held right away, 1B only once the waitlist grants it.
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("1A", seat.CmdHold, 0)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 7}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("2A", seat.CmdHold, 0)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 3}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("1B", seat.CmdHold, 0)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied, Reason: "waitlisted at position 1"}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("2A", seat.CmdRelease, 3)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("1A", seat.CmdConfirm, 7)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("1B", seat.CmdConfirm, 12)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied}, nil).Once()
+	env.OnActivity(activities.ValidatePaymentActivity, mock.Anything, input.OrderID, "12345").Return("PAYMENT_SUCCESSFUL", nil).Once()
+	env.OnActivity(activities.ConfirmOrderActivity, mock.Anything, input.OrderID).Return(nil).Once()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "2A"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "1B", Event: seat.WaitlistQueued})
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal(map[string]int64{"1A": 7}, status().HoldTokens)
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "1B", Event: seat.WaitlistGranted, HoldToken: 12})
+	}, 3*time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal(map[string]int64{"1A": 7, "1B": 12}, status().HoldTokens)
+		env.SignalWorkflow(workflows.SubmitPaymentSignal, "12345")
+	}, 4*time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("CONFIRMED", status().State)
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatCommandsRunInParallel() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-group", FlightID: "test-flight-group"}
+	seats := []string{"1A", "1B", "1C", "1D", "1E", "1F", "2A", "2B", "2C"}
+
+	// Every seat command takes a second; each seat gets its row number as token.
+	// The commands run on concurrent activity goroutines.
+	var mu sync.Mutex
+	var released []string
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, in activities.SeatSignalInput) (seat.CommandResult, error) {
+			if in.Cmd.Type == seat.CmdRelease {
+				s.Equal(int64(in.SeatID[0]-'0'), in.Cmd.HoldToken)
+				mu.Lock()
+				released = append(released, in.SeatID)
+				mu.Unlock()
+			}
+			return seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: int64(in.SeatID[0] - '0')}, nil
+		}).After(time.Second)
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Once()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, seats)
+	}, 0)
+	// Nine holds five at a time take two rounds, not nine.
+	env.RegisterDelayedCallback(func() {
+		s.Equal("PENDING", status().State)
+	}, 1500*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Len(st.HoldTokens, len(seats))
+		s.Equal(int64(2), st.HoldTokens["2C"])
+	}, 2500*time.Millisecond)
+
+	// The hold expires and every seat is released, in parallel as well.
+	env.RegisterDelayedCallback(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		s.ElementsMatch(seats, released)
+		env.CancelWorkflow()
+	}, 16*time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatCommandsOneByOneBeforeVersion() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-group-v0", FlightID: "test-flight-group-v0"}
+
+	// An order started before the parallel commands replays sending them one
+	// by one; every seat command takes a second.
+	env.OnGetVersion("parallel-seat-commands", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied}, nil).After(time.Second)
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B", "1C"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("PENDING", status().State)
+	}, 2500*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("SEATS_SELECTED", status().State)
+		env.CancelWorkflow()
+	}, 3500*time.Millisecond)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+	s.True(env.IsWorkflowCompleted())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatCommandsReplayInOrder() {
+	// Each run schedules the seat commands of a selection change; a replay
+	// must schedule them in the same order, and each token must stay with
+	// its seat.
+	run := func() (holds, releases []string, tokens map[string]int64) {
+		env := s.NewTestWorkflowEnvironment()
+		env.RegisterActivity(activities.FailOrderActivity)
+		env.RegisterActivity(seatActivities.SeatSignalActivity)
+
+		input := workflows.OrderInput{OrderID: "test-order-replay", FlightID: "test-flight-replay"}
+		// The commands run on concurrent activity goroutines.
+		var mu sync.Mutex
+		scheduled := map[int]activities.SeatSignalInput{}
+		cancelled := false
+		env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).
+			Return(func(ctx context.Context, in activities.SeatSignalInput) (seat.CommandResult, error) {
+				id, err := strconv.Atoi(activity.GetInfo(ctx).ActivityID)
+				s.Require().NoError(err)
+				mu.Lock()
+				if !cancelled {
+					scheduled[id] = in
+				}
+				mu.Unlock()
+				// The token names the seat, so a token on the wrong seat shows.
+				return seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: seatToken(in.SeatID)}, nil
+			})
+		env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"3C", "1A", "2F", "1B", "4D", "2A"})
+		}, 0)
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"5E", "2F", "3A", "1B", "5F"})
+		}, time.Second)
+		env.RegisterDelayedCallback(func() {
+			res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+			s.Require().NoError(err)
+			var st workflows.OrderState
+			s.Require().NoError(res.Get(&st))
+			tokens = st.HoldTokens
+			mu.Lock()
+			cancelled = true
+			mu.Unlock()
+			env.CancelWorkflow()
+		}, 2*time.Second)
+
+		env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+		s.True(env.IsWorkflowCompleted())
+
+		for _, id := range slices.Sorted(maps.Keys(scheduled)) {
+			in := scheduled[id]
+			switch in.Cmd.Type {
+			case seat.CmdHold:
+				holds = append(holds, in.SeatID)
+			case seat.CmdRelease:
+				s.Equal(seatToken(in.SeatID), in.Cmd.HoldToken, in.SeatID)
+				releases = append(releases, in.SeatID)
+			}
+		}
+		return holds, releases, tokens
+	}
+
+	holds, releases, tokens := run()
+	s.Equal([]string{"3C", "1A", "2F", "1B", "4D", "2A", "3A", "5E", "5F"}, holds)
+	s.Equal([]string{"1A", "2A", "3C", "4D"}, releases)
+	s.Equal(map[string]int64{"1B": seatToken("1B"), "2F": seatToken("2F"), "3A": seatToken("3A"), "5E": seatToken("5E"), "5F": seatToken("5F")}, tokens)
+
+	for range 5 {
+		replayHolds, replayReleases, replayTokens := run()
+		s.Equal(holds, replayHolds)
+		s.Equal(releases, replayReleases)
+		s.Equal(tokens, replayTokens)
+	}
+}
+
+// seatToken is a hold token unique to seatID, such as 301 for 3A.
+func seatToken(seatID string) int64 {
+	return int64(seatID[0]-'0')*100 + int64(seatID[1]-'A'+1)
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_AssignsAdjacentSeats() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatBatchActivity)
+	env.RegisterActivity(seatActivities.FreeSeatsActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-assign", FlightID: "test-flight-assign", AssignSeats: 3}
+	batch := func(t seat.CommandType, tokens map[string]int64, seatIDs ...string) interface{} {
+		return mock.MatchedBy(func(in activities.SeatBatchInput) bool {
+			return slices.Equal(in.SeatIDs, seatIDs) && in.Cmd.Type == t && !in.Cmd.Waitlist && maps.Equal(in.HoldTokens, tokens)
+		})
+	}
+	granted := func(token int64) seat.CommandResult {
+		return seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: token}
+	}
+
+	// Another order takes 1B before this one, so 1A-1C is given back for 1D-1F.
+	everySeat := seat.AllSeatIDs()
+	env.OnActivity(seatActivities.FreeSeatsActivity, mock.Anything, activities.FreeSeatsInput{FlightID: input.FlightID}).
+		Return(everySeat, nil).Once()
+	env.OnActivity(seatActivities.FreeSeatsActivity, mock.Anything, activities.FreeSeatsInput{FlightID: input.FlightID}).
+		Return(slices.DeleteFunc(slices.Clone(everySeat), func(id string) bool { return id == "1B" }), nil).Once()
+	env.OnActivity(seatActivities.SeatBatchActivity, mock.Anything, batch(seat.CmdHold, nil, "1A", "1B", "1C")).
+		Return(activities.SeatBatchResult{Results: map[string]seat.CommandResult{
+			"1A": granted(1),
+			"1B": {Outcome: seat.OutcomeIgnored, Reason: "seat is held by test-order-other"},
+			"1C": granted(3),
+		}}, nil).Once()
+	env.OnActivity(seatActivities.SeatBatchActivity, mock.Anything, batch(seat.CmdRelease, map[string]int64{"1A": 1, "1C": 3}, "1A", "1C")).
+		Return(activities.SeatBatchResult{Results: map[string]seat.CommandResult{"1A": granted(0), "1C": granted(0)}}, nil).Once()
+	env.OnActivity(seatActivities.SeatBatchActivity, mock.Anything, batch(seat.CmdHold, nil, "1D", "1E", "1F")).
+		Return(activities.SeatBatchResult{Results: map[string]seat.CommandResult{"1D": granted(4), "1E": granted(5), "1F": granted(6)}}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).Return(seat.CommandResult{}, nil).Maybe()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Equal([]string{"1D", "1E", "1F"}, st.Seats)
+		s.Equal(map[string]int64{"1D": 4, "1E": 5, "1F": 6}, st.HoldTokens)
+		s.Empty(st.SeatsError)
+	}, time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_AssignsAdjacentSeatsBeforeBatchVersion() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.SeatBatchActivity)
+	env.RegisterActivity(seatActivities.FreeSeatsActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-assign-v0", FlightID: "test-flight-assign-v0", AssignSeats: 2}
+
+	// An order started before the batch replays holding the block seat by seat.
+	env.OnGetVersion("seat-batch", workflow.DefaultVersion, 1).Return(workflow.DefaultVersion)
+	env.OnActivity(seatActivities.FreeSeatsActivity, mock.Anything, activities.FreeSeatsInput{FlightID: input.FlightID}).
+		Return(seat.AllSeatIDs(), nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+		return in.Cmd.Type == seat.CmdHold
+	})).Return(func(_ context.Context, in activities.SeatSignalInput) (seat.CommandResult, error) {
+		return seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: seatToken(in.SeatID)}, nil
+	}).Twice()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).Return(seat.CommandResult{}, nil).Maybe()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Equal([]string{"1A", "1B"}, st.Seats)
+		s.Equal(map[string]int64{"1A": seatToken("1A"), "1B": seatToken("1B")}, st.HoldTokens)
+		env.CancelWorkflow()
+	}, time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+	env.AssertActivityNotCalled(s.T(), "SeatBatchActivity", mock.Anything, mock.Anything)
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_SeatBatchResumesOnRetry() {
+	// The real SeatBatchActivity holds the block; 1B does not answer the
+	// first time, and the retry only sends its command again.
+	temporal := &mocks.Client{}
+	expectHold := func(seatID string, token int64, err error) {
+		start := &startOperation{seatID: seatID}
+		temporal.On("NewWithStartWorkflowOperation", mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			return opts.ID == seat.WorkflowID("test-flight-batch", seatID)
+		}), mock.Anything, "test-flight-batch", seatID, (*seat.SeatPersistedState)(nil)).Return(start).Once()
+		handle := &mocks.WorkflowUpdateHandle{}
+		handle.On("Get", mock.Anything, mock.Anything).Return(func(_ context.Context, valuePtr interface{}) error {
+			if err == nil {
+				*valuePtr.(*seat.CommandResult) = seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: token}
+			}
+			return err
+		}).Once()
+		temporal.On("UpdateWithStartWorkflow", mock.Anything, mock.MatchedBy(func(opts client.UpdateWithStartWorkflowOptions) bool {
+			return opts.StartWorkflowOperation == start && opts.UpdateOptions.Args[0].(seat.Command).Type == seat.CmdHold
+		})).Return(handle, nil).Once()
+	}
+	expectHold("1A", 1, nil)
+	expectHold("1B", 0, errors.New("update timed out"))
+	expectHold("1C", 3, nil)
+	expectHold("1B", 2, nil)
+
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(activities.NewSeatActivities(temporal))
+
+	input := workflows.OrderInput{OrderID: "test-order-batch", FlightID: "test-flight-batch", AssignSeats: 3}
+	env.OnActivity(seatActivities.FreeSeatsActivity, mock.Anything, mock.Anything).Return([]string{"1A", "1B", "1C"}, nil).Once()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+	var attempts []int32
+	env.SetOnActivityStartedListener(func(info *activity.Info, _ context.Context, _ converter.EncodedValues) {
+		if info.ActivityType.Name == "SeatBatchActivity" {
+			attempts = append(attempts, info.Attempt)
+		}
+	})
+
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Equal([]string{"1A", "1B", "1C"}, st.Seats)
+		s.Equal(map[string]int64{"1A": 1, "1B": 2, "1C": 3}, st.HoldTokens)
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	s.Equal([]int32{1, 2}, attempts)
+	temporal.AssertExpectations(s.T())
+}
+
+// startOperation tells the start operations of the mocked seats apart.
+type startOperation struct {
+	client.WithStartWorkflowOperation
+	seatID string
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_FallsBackToSelectionWithoutAdjacentSeats() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.FailOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(seatActivities.FreeSeatsActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-no-block", FlightID: "test-flight-no-block", AssignSeats: 2}
+	env.OnActivity(seatActivities.FreeSeatsActivity, mock.Anything, mock.Anything).Return([]string{"1A", "2C", "4F"}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 1}, nil).Maybe()
+	env.OnActivity(activities.FailOrderActivity, mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("PENDING", st.State)
+		s.Equal("no 2 adjacent seats are free; select seats instead", st.SeatsError)
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "2C"})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("SEATS_SELECTED", st.State)
+		s.Equal([]string{"1A", "2C"}, st.Seats)
+		s.Empty(st.SeatsError)
+	}, 2*time.Second)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_ConfirmedOrderDropsRevokedSeat() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.ConfirmOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(activities.ValidatePaymentActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-unconfirmed", FlightID: "test-flight-unconfirmed"}
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, mock.Anything).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 1}, nil)
+	env.OnActivity(activities.ValidatePaymentActivity, mock.Anything, input.OrderID, "12345").Return("PAYMENT_SUCCESSFUL", nil).Once()
+	env.OnActivity(activities.ConfirmOrderActivity, mock.Anything, input.OrderID).Return(nil).Once()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A", "1B"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.SubmitPaymentSignal, "12345")
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("CONFIRMED", status().State)
+		// An administrator unconfirms 1B and reassigns the confirmed 3D to the
+		// order; a late grant of a hold changes nothing.
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "1B", Event: seat.WaitlistRevoked})
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "2C", Event: seat.WaitlistGranted, HoldToken: 3, Reassigned: true})
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "3D", Event: seat.WaitlistReassigned})
+	}, time.Minute)
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal("CONFIRMED", st.State)
+		s.Equal([]string{"1A", "3D"}, st.Seats)
+		s.Equal("seat 1B was taken back by an administrator", st.SeatsError)
+		env.CancelWorkflow()
+	}, 2*time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
+
+func (s *OrderWorkflowTestSuite) TestOrderWorkflow_AdoptsReassignedSeat() {
+	env := s.NewTestWorkflowEnvironment()
+	env.RegisterActivity(activities.ConfirmOrderActivity)
+	env.RegisterActivity(seatActivities.SeatSignalActivity)
+	env.RegisterActivity(activities.ValidatePaymentActivity)
+
+	input := workflows.OrderInput{OrderID: "test-order-reassigned", FlightID: "test-flight-reassigned"}
+	command := func(seatID string, t seat.CommandType, token int64) interface{} {
+		return mock.MatchedBy(func(in activities.SeatSignalInput) bool {
+			return in.SeatID == seatID && in.Cmd.Type == t && in.Cmd.HoldToken == token
+		})
+	}
+
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("1A", seat.CmdHold, 0)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied, HoldToken: 4}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("1A", seat.CmdConfirm, 4)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied}, nil).Once()
+	env.OnActivity(seatActivities.SeatSignalActivity, mock.Anything, command("2B", seat.CmdConfirm, 9)).
+		Return(seat.CommandResult{Outcome: seat.OutcomeApplied}, nil).Once()
+	env.OnActivity(activities.ValidatePaymentActivity, mock.Anything, input.OrderID, "12345").Return("PAYMENT_SUCCESSFUL", nil).Once()
+	env.OnActivity(activities.ConfirmOrderActivity, mock.Anything, input.OrderID).Return(nil).Once()
+
+	status := func() workflows.OrderState {
+		res, err := env.QueryWorkflow(workflows.GetStatusQuery)
+		s.NoError(err)
+		var st workflows.OrderState
+		s.NoError(res.Get(&st))
+		return st
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.UpdateSeatsSignal, []string{"1A"})
+	}, 0)
+	env.RegisterDelayedCallback(func() {
+		// A late grant of a seat the order gave up stays ignored; an
+		// administrator's reassignment joins the selection.
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "3C", Event: seat.WaitlistGranted, HoldToken: 2})
+		env.SignalWorkflow(seat.WaitlistSignal, seat.WaitlistNotice{SeatID: "2B", Event: seat.WaitlistGranted, HoldToken: 9, Reassigned: true})
+	}, time.Second)
+	env.RegisterDelayedCallback(func() {
+		st := status()
+		s.Equal([]string{"1A", "2B"}, st.Seats)
+		s.Equal(map[string]int64{"1A": 4, "2B": 9}, st.HoldTokens)
+		env.SignalWorkflow(workflows.SubmitPaymentSignal, "12345")
+	}, 2*time.Second)
+	env.RegisterDelayedCallback(func() {
+		s.Equal("CONFIRMED", status().State)
+		env.CancelWorkflow()
+	}, time.Minute)
+
+	env.ExecuteWorkflow(workflows.OrderOrchestrationWorkflow, input)
+
+	s.True(env.IsWorkflowCompleted())
+	env.AssertExpectations(s.T())
+}
@@ -0,0 +1,310 @@
+// Package client is a typed Go client for the temporal-seats reservation API.
+//
+// It talks to the /v1 HTTP API, retries transient failures, turns problem
+// responses into *APIError values and can follow an order's state over SSE:
+//
+//	c := client.New("http://localhost:8080", client.WithTimeout(5*time.Second))
+//	order, err := c.CreateOrder(ctx, client.CreateOrderRequest{FlightID: "F-100"})
+//	if err != nil { ... }
+//	sub := c.SubscribeOrder(ctx, order.OrderID)
+//	defer sub.Close()
+//	for state := range sub.C { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+)
+
+// Request and response types of the API.
+type (
+	CreateOrderRequest  = domain.CreateOrderRequest
+	CreateOrderResponse = domain.CreateOrderResponse
+	OrderState          = domain.GetStatusResponse
+	FlightAvailability  = domain.FlightAvailability
+	QueueTicket         = domain.QueueTicket
+	SeatHistory         = domain.SeatHistory
+	OrderTimeline       = domain.OrderTimeline
+	Problem             = domain.Problem
+)
+
+// Client calls the reservation API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	token      string
+	httpClient *http.Client
+	timeout    time.Duration
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client. Its Timeout should be zero,
+// since it would also cut off SSE subscriptions; use WithTimeout instead.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithTimeout sets the timeout of each request attempt (default 10s). Long-poll
+// waits are added on top of it; it does not apply to SSE subscriptions.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+// WithRetries sets how often a failed request is retried (default 3) and the
+// initial backoff between attempts (default 200ms), which doubles per attempt.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithAPIKey authenticates every request with an API key.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithBearerToken authenticates every request with a JWT bearer token.
+func WithBearerToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// New creates a Client for the API at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{},
+		timeout:    10 * time.Second,
+		maxRetries: 3,
+		backoff:    200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CreateOrder starts a new order. OrderID is optional; the server generates one when empty.
+func (c *Client) CreateOrder(ctx context.Context, req CreateOrderRequest) (CreateOrderResponse, error) {
+	var resp CreateOrderResponse
+	_, err := c.do(ctx, http.MethodPost, "/v1/orders", req, nil, 0, &resp)
+	return resp, err
+}
+
+// UpdateSeats selects or changes the seats of an order.
+func (c *Client) UpdateSeats(ctx context.Context, orderID string, seats []string) error {
+	_, err := c.do(ctx, http.MethodPost, orderPath(orderID, "seats"), domain.UpdateSeatsRequest{Seats: seats}, nil, 0, nil)
+	return err
+}
+
+// SubmitPayment submits a 5-digit payment code for an order.
+func (c *Client) SubmitPayment(ctx context.Context, orderID, code string) error {
+	_, err := c.do(ctx, http.MethodPost, orderPath(orderID, "payment"), domain.SubmitPaymentRequest{Code: code}, nil, 0, nil)
+	return err
+}
+
+// GetStatus returns the current state of an order.
+func (c *Client) GetStatus(ctx context.Context, orderID string) (OrderState, error) {
+	var state OrderState
+	_, err := c.do(ctx, http.MethodGet, orderPath(orderID, "status"), nil, nil, 0, &state)
+	return state, err
+}
+
+// GetTimeline returns what happened to an order: the signals it received,
+// the activities it ran and how it ended. Only the order's owner and admins
+// may read it.
+func (c *Client) GetTimeline(ctx context.Context, orderID string) (OrderTimeline, error) {
+	var timeline OrderTimeline
+	_, err := c.do(ctx, http.MethodGet, orderPath(orderID, "timeline"), nil, nil, 0, &timeline)
+	return timeline, err
+}
+
+// WaitForChange long-polls an order: it blocks for up to wait (max 60s) until
+// the order moves past version and returns the new state. changed is false if
+// the order did not change in time, in which case state is the zero value.
+func (c *Client) WaitForChange(ctx context.Context, orderID string, version int64, wait time.Duration) (state OrderState, changed bool, err error) {
+	path := orderPath(orderID, "status") + "?wait=" + url.QueryEscape(wait.String())
+	header := http.Header{"If-None-Match": {`"v1-` + strconv.FormatInt(version, 10) + `"`}}
+	status, err := c.do(ctx, http.MethodGet, path, nil, header, wait, &state)
+	if err != nil || status == http.StatusNotModified {
+		return OrderState{}, false, err
+	}
+	return state, true, nil
+}
+
+// GetFlightAvailability groups the seats of a flight by their current state.
+func (c *Client) GetFlightAvailability(ctx context.Context, flightID string) (FlightAvailability, error) {
+	var avail FlightAvailability
+	_, err := c.do(ctx, http.MethodGet, "/v1/flights/"+url.PathEscape(flightID)+"/available-seats", nil, nil, 0, &avail)
+	return avail, err
+}
+
+// GetSeatHistory returns the latest commands a seat processed, oldest first,
+// with whether each was applied or ignored and why.
+func (c *Client) GetSeatHistory(ctx context.Context, flightID, seatID string) (SeatHistory, error) {
+	var history SeatHistory
+	path := "/v1/flights/" + url.PathEscape(flightID) + "/seats/" + url.PathEscape(seatID) + "/history"
+	_, err := c.do(ctx, http.MethodGet, path, nil, nil, 0, &history)
+	return history, err
+}
+
+// JoinQueue joins the waiting room of a flight. Orders for flights behind a
+// waiting room need the TicketID once GetTicket reports it "admitted".
+func (c *Client) JoinQueue(ctx context.Context, flightID string) (QueueTicket, error) {
+	var ticket QueueTicket
+	_, err := c.do(ctx, http.MethodPost, queuePath(flightID, ""), nil, nil, 0, &ticket)
+	return ticket, err
+}
+
+// GetTicket returns the state of a waiting room ticket and its queue position.
+func (c *Client) GetTicket(ctx context.Context, flightID, ticketID string) (QueueTicket, error) {
+	var ticket QueueTicket
+	_, err := c.do(ctx, http.MethodGet, queuePath(flightID, ticketID), nil, nil, 0, &ticket)
+	return ticket, err
+}
+
+func queuePath(flightID, ticketID string) string {
+	path := "/v1/flights/" + url.PathEscape(flightID) + "/queue"
+	if ticketID != "" {
+		path += "/" + url.PathEscape(ticketID)
+	}
+	return path
+}
+
+func orderPath(orderID, action string) string {
+	return "/v1/orders/" + url.PathEscape(orderID) + "/" + action
+}
+
+// do sends a request, retrying transient failures, and decodes a 2xx JSON
+// response into out. It returns the final HTTP status code.
+func (c *Client) do(ctx context.Context, method, path string, body any, header http.Header, extraTimeout time.Duration, out any) (int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		status, retryAfter, err := c.attempt(ctx, method, path, payload, header, extraTimeout, out)
+		if err == nil || attempt >= c.maxRetries || !retryable(method, err) || ctx.Err() != nil {
+			return status, err
+		}
+		if err := c.sleep(ctx, attempt, retryAfter); err != nil {
+			return status, err
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, method, path string, payload []byte, header http.Header, extraTimeout time.Duration, out any) (int, time.Duration, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout+extraTimeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, 0, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	c.setCredentials(req)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, 0, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, retryAfter(resp), newAPIError(resp)
+	}
+	if out != nil && resp.StatusCode != http.StatusNotModified {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, 0, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, 0, nil
+}
+
+func (c *Client) setCredentials(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+}
+
+// retryable reports whether a failed request may be sent again. Signals are not
+// idempotent, so requests that might have reached the server are only retried
+// for GETs; any method is retried when the server reports it did not act.
+func retryable(method string, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return method == http.MethodGet
+		}
+		return false
+	}
+	var tErr *transportError
+	return errors.As(err, &tErr) && method == http.MethodGet
+}
+
+// sleep waits before the next attempt: the server's Retry-After if given,
+// otherwise exponential backoff with jitter.
+func (c *Client) sleep(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	delay := retryAfter
+	if delay <= 0 {
+		delay = min(c.backoff<<attempt, c.maxBackoff)
+		delay = delay/2 + rand.N(delay/2+1)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
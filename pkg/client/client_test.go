@@ -0,0 +1,299 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func writeProblem(w http.ResponseWriter, status int, code, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(domain.Problem{Status: status, Code: code, Detail: detail})
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(server.URL, WithTimeout(2*time.Second), WithRetries(3, time.Millisecond))
+}
+
+func TestClient_CreateOrder(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST", r.Method)
+		require.Equal(t, "/v1/orders", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req domain.CreateOrderRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "F-100", req.FlightID)
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(domain.CreateOrderResponse{OrderID: "o-1"})
+	})
+
+	resp, err := c.CreateOrder(context.Background(), CreateOrderRequest{FlightID: "F-100"})
+	require.NoError(t, err)
+	require.Equal(t, "o-1", resp.OrderID)
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeProblem(w, http.StatusNotFound, domain.CodeOrderNotFound, "Order not found")
+	})
+
+	_, err := c.GetStatus(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrOrderNotFound)
+	require.NotErrorIs(t, err, ErrConflict)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	require.Equal(t, "Order not found", apiErr.Problem.Detail)
+	require.EqualValues(t, 1, calls.Load(), "not found must not be retried")
+}
+
+func TestClient_HoldLimitErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusConflict, domain.CodeHoldLimitExceeded, "A customer can hold at most 10 seats on this flight")
+	})
+
+	err := c.UpdateSeats(context.Background(), "o-1", []string{"1A"})
+	require.ErrorIs(t, err, ErrHoldLimitExceeded)
+	require.NotErrorIs(t, err, ErrConflict)
+}
+
+func TestClient_WaitingRoom(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/flights/F-100/queue":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(domain.QueueTicket{TicketID: "t-1", FlightID: "F-100", State: "waiting", Position: 2})
+		case "GET /v1/flights/F-100/queue/t-1":
+			json.NewEncoder(w).Encode(domain.QueueTicket{TicketID: "t-1", FlightID: "F-100", State: "waiting", Position: 1})
+		case "POST /v1/orders":
+			var req domain.CreateOrderRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			require.Equal(t, "t-1", req.TicketID)
+			writeProblem(w, http.StatusConflict, domain.CodeTicketNotAdmitted, "Ticket cannot create an order: ticket has not been admitted yet")
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	ticket, err := c.JoinQueue(ctx, "F-100")
+	require.NoError(t, err)
+	require.Equal(t, 2, ticket.Position)
+
+	ticket, err = c.GetTicket(ctx, "F-100", ticket.TicketID)
+	require.NoError(t, err)
+	require.Equal(t, 1, ticket.Position)
+
+	_, err = c.CreateOrder(ctx, CreateOrderRequest{FlightID: "F-100", TicketID: ticket.TicketID})
+	require.ErrorIs(t, err, ErrTicketNotAdmitted)
+}
+
+func TestClient_GetSeatHistory(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/flights/F-100/seats/1A/history":
+			json.NewEncoder(w).Encode(domain.SeatHistory{FlightID: "F-100", SeatID: "1A", Entries: []domain.SeatHistoryEntry{
+				{At: at, Command: "HOLD", OrderID: "o-1", Outcome: "applied", HeldBy: "o-1"},
+				{At: at, Command: "HOLD", OrderID: "o-2", Outcome: "ignored", Reason: "seat is held by o-1", HeldBy: "o-1"},
+			}})
+		default:
+			writeProblem(w, http.StatusNotFound, domain.CodeSeatNotFound, "Seat not found")
+		}
+	})
+	ctx := context.Background()
+
+	history, err := c.GetSeatHistory(ctx, "F-100", "1A")
+	require.NoError(t, err)
+	require.Len(t, history.Entries, 2)
+	require.Equal(t, "ignored", history.Entries[1].Outcome)
+	require.Equal(t, "seat is held by o-1", history.Entries[1].Reason)
+
+	_, err = c.GetSeatHistory(ctx, "F-100", "9F")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, domain.CodeSeatNotFound, apiErr.Problem.Code)
+}
+
+func TestClient_GetTimeline(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/orders/o-1/timeline":
+			json.NewEncoder(w).Encode(domain.OrderTimeline{OrderID: "o-1", State: "CONFIRMED", Events: []domain.TimelineEvent{
+				{At: start, Kind: "workflow", Name: "started", Summary: "Order started"},
+				{At: start.Add(time.Second), Kind: "signal", Name: "SubmitPayment", Summary: "Payment code *****", Input: "*****"},
+			}})
+		default:
+			writeProblem(w, http.StatusForbidden, domain.CodeForbidden, "Order belongs to another caller")
+		}
+	})
+	ctx := context.Background()
+
+	timeline, err := c.GetTimeline(ctx, "o-1")
+	require.NoError(t, err)
+	require.Equal(t, "CONFIRMED", timeline.State)
+	require.Len(t, timeline.Events, 2)
+	require.Equal(t, "SubmitPayment", timeline.Events[1].Name)
+	require.Equal(t, "*****", timeline.Events[1].Input)
+
+	_, err = c.GetTimeline(ctx, "o-2")
+	require.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestClient_RetriesUnavailable(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			writeProblem(w, http.StatusServiceUnavailable, domain.CodeServiceUnavailable, "Temporal is unavailable")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	require.NoError(t, c.SubmitPayment(context.Background(), "o-1", "12345"))
+	require.EqualValues(t, 3, calls.Load())
+}
+
+func TestClient_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		writeProblem(w, http.StatusServiceUnavailable, domain.CodeServiceUnavailable, "Temporal is unavailable")
+	})
+
+	err := c.UpdateSeats(context.Background(), "o-1", []string{"1A"})
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.EqualValues(t, 4, calls.Load())
+}
+
+func TestClient_HonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			writeProblem(w, http.StatusTooManyRequests, domain.CodeRateLimited, "Too many requests, slow down")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	start := time.Now()
+	require.NoError(t, c.UpdateSeats(context.Background(), "o-1", []string{"1A"}))
+	require.EqualValues(t, 2, calls.Load())
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	c := New(server.URL, WithTimeout(50*time.Millisecond), WithRetries(0, 0))
+
+	start := time.Now()
+	_, err := c.GetFlightAvailability(context.Background(), "F-100")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestClient_WaitForChange(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "30s", r.URL.Query().Get("wait"))
+		if r.Header.Get("If-None-Match") == `"v1-2"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode(domain.GetStatusResponse{State: "CONFIRMED", Version: 3})
+	})
+
+	_, changed, err := c.WaitForChange(context.Background(), "o-1", 2, 30*time.Second)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	state, changed, err := c.WaitForChange(context.Background(), "o-1", 1, 30*time.Second)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, "CONFIRMED", state.State)
+}
+
+func TestClient_SubscribeOrderReconnects(t *testing.T) {
+	var connections atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/orders/o-1/events", r.URL.Path)
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		// The first connection drops after version 1; the resumed one must ask for later versions.
+		if connections.Add(1) == 1 {
+			require.Empty(t, r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, ": keep-alive\n\nid: 1\nevent: state\ndata: {\"state\":\"PENDING\",\"version\":1}\n\n")
+			return
+		}
+		require.Equal(t, "1", r.Header.Get("Last-Event-ID"))
+		fmt.Fprint(w, "id: 2\nevent: state\ndata: {\"state\":\"SEATS_SELECTED\",\"seats\":[\"1A\"],\"version\":2}\n\n")
+		fmt.Fprint(w, "id: 2\nevent: payment\ndata: {\"paymentStatus\":\"trying\",\"attemptsLeft\":3}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	sub := c.SubscribeOrder(context.Background(), "o-1")
+	defer sub.Close()
+
+	first := <-sub.C
+	require.Equal(t, "PENDING", first.State)
+	second := <-sub.C
+	require.Equal(t, "SEATS_SELECTED", second.State)
+	require.Equal(t, []string{"1A"}, second.Seats)
+	require.EqualValues(t, 2, second.Version)
+
+	sub.Close()
+	_, ok := <-sub.C
+	require.False(t, ok)
+	require.NoError(t, sub.Err())
+}
+
+func TestClient_SubscribeOrderNotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, http.StatusNotFound, domain.CodeOrderNotFound, "Order not found")
+	})
+
+	sub := c.SubscribeOrder(context.Background(), "missing")
+	select {
+	case _, ok := <-sub.C:
+		require.False(t, ok)
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscription did not end")
+	}
+	require.True(t, errors.Is(sub.Err(), ErrOrderNotFound))
+}
+
+func TestClient_SendsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "key-a", r.Header.Get("X-API-Key"))
+		require.Equal(t, "Bearer token-a", r.Header.Get("Authorization"))
+		writeProblem(w, http.StatusForbidden, domain.CodeForbidden, "Order belongs to another caller")
+	}))
+	defer server.Close()
+	c := New(server.URL, WithAPIKey("key-a"), WithBearerToken("token-a"))
+
+	_, err := c.GetStatus(context.Background(), "o-1")
+	require.ErrorIs(t, err, ErrForbidden)
+}
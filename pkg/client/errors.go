@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/EyalShahaf/temporal-seats/internal/domain"
+)
+
+// Sentinel errors matched by errors.Is against an *APIError with the corresponding code.
+var (
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrOrderAlreadyExists = errors.New("order already exists")
+	ErrConflict           = errors.New("conflict")
+	ErrTooManySeats       = errors.New("too many seats for one order")
+	ErrHoldLimitExceeded  = errors.New("customer hold limit exceeded")
+	ErrTicketRequired     = errors.New("waiting room ticket required")
+	ErrTicketNotFound     = errors.New("waiting room ticket not found")
+	ErrTicketNotAdmitted  = errors.New("waiting room ticket not admitted")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrUnavailable        = errors.New("service unavailable")
+)
+
+var sentinels = map[string]error{
+	domain.CodeInvalidRequest:     ErrInvalidRequest,
+	domain.CodeInvalidOrderID:     ErrInvalidRequest,
+	domain.CodeUnauthorized:       ErrUnauthorized,
+	domain.CodeForbidden:          ErrForbidden,
+	domain.CodeOrderNotFound:      ErrOrderNotFound,
+	domain.CodeOrderAlreadyExists: ErrOrderAlreadyExists,
+	domain.CodeConflict:           ErrConflict,
+	domain.CodeTooManySeats:       ErrTooManySeats,
+	domain.CodeHoldLimitExceeded:  ErrHoldLimitExceeded,
+	domain.CodeTicketRequired:     ErrTicketRequired,
+	domain.CodeTicketNotFound:     ErrTicketNotFound,
+	domain.CodeTicketNotAdmitted:  ErrTicketNotAdmitted,
+	domain.CodeRateLimited:        ErrRateLimited,
+	domain.CodeServiceUnavailable: ErrUnavailable,
+}
+
+// APIError is returned when the API responds with an error status. Problem
+// holds the decoded problem+json body; its Code is one of the stable codes in
+// internal/domain and is empty if the body was not a problem.
+type APIError struct {
+	StatusCode int
+	Problem    Problem
+}
+
+func (e *APIError) Error() string {
+	detail := e.Problem.Detail
+	if detail == "" {
+		detail = http.StatusText(e.StatusCode)
+	}
+	if e.Problem.Code == "" {
+		return fmt.Sprintf("temporal-seats: %d %s", e.StatusCode, detail)
+	}
+	return fmt.Sprintf("temporal-seats: %d %s (%s)", e.StatusCode, detail, e.Problem.Code)
+}
+
+// Is makes errors.Is(err, ErrOrderNotFound) and friends work.
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := sentinels[e.Problem.Code]
+	return ok && sentinel == target
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(body, &apiErr.Problem) != nil {
+		apiErr.Problem = Problem{Status: resp.StatusCode, Detail: string(body)}
+	}
+	return apiErr
+}
+
+// transportError is a failure to get any response from the API.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string { return "temporal-seats: " + e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
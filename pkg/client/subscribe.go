@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/EyalShahaf/temporal-seats/internal/realtime/sse"
+)
+
+// Subscription delivers the state of an order every time it changes, until it
+// is closed, its context is done or the order cannot be streamed.
+type Subscription struct {
+	// C receives every new order state. It is closed when the subscription ends.
+	C <-chan OrderState
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Close ends the subscription and waits for its goroutine to exit.
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// Err returns the error that ended the subscription once C is closed, or nil
+// if it was ended by Close or its context.
+func (s *Subscription) Err() error {
+	<-s.done
+	return s.err
+}
+
+// SubscribeOrder follows the order's SSE stream. Dropped connections are
+// re-established with backoff, resuming after the last received version so no
+// state is delivered twice. Errors the API reports as permanent (such as
+// ErrOrderNotFound) end the subscription; see Err.
+func (c *Client) SubscribeOrder(ctx context.Context, orderID string) *Subscription {
+	ctx, cancel := context.WithCancel(ctx)
+	ch := make(chan OrderState, 1)
+	s := &Subscription{C: ch, cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+		defer close(ch)
+		defer cancel()
+
+		lastEventID := ""
+		for attempt := 0; ; attempt++ {
+			received, err := c.stream(ctx, orderID, &lastEventID, ch)
+			if ctx.Err() != nil {
+				return
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && !retryable(http.MethodGet, err) {
+				s.err = err
+				return
+			}
+			if received {
+				attempt = 0
+			}
+			if c.sleep(ctx, attempt, 0) != nil {
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// stream reads one SSE connection until it ends, sending every state event to
+// ch. It reports whether any state was received.
+func (c *Client) stream(ctx context.Context, orderID string, lastEventID *string, ch chan<- OrderState) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+orderPath(orderID, "events"), nil)
+	if err != nil {
+		return false, err
+	}
+	c.setCredentials(req)
+	req.Header.Set("Accept", "text/event-stream")
+	if *lastEventID != "" {
+		req.Header.Set("Last-Event-ID", *lastEventID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return false, newAPIError(resp)
+	}
+
+	received := false
+	events := sse.NewReader(resp.Body)
+	for {
+		ev, err := events.Next()
+		if err != nil {
+			return received, err
+		}
+		if ev.Name != "state" {
+			continue
+		}
+
+		var state OrderState
+		if err := json.Unmarshal(ev.Data.(json.RawMessage), &state); err != nil {
+			return received, fmt.Errorf("decode order state: %w", err)
+		}
+		select {
+		case ch <- state:
+		case <-ctx.Done():
+			return received, ctx.Err()
+		}
+		received = true
+		if ev.ID != "" {
+			*lastEventID = ev.ID
+		}
+	}
+}
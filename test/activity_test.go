@@ -2,26 +2,21 @@ package test
 
 import (
 	"context"
-	"os"
 	"testing"
 	"time"
 
 	"github.com/EyalShahaf/temporal-seats/internal/activities"
 	"github.com/EyalShahaf/temporal-seats/internal/entities/seat"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
 )
 
 func TestSeatSignalActivity(t *testing.T) {
 	t.Skip()
-	// Set up environment variables for the activity
-	os.Setenv("TEMPORAL_HOSTPORT", "localhost:7233")
-	os.Setenv("TEMPORAL_NAMESPACE", "default")
-	os.Setenv("SEAT_TASK_QUEUE", "seat-tq")
-	defer func() {
-		os.Unsetenv("TEMPORAL_HOSTPORT")
-		os.Unsetenv("TEMPORAL_NAMESPACE")
-		os.Unsetenv("SEAT_TASK_QUEUE")
-	}()
+	c, err := client.Dial(client.Options{HostPort: "localhost:7233", Namespace: "default"})
+	require.NoError(t, err)
+	defer c.Close()
 
 	ctx := context.Background()
 
@@ -37,7 +32,7 @@ func TestSeatSignalActivity(t *testing.T) {
 	}
 
 	// Execute the activity
-	err := activities.SeatSignalActivity(ctx, input)
+	_, err = activities.NewSeatActivities(c).SeatSignalActivity(ctx, input)
 
 	// The activity should succeed (it will start the seat entity workflow)
 	assert.NoError(t, err, "SeatSignalActivity should succeed")
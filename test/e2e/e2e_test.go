@@ -16,6 +16,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/EyalShahaf/temporal-seats/internal/activities"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -246,6 +247,8 @@ func (s *E2ETestSuite) startWorker(t *testing.T) error {
 
 	cmd := exec.Command("go", "run", "cmd/worker/main.go")
 	cmd.Dir = "."
+	// The tests pay with the fixed codes of activities.ValidatePaymentWithTestCodes.
+	cmd.Env = append(os.Environ(), "PAYMENT_TEST_CODES=true")
 
 	// Capture output for debugging
 	var stdout, stderr bytes.Buffer
@@ -340,7 +343,7 @@ func TestFullOrderFlow(t *testing.T) {
 	// Test data
 	orderID := fmt.Sprintf("e2e-test-%d", time.Now().Unix())
 	flightID := "E2E-FL001"
-	paymentCode := "E2E-PAY-123"
+	paymentCode := "12345"
 
 	t.Run("Create Order", func(t *testing.T) {
 		t.Log("📝 Testing order creation...")
@@ -530,7 +533,6 @@ func TestErrorHandling(t *testing.T) {
 	})
 
 	t.Run("Invalid Payment Code", func(t *testing.T) {
-		t.Skip()
 		t.Log("❌ Testing invalid payment code handling...")
 
 		// Create order first
@@ -569,51 +571,64 @@ func TestErrorHandling(t *testing.T) {
 		// Wait a moment for seats to be processed
 		time.Sleep(2 * time.Second)
 
-		// Submit invalid payment
-		paymentData := map[string]interface{}{
+		// Malformed codes are rejected by request validation before reaching the workflow.
+		resp, err = suite.makeRequest(t, "POST", fmt.Sprintf("/orders/%s/payment", orderID), map[string]interface{}{
 			"code": "INVALID-PAYMENT",
-		}
-
-		t.Logf("Submitting payment with code: %s", paymentData["code"])
-
-		resp, err = suite.makeRequest(t, "POST", fmt.Sprintf("/orders/%s/payment", orderID), paymentData)
+		})
 		require.NoError(t, err)
-		require.Equal(t, 200, resp.StatusCode)
+		require.Equal(t, 400, resp.StatusCode)
 		defer resp.Body.Close()
 
-		// Wait for failure - need to wait for all retry attempts to complete
-		time.Sleep(15 * time.Second)
-
-		// Check final state
-		resp, err = suite.makeRequest(t, "GET", fmt.Sprintf("/orders/%s/status", orderID), nil)
-		require.NoError(t, err)
-		require.Equal(t, 200, resp.StatusCode)
-		defer resp.Body.Close()
-
-		var status map[string]any
-		err = json.NewDecoder(resp.Body).Decode(&status)
+		var problem map[string]any
+		err = json.NewDecoder(resp.Body).Decode(&problem)
 		require.NoError(t, err)
+		assert.Equal(t, "invalid_request", problem["code"])
 
-		t.Logf("Final status response: %+v", status)
+		// A declined payment uses up one attempt; the order fails once all are used.
+		paymentData := map[string]interface{}{
+			"code": activities.TestPaymentCodeDeclined,
+		}
+		t.Logf("Submitting payment with code: %s", paymentData["code"])
+		for attempt := 1; attempt <= 3; attempt++ {
+			resp, err = suite.makeRequest(t, "POST", fmt.Sprintf("/orders/%s/payment", orderID), paymentData)
+			require.NoError(t, err)
+			require.Equal(t, 200, resp.StatusCode)
+			resp.Body.Close()
+			time.Sleep(2 * time.Second)
+		}
 
-		// handle either nested or flat shape
+		// Wait for failure
 		var final string
-		if o, ok := status["order"].(map[string]any); ok {
-			if s, ok := o["state"].(string); ok {
-				final = s
+		require.Eventually(t, func() bool {
+			resp, err := suite.makeRequest(t, "GET", fmt.Sprintf("/orders/%s/status", orderID), nil)
+			if err != nil {
+				return false
 			}
-		}
-		if final == "" {
-			if s, ok := status["state"].(string); ok {
+			defer resp.Body.Close()
+
+			var status map[string]any
+			if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+				return false
+			}
+			t.Logf("Final status response: %+v", status)
+
+			// handle either nested or flat shape
+			final = ""
+			if o, ok := status["order"].(map[string]any); ok {
+				if s, ok := o["state"].(string); ok {
+					final = s
+				}
+			}
+			if s, ok := status["state"].(string); ok && final == "" {
 				final = s
 			}
-			if s, ok := status["State"].(string); ok {
+			if s, ok := status["State"].(string); ok && final == "" {
 				final = s
 			}
-		}
+			return final == "FAILED"
+		}, 20*time.Second, time.Second, "order did not fail after its payment attempts were declined")
 
 		t.Logf("Final state: %s", final)
-		assert.Equal(t, "FAILED", final)
 
 		t.Log("✅ Invalid payment handled correctly")
 	})
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/EyalShahaf/temporal-seats/internal/activities"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -46,7 +47,7 @@ func TestPermanentSeatLocking(t *testing.T) {
 	baseURL := "http://localhost:8080"
 	flightID := fmt.Sprintf("FL-%d", time.Now().UnixNano()) // Unique flight ID
 	seats := []string{"1A", "2A"}
-	paymentCode := "E2E-OK" // Deterministic payment for E2E
+	paymentCode := activities.TestPaymentCodeApproved // needs a worker running with PAYMENT_TEST_CODES=true
 
 	// Step 1: Create an order
 	t.Log("Step 1: Creating order...")
@@ -13,6 +13,7 @@ interface SeatAvailability {
   available: string[];
   held: string[];
   confirmed: string[];
+  blocked?: string[]; // taken out of sale by operations
 }
 
 const SEAT_ROWS = 5;
@@ -71,8 +72,8 @@ const SeatGrid: React.FC<SeatGridProps> = ({ selectedSeats, onSeatsChanged, isLo
     
     // Check if seat is unavailable (held or confirmed by others)
     const seatState = getSeatState(seatId);
-    if (seatState === 'held-by-others' || seatState === 'confirmed-by-others') {
-      return; // Don't allow clicking on seats held/confirmed by others
+    if (seatState === 'held-by-others' || seatState === 'confirmed-by-others' || seatState === 'blocked') {
+      return; // Don't allow clicking on seats held/confirmed by others or blocked
     }
     
     // Prevent rapid clicking on the SAME seat (debounce per seat)
@@ -115,6 +116,9 @@ const SeatGrid: React.FC<SeatGridProps> = ({ selectedSeats, onSeatsChanged, isLo
       return 'confirmed-by-others';
     }
     
+    // Blocked seats are out of sale for everyone
+    if (seatAvailability.blocked?.includes(seatId)) return 'blocked';
+
     // Check if seat is held by anyone
     if (seatAvailability.held.includes(seatId)) {
       // If it's held by the current order, show as "held by you"
@@ -158,6 +162,8 @@ const SeatGrid: React.FC<SeatGridProps> = ({ selectedSeats, onSeatsChanged, isLo
               'bg-yellow-500/20 border-yellow-500 text-yellow-400 cursor-not-allowed': seatState === 'held-by-others',
               // Confirmed by others (red)
               'bg-red-500/20 border-red-500 text-red-400 cursor-not-allowed': seatState === 'confirmed-by-others',
+              // Blocked by operations (dashed gray)
+              'bg-gray-900 border-dashed border-gray-600 text-gray-600 line-through cursor-not-allowed': seatState === 'blocked',
               // Disabled states
               'bg-gray-800 text-gray-500 cursor-not-allowed border-gray-700': (isLocked || isConfirming) && seatState === 'available',
             }
@@ -223,6 +229,10 @@ const SeatGrid: React.FC<SeatGridProps> = ({ selectedSeats, onSeatsChanged, isLo
           <div className="w-3 h-3 bg-green-500/20 border border-green-500 rounded"></div>
           <span>Your confirmed</span>
         </div>
+        <div className="flex items-center gap-2">
+          <div className="w-3 h-3 bg-gray-900 border border-dashed border-gray-600 rounded"></div>
+          <span>Blocked</span>
+        </div>
       </div>
       
       {hasChanges && !isLocked && (
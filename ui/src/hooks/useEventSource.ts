@@ -22,7 +22,7 @@ export function useEventSource<T = any>(url: string, options: UseEventSourceOpti
       options.onOpen?.(event);
     };
 
-    eventSource.onmessage = (event) => {
+    const handleData = (event: MessageEvent) => {
       try {
         const parsedData = JSON.parse(event.data) as T;
         setData(parsedData);
@@ -33,6 +33,11 @@ export function useEventSource<T = any>(url: string, options: UseEventSourceOpti
       }
     };
 
+    // The API sends full state snapshots as named "state" events; unnamed
+    // messages are still accepted for compatibility.
+    eventSource.onmessage = handleData;
+    eventSource.addEventListener('state', handleData);
+
     eventSource.onerror = (event) => {
       setReadyState(EventSource.CLOSED);
       options.onError?.(event);